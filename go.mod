@@ -3,13 +3,13 @@ module github.com/realtobi999/GO_BankDemoApi
 go 1.22.0
 
 require (
+	github.com/go-chi/chi/v5 v5.0.12
 	github.com/go-chi/cors v1.2.1
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/urfave/negroni v1.0.0
+	golang.org/x/crypto v0.31.0
 )
 
-require (
-	github.com/go-chi/chi/v5 v5.0.12 // indirect
-	github.com/urfave/negroni v1.0.0 // indirect
-)
+require golang.org/x/sys v0.28.0 // indirect
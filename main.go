@@ -3,14 +3,34 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	exchangeAdapter "github.com/realtobi999/GO_BankDemoApi/src/adapters/exchange"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/repository"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/repository/migrations"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/web"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/account"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/auth"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/consent"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/credential"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/currency"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/exchange"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/fx"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/idempotency"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/interest"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/jobs"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/ledger"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/mandates"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/ratelimit"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/standingorders"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/transactions"
 )
 
@@ -41,18 +61,200 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Optionally seed historical exchange rates
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if err := exchangeAdapter.SeedFromCSV(path, database); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	systemClock := clock.NewSystemClock()
+
 	server := web.NewServer(":"+os.Getenv("SERVER_PORT"), chi.NewMux())
-	server.AccountService = account.NewAccountService(database, database)
-	server.CustomerService = customer.NewCustomerService(database)
-	server.TransactionService = transactions.NewTransactionService(database, database, database)
+	server.LedgerService = ledger.NewLedgerService(database, database)
+	server.CustomerService = customer.NewCustomerService(database, database, database, systemClock)
+	server.CredentialService = credential.NewCredentialService(database, database, database, systemClock)
+	server.ConsentService = consent.NewConsentService(database, database, systemClock)
+	server.AuthService = auth.NewAuthService(server.CustomerService, server.CredentialService, database, database, jwtKeySet(), systemClock)
+	server.ExchangeService = exchange.NewExchangeService(database, exchangeBaseCurrency())
+	server.CurrencyService = currency.NewCurrencyService(database, domain.Currencies)
+	server.FXService = fx.NewFXService(database, server.ExchangeService, server.CurrencyService, fxPricing(), systemClock)
+	server.TransactionService = transactions.NewTransactionService(database, database, database, server.LedgerService, server.FXService, server.CredentialService, server.CurrencyService, stepUpThreshold(), systemClock)
+	server.InterestService = interest.NewInterestService(database, database, server.TransactionService)
+	server.AccountService = account.NewAccountService(database, database, database, server.LedgerService, server.TransactionService, server.InterestService, server.CurrencyService, systemClock)
+	server.StandingOrderService = standingorders.NewStandingOrderService(database, database, database, server.TransactionService, server.CredentialService, standingOrderRetryPolicy(), systemClock)
+	server.MandateService = mandates.NewMandateService(database, database, database, server.TransactionService, server.CredentialService, mandateRefundDays(), systemClock)
+	server.JobService = jobs.NewJobService(database, systemClock)
+	server.IdempotencyService = idempotency.NewIdempotencyService(database, systemClock)
+	server.RateLimitService = ratelimit.NewRateLimitService(rateLimitStore(database), rateLimitPolicy(), systemClock)
+	server.AdminToken = os.Getenv("ADMIN_TOKEN")
 
-	go func(server *web.Server){
-		if err := server.AccountService.UpdateBalanceDaily(); err != nil {
-			log.Fatal("[ERROR] - "+err.Error())
-		}
-	}(server)
+	// Background work runs as jobs of the scheduler
+	registerJobs(server)
+	go server.JobService.Start()
 
 	server.LoadSharedMiddleware()
 	server.LoadRoutes()
 	log.Fatal(server.Run())
 }
+
+// registerJobs adds the background jobs to the scheduler, their schedules are cron expressions in UTC.
+func registerJobs(server *web.Server) {
+	markDormant := func(at time.Time) error {
+		_, err := server.AccountService.MarkDormant(at)
+		return err
+	}
+
+	jobs := []struct {
+		name     string
+		schedule string
+		run      domain.JobFunc
+	}{
+		{"interest-accrual", "5 * * * *", server.InterestService.Accrue},
+		{"dormant-accounts", "0 3 * * *", markDormant},
+		{"standing-orders", "*/15 * * * *", server.StandingOrderService.Execute},
+	}
+
+	for _, job := range jobs {
+		if err := server.JobService.Register(job.name, job.schedule, job.run); err != nil {
+			log.Fatal("[ERROR] - Failed to register job " + job.name + ": " + err.Error())
+		}
+	}
+}
+
+func exchangeBaseCurrency() domain.Currency {
+	if base := os.Getenv("EXCHANGE_BASE_CURRENCY"); base != "" {
+		return domain.Currency(base)
+	}
+	return "USD"
+}
+
+// fxPricing reads the spread and fee of conversions, both are fractions like 0.005.
+func fxPricing() domain.FXPricing {
+	var pricing domain.FXPricing
+	var err error
+
+	if spread := os.Getenv("FX_SPREAD"); spread != "" {
+		if pricing.Spread, err = domain.ParseRate(spread); err != nil {
+			log.Fatal("[ERROR] - Invalid FX_SPREAD: " + err.Error())
+		}
+	}
+
+	if fee := os.Getenv("FX_FEE"); fee != "" {
+		if pricing.FeeRate, err = domain.ParseRate(fee); err != nil {
+			log.Fatal("[ERROR] - Invalid FX_FEE: " + err.Error())
+		}
+	}
+
+	return pricing
+}
+
+// stepUpThreshold reads the amount above which transfers need a second factor, it is disabled when empty.
+func stepUpThreshold() int64 {
+	value := os.Getenv("STEP_UP_THRESHOLD")
+	if value == "" {
+		return 0
+	}
+
+	threshold, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || threshold < 0 {
+		log.Fatal("[ERROR] - Invalid STEP_UP_THRESHOLD: " + value)
+	}
+
+	return threshold
+}
+
+// standingOrderRetryPolicy reads how often standing orders retry transfers the account couldnt
+// cover like 3/24h, 0 turns retries off.
+func standingOrderRetryPolicy() domain.RetryPolicy {
+	value := os.Getenv("STANDING_ORDER_RETRY")
+	if value == "" {
+		return domain.RetryPolicy{Retries: 3, Interval: 24 * time.Hour}
+	}
+
+	policy, err := domain.ParseRetryPolicy(value)
+	if err != nil {
+		log.Fatal("[ERROR] - Invalid STANDING_ORDER_RETRY: " + err.Error())
+	}
+
+	return policy
+}
+
+// mandateRefundDays reads for how many days payers can have direct debit collections refunded, 8 weeks by default.
+func mandateRefundDays() int {
+	value := os.Getenv("MANDATE_REFUND_DAYS")
+	if value == "" {
+		return 56
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Fatal("[ERROR] - Invalid MANDATE_REFUND_DAYS: " + value)
+	}
+
+	return days
+}
+
+// rateLimitPolicy reads the limits of reading and writing requests like 60/1m, 0 turns a limit off.
+func rateLimitPolicy() domain.RateLimitPolicy {
+	policy := domain.RateLimitPolicy{
+		Read:  domain.RateLimit{Burst: 300, Period: time.Minute},
+		Write: domain.RateLimit{Burst: 60, Period: time.Minute},
+	}
+	var err error
+
+	if read := os.Getenv("RATE_LIMIT_READ"); read != "" {
+		if policy.Read, err = domain.ParseRateLimit(read); err != nil {
+			log.Fatal("[ERROR] - Invalid RATE_LIMIT_READ: " + err.Error())
+		}
+	}
+
+	if write := os.Getenv("RATE_LIMIT_WRITE"); write != "" {
+		if policy.Write, err = domain.ParseRateLimit(write); err != nil {
+			log.Fatal("[ERROR] - Invalid RATE_LIMIT_WRITE: " + err.Error())
+		}
+	}
+
+	return policy
+}
+
+// rateLimitStore picks where the token buckets are kept. Instances sharing a database
+// should use postgres, otherwise every instance lets through the full limit.
+func rateLimitStore(database *repository.Postgres) ports.IRateLimitStore {
+	switch store := os.Getenv("RATE_LIMIT_STORE"); store {
+	case "", "memory":
+		return ratelimit.NewMemoryStore()
+	case "postgres":
+		return ratelimit.NewRepositoryStore(database)
+	default:
+		log.Fatal("[ERROR] - Invalid RATE_LIMIT_STORE: " + store)
+		return nil
+	}
+}
+
+// jwtKeySet reads the access token keys from JWT_KEYS, a comma separated list of
+// id:algorithm:base64 keys where the first one signs. Without it a random key is
+// generated, so access tokens stop working after a restart.
+func jwtKeySet() *auth.KeySet {
+	value := os.Getenv("JWT_KEYS")
+	if value == "" {
+		log.Println("[WARNING]\tJWT_KEYS is not set, signing access tokens with a generated key")
+
+		key, err := auth.GenerateKey("generated", auth.EdDSA)
+		if err != nil {
+			log.Fatal("[ERROR] - Failed to generate a JWT key: " + err.Error())
+		}
+		return auth.NewKeySet(key)
+	}
+
+	var keys []auth.SigningKey
+	for _, spec := range strings.Split(value, ",") {
+		key, err := auth.ParseKey(spec)
+		if err != nil {
+			log.Fatal("[ERROR] - Invalid JWT_KEYS: " + err.Error())
+		}
+		keys = append(keys, key)
+	}
+
+	return auth.NewKeySet(keys[0], keys[1:]...)
+}
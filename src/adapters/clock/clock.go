@@ -0,0 +1,60 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// SystemClock tells the time of the machine the server runs on.
+type SystemClock struct{}
+
+func NewSystemClock() SystemClock {
+	return SystemClock{}
+}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock only moves when told to, it's meant for tests simulating days or months in an instant.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now: now,
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set moves the clock to the time, backwards too.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Advance moves the clock forward by the duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// AdvanceDays moves the clock forward by whole calendar days.
+func (c *FakeClock) AdvanceDays(days int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.AddDate(0, 0, days)
+}
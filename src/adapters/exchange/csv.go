@@ -0,0 +1,100 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// LoadCSV reads rates from a CSV file with the header: from,to,rate,effective_at
+// where effective_at is either a date (2006-01-02) or an RFC 3339 timestamp.
+func LoadCSV(path string) ([]domain.ExchangeRate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	// Skip the header
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read rates header: %w", err)
+	}
+
+	var rates []domain.ExchangeRate
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rates file at line %v: %w", line, err)
+		}
+
+		if len(record) != 4 {
+			return nil, fmt.Errorf("expected 4 columns at line %v", line)
+		}
+
+		value, err := domain.ParseRate(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+
+		effectiveAt, err := parseEffectiveAt(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+
+		pair := domain.NewCurrencyPair(domain.Currency(strings.ToUpper(record[0])), domain.Currency(strings.ToUpper(record[1])))
+		rate := domain.NewExchangeRate(pair, value, effectiveAt, "csv")
+
+		if err := rate.Validate(); err != nil {
+			return nil, fmt.Errorf("line %v: %s", line, err.Error())
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// SeedFromCSV loads the rates from the file and stores them in the repository.
+func SeedFromCSV(path string, repository ports.IExchangeRateRepository) error {
+	rates, err := LoadCSV(path)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range rates {
+		if _, err := repository.CreateExchangeRate(rate); err != nil {
+			return fmt.Errorf("failed to store rate %s: %w", rate.Pair.String(), err)
+		}
+	}
+
+	log.Printf("[EVENT]\tSeeded %v exchange rates from: %s", len(rates), path)
+
+	return nil
+}
+
+func parseEffectiveAt(value string) (time.Time, error) {
+	if effectiveAt, err := time.Parse(time.DateOnly, value); err == nil {
+		return effectiveAt, nil
+	}
+
+	effectiveAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid effective_at: %s", value)
+	}
+
+	return effectiveAt, nil
+}
@@ -0,0 +1,34 @@
+package exchange
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+// FixedRateProvider serves the same rates regardless of time, it's meant for tests.
+type FixedRateProvider struct {
+	Rates map[domain.CurrencyPair]string
+}
+
+func NewFixedRateProvider(rates map[domain.CurrencyPair]string) *FixedRateProvider {
+	return &FixedRateProvider{
+		Rates: rates,
+	}
+}
+
+func (p *FixedRateProvider) GetExchangeRate(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	value, ok := p.Rates[pair]
+	if !ok {
+		// Same contract as the repositories
+		return domain.ExchangeRate{}, sql.ErrNoRows
+	}
+
+	rate, err := domain.ParseRate(value)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	return domain.NewExchangeRate(pair, rate, time.Time{}, "fixed"), nil
+}
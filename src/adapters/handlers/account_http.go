@@ -79,9 +79,9 @@ func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	customerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
-	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
 		return
 	}
 	
@@ -120,10 +120,22 @@ func (h *AccountHandler) Update(w http.ResponseWriter, r *http.Request) {
 			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
 			return
 		}
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrInternalFailure) {
 			RespondWithError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -133,23 +145,187 @@ func (h *AccountHandler) Update(w http.ResponseWriter, r *http.Request) {
 	RespondWithJson(w, http.StatusOK, nil)
 }
 
-func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+func (h *AccountHandler) Adjust(w http.ResponseWriter, r *http.Request) {
 	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
 	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
 		return
 	}
 
-	_, err = h.AccountService.Delete(accountID)
+	body, err := decode[domain.CreateAdjustmentRequest](r)
 	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	// Adjustments made with the admin token have no staff member to record
+	createdBy, _ := CustomerIDFromContext(r.Context())
+
+	adjustment, err := h.AccountService.Adjust(accountID, createdBy, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, err.Error())
 			return
-		} else if errors.Is(err, domain.ErrInternalFailure) {
-			RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
 			return
 		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	RespondWithJson(w, http.StatusOK, nil)
-}
\ No newline at end of file
+	RespondWithJsonAndSerialize(w, http.StatusCreated, adjustment)
+}
+
+func (h *AccountHandler) Adjustments(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	adjustments, err := h.AccountService.Adjustments(accountID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, adjustments)
+}
+
+func (h *AccountHandler) Freeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.AccountService.Freeze)
+}
+
+func (h *AccountHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.AccountService.Unfreeze)
+}
+
+func (h *AccountHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.AccountService.Reactivate)
+}
+
+func (h *AccountHandler) Close(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	// The body is optional for accounts without any balance left
+	var body domain.CloseAccountRequest
+	if r.ContentLength != 0 {
+		body, err = decode[domain.CloseAccountRequest](r)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+			return
+		}
+	}
+
+	account, err := h.AccountService.Close(accountID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUnprocessable) {
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, account)
+}
+
+// SetOverdraft arranges or removes the overdraft of the {account_id}.
+func (h *AccountHandler) SetOverdraft(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.SetOverdraftRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	account, err := h.AccountService.SetOverdraft(accountID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, account)
+}
+
+// changeStatus moves the {account_id} through its lifecycle with the given service method.
+func (h *AccountHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(accountID uuid.UUID) (domain.Account, error)) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	account, err := change(accountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, account)
+}
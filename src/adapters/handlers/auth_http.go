@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type AuthHandler struct {
+	AuthService ports.IAuthService
+}
+
+func NewAuthHandler(authService ports.IAuthService) *AuthHandler {
+	return &AuthHandler{
+		AuthService: authService,
+	}
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	body, err := decode[domain.LoginRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	pair, err := h.AuthService.Login(body)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, pair)
+}
+
+func (h *AuthHandler) PasswordLogin(w http.ResponseWriter, r *http.Request) {
+	body, err := decode[domain.PasswordLoginRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	pair, err := h.AuthService.PasswordLogin(body)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, pair)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	body, err := decode[domain.RefreshRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	pair, err := h.AuthService.Refresh(body)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, pair)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	body, err := decode[domain.RefreshRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	err = h.AuthService.Logout(body)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJson(w, http.StatusOK, nil)
+}
+
+// Keys serves the public keys as a plain JWK set, so standard JWT libraries can read it.
+func (h *AuthHandler) Keys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.AuthService.Keys())
+}
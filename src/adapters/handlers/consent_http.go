@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type ConsentHandler struct {
+	ConsentService ports.IConsentService
+}
+
+func NewConsentHandler(consentService ports.IConsentService) *ConsentHandler {
+	return &ConsentHandler{
+		ConsentService: consentService,
+	}
+}
+
+func (h *ConsentHandler) Index(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	consents, err := h.ConsentService.Index(customerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, consents)
+}
+
+func (h *ConsentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.CreateConsentRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	consent, apiKey, err := h.ConsentService.Create(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// The API key is only ever shown in this response
+	response := struct {
+		Consent domain.DTO `json:"consent"`
+		APIKey  string     `json:"api_key"`
+	}{
+		Consent: consent.ToDTO(),
+		APIKey:  apiKey,
+	}
+
+	RespondWithJson(w, http.StatusCreated, response)
+}
+
+func (h *ConsentHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	consentID, err := uuid.Parse(chi.URLParam(r, "consent_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	_, err = h.ConsentService.Revoke(customerID, consentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJson(w, http.StatusOK, nil)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+type contextKey string
+
+const (
+	customerIDKey  contextKey = "customer_id"
+	roleKey        contextKey = "role"
+	consentKey     contextKey = "consent"
+	apiKeyScopeKey contextKey = "api_key_scope"
+)
+
+// WithCustomerID stores the authenticated customer in the context of the request.
+func WithCustomerID(ctx context.Context, customerID uuid.UUID) context.Context {
+	return context.WithValue(ctx, customerIDKey, customerID)
+}
+
+// CustomerIDFromContext returns the customer the request was authenticated as.
+func CustomerIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	customerID, ok := ctx.Value(customerIDKey).(uuid.UUID)
+	return customerID, ok && customerID != uuid.Nil
+}
+
+// WithRole stores the role of the authenticated customer in the context of the request.
+func WithRole(ctx context.Context, role domain.Role) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the role the request was authenticated with, false when it wasn't authenticated.
+func RoleFromContext(ctx context.Context) (domain.Role, bool) {
+	role, ok := ctx.Value(roleKey).(domain.Role)
+	return role, ok
+}
+
+// WithConsent stores the consent of the API key the request was authenticated with.
+func WithConsent(ctx context.Context, consent domain.Consent) context.Context {
+	return context.WithValue(ctx, consentKey, consent)
+}
+
+// ConsentFromContext returns the consent of the API key, false when the customer authenticated themselves.
+func ConsentFromContext(ctx context.Context) (domain.Consent, bool) {
+	consent, ok := ctx.Value(consentKey).(domain.Consent)
+	return consent, ok
+}
+
+// WithAPIKeyScope marks the request as open to API keys granted the scope.
+func WithAPIKeyScope(ctx context.Context, scope domain.Scope) context.Context {
+	return context.WithValue(ctx, apiKeyScopeKey, scope)
+}
+
+// APIKeyScopeFromContext returns the scope API keys need for the request, false when they cannot be used.
+func APIKeyScopeFromContext(ctx context.Context) (domain.Scope, bool) {
+	scope, ok := ctx.Value(apiKeyScopeKey).(domain.Scope)
+	return scope, ok
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type CredentialHandler struct {
+	CredentialService ports.ICredentialService
+}
+
+func NewCredentialHandler(credentialService ports.ICredentialService) *CredentialHandler {
+	return &CredentialHandler{
+		CredentialService: credentialService,
+	}
+}
+
+func (h *CredentialHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.SetPasswordRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	err = h.CredentialService.SetPassword(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJson(w, http.StatusOK, nil)
+}
+
+func (h *CredentialHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	enrollment, err := h.CredentialService.EnrollTOTP(customerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// The secret is only ever shown in this response
+	RespondWithJson(w, http.StatusCreated, enrollment)
+}
+
+func (h *CredentialHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.TwoFactorCodeRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	recoveryCodes, err := h.CredentialService.ConfirmTOTP(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// The recovery codes are only ever shown in this response
+	response := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: recoveryCodes,
+	}
+
+	RespondWithJson(w, http.StatusOK, response)
+}
+
+func (h *CredentialHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.TwoFactorCodeRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	err = h.CredentialService.DisableTOTP(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJson(w, http.StatusOK, nil)
+}
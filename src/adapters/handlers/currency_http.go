@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type CurrencyHandler struct {
+	CurrencyService ports.ICurrencyService
+}
+
+func NewCurrencyHandler(currencyService ports.ICurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		CurrencyService: currencyService,
+	}
+}
+
+func (h *CurrencyHandler) Index(w http.ResponseWriter, r *http.Request) {
+	enabledOnly := false
+	if r.URL.Query().Get("enabled") != "" {
+		var err error
+		enabledOnly, err = strconv.ParseBool(r.URL.Query().Get("enabled"))
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+			return
+		}
+	}
+
+	currencies, err := h.CurrencyService.Index(enabledOnly)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, currencies)
+}
+
+func (h *CurrencyHandler) Update(w http.ResponseWriter, r *http.Request) {
+	body, err := decode[domain.UpdateCurrencyRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	currency, err := h.CurrencyService.SetEnabled(domain.Currency(chi.URLParam(r, "code")), body.Enabled)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, currency)
+}
@@ -92,9 +92,9 @@ func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
-	customerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
-	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
 		return
 	}
 
@@ -124,13 +124,132 @@ func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	_, err := h.CustomerService.Delete(customerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJson(w, http.StatusOK, nil)
+}
+
+func (h *CustomerHandler) SetRole(w http.ResponseWriter, r *http.Request) {
 	customerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
 	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
 		return
 	}
 
-	_, err = h.CustomerService.Delete(customerID)
+	body, err := decode[domain.UpdateRoleRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	customer, err := h.CustomerService.SetRole(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) IndexTokens(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	tokens, err := h.CustomerService.Tokens(customerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, tokens)
+}
+
+func (h *CustomerHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.CreateCustomerTokenRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	token, plaintext, err := h.CustomerService.CreateToken(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// The token is only ever shown in this response
+	response := struct {
+		ID    uuid.UUID `json:"id"`
+		Token string    `json:"token"`
+	}{
+		ID:    token.ID,
+		Token: plaintext,
+	}
+
+	RespondWithJson(w, http.StatusCreated, response)
+}
+
+func (h *CustomerHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "token_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	_, err = h.CustomerService.RevokeToken(customerID, tokenID)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, err.Error())
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type FXHandler struct {
+	FXService ports.IFXService
+}
+
+func NewFXHandler(fxService ports.IFXService) *FXHandler {
+	return &FXHandler{
+		FXService: fxService,
+	}
+}
+
+func (h *FXHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := CustomerIDFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+		return
+	}
+
+	body, err := decode[domain.CreateFXQuoteRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	quote, err := h.FXService.Quote(customerID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/fx/quote/%s", quote.ID.String()))
+	RespondWithJsonAndSerialize(w, http.StatusCreated, quote)
+}
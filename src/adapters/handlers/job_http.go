@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type JobHandler struct {
+	JobService ports.IJobService
+}
+
+func NewJobHandler(jobService ports.IJobService) *JobHandler {
+	return &JobHandler{
+		JobService: jobService,
+	}
+}
+
+func (h *JobHandler) Index(w http.ResponseWriter, r *http.Request) {
+	RespondWithJsonAndSerializeList(w, http.StatusOK, h.JobService.Index())
+}
+
+func (h *JobHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	runs, err := h.JobService.Runs(chi.URLParam(r, "job"), limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, runs)
+}
+
+// Trigger starts a run of the job outside of its schedule, the run goes on after the response.
+func (h *JobHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	// Runs triggered with the admin token have no staff member to record
+	triggeredBy, _ := CustomerIDFromContext(r.Context())
+
+	run, err := h.JobService.Trigger(chi.URLParam(r, "job"), triggeredBy)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusAccepted, run)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type LedgerHandler struct {
+	LedgerService ports.ILedgerService
+}
+
+func NewLedgerHandler(ledgerService ports.ILedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		LedgerService: ledgerService,
+	}
+}
+
+func (h *LedgerHandler) Index(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	entries, err := h.LedgerService.Entries(accountID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, entries)
+}
+
+func (h *LedgerHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	balance, err := h.LedgerService.Verify(accountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, balance)
+}
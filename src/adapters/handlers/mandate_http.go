@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type MandateHandler struct {
+	MandateService ports.IMandateService
+}
+
+func NewMandateHandler(mandateService ports.IMandateService) *MandateHandler {
+	return &MandateHandler{
+		MandateService: mandateService,
+	}
+}
+
+func (h *MandateHandler) Index(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	mandates, err := h.MandateService.Index(accountID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, mandates)
+}
+
+func (h *MandateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	accountID, mandateID, err := parseMandateParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	mandate, err := h.MandateService.Get(accountID, mandateID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, mandate)
+}
+
+func (h *MandateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.CreateMandateRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	mandate, err := h.MandateService.Create(accountID, body)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/customer/%s/account/%s/mandates/%s", chi.URLParam(r, "customer_id"), accountID.String(), mandate.ID.String()))
+	RespondWithJsonAndSerialize(w, http.StatusCreated, mandate)
+}
+
+func (h *MandateHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	accountID, mandateID, err := parseMandateParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	mandate, err := h.MandateService.Cancel(accountID, mandateID)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, mandate)
+}
+
+func (h *MandateHandler) Collect(w http.ResponseWriter, r *http.Request) {
+	accountID, mandateID, err := parseMandateParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.CreateCollectionRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	collection, err := h.MandateService.Collect(accountID, mandateID, body)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", collection.ID.String()))
+	RespondWithJsonAndSerialize(w, http.StatusCreated, collection)
+}
+
+func (h *MandateHandler) Collections(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, mandateID, err := parseMandateParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	collections, err := h.MandateService.Collections(accountID, mandateID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, collections)
+}
+
+func (h *MandateHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	accountID, mandateID, err := parseMandateParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	transactionID, err := uuid.Parse(chi.URLParam(r, "transaction_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	refund, err := h.MandateService.Refund(accountID, mandateID, transactionID)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", refund.ID.String()))
+	RespondWithJsonAndSerialize(w, http.StatusCreated, refund)
+}
+
+func parseMandateParams(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	mandateID, err := uuid.Parse(chi.URLParam(r, "mandate_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return accountID, mandateID, nil
+}
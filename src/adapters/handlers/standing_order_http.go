@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type StandingOrderHandler struct {
+	StandingOrderService ports.IStandingOrderService
+}
+
+func NewStandingOrderHandler(standingOrderService ports.IStandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		StandingOrderService: standingOrderService,
+	}
+}
+
+func (h *StandingOrderHandler) Index(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	orders, err := h.StandingOrderService.Index(accountID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, orders)
+}
+
+func (h *StandingOrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	accountID, orderID, err := parseStandingOrderParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	order, err := h.StandingOrderService.Get(accountID, orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, order)
+}
+
+func (h *StandingOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.CreateStandingOrderRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	order, err := h.StandingOrderService.Create(accountID, body)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/customer/%s/account/%s/standing-orders/%s", chi.URLParam(r, "customer_id"), accountID.String(), order.ID.String()))
+	RespondWithJsonAndSerialize(w, http.StatusCreated, order)
+}
+
+func (h *StandingOrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	accountID, orderID, err := parseStandingOrderParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	order, err := h.StandingOrderService.Cancel(accountID, orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerialize(w, http.StatusOK, order)
+}
+
+func (h *StandingOrderHandler) Executions(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parseLimitOffsetParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+		return
+	}
+
+	accountID, orderID, err := parseStandingOrderParams(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	executions, err := h.StandingOrderService.Executions(accountID, orderID, limit, offset)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJsonAndSerializeList(w, http.StatusOK, executions)
+}
+
+func parseStandingOrderParams(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	orderID, err := uuid.Parse(chi.URLParam(r, "standing_order_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return accountID, orderID, nil
+}
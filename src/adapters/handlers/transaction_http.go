@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -37,7 +38,16 @@ func (h *TransactionHandler) Index(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	transactions, err := h.TransactionService.Index(accountID, limit, offset)
+	var status domain.TransactionStatus
+	if r.URL.Query().Get("status") != "" {
+		status, err = domain.ParseTransactionStatus(r.URL.Query().Get("status"))
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Failed to parse parameters: "+err.Error())
+			return
+		}
+	}
+
+	transactions, err := h.TransactionService.Index(accountID, status, limit, offset)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, err.Error())
@@ -90,6 +100,14 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	transaction, err := h.TransactionService.Create(body)
 	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, err.Error())
 			return
@@ -98,6 +116,14 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 			RespondWithError(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUnprocessable) {
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrValidation) {
 			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
 			return
@@ -110,4 +136,122 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", transaction.ID.String()))
 	RespondWithJson(w, http.StatusCreated, nil)
-}
\ No newline at end of file
+}
+
+func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.CreateDepositRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	deposit, err := h.TransactionService.Deposit(accountID, body)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", deposit.ID.String()))
+	RespondWithJson(w, http.StatusCreated, nil)
+}
+
+func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	body, err := decode[domain.CreateWithdrawalRequest](r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	withdrawal, err := h.TransactionService.Withdraw(accountID, body)
+	if err != nil {
+		respondWithBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", withdrawal.ID.String()))
+	RespondWithJson(w, http.StatusCreated, nil)
+}
+
+// respondWithBookingError maps the errors of deposits, withdrawals, standing orders and mandates to their status codes.
+func respondWithBookingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		RespondWithError(w, http.StatusUnauthorized, "Not authorized! "+err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		RespondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrBadRequest) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrConflict) {
+		RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrValidation) {
+		RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+		return
+	}
+
+	RespondWithError(w, http.StatusInternalServerError, err.Error())
+}
+
+func (h *TransactionHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := uuid.Parse(chi.URLParam(r, "transaction_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		return
+	}
+
+	// The body is optional, without it the whole transaction is reversed
+	body, err := decode[domain.CreateReversalRequest](r)
+	if err != nil && !errors.Is(err, io.EOF) {
+		RespondWithError(w, http.StatusBadRequest, "Failed to parse the body: "+err.Error())
+		return
+	}
+
+	reversal, err := h.TransactionService.Reverse(transactionID, body)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrBadRequest) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			RespondWithValidationErrors(w, http.StatusBadRequest, "Failed to validate request", domain.ExtractValidationErrorsToList(err))
+			return
+		}
+		if errors.Is(err, domain.ErrInternalFailure) {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/transaction/%s", reversal.ID.String()))
+	RespondWithJson(w, http.StatusCreated, nil)
+}
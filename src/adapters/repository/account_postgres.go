@@ -2,6 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
@@ -10,7 +14,7 @@ import (
 func (p *Postgres) GetAllAccounts(limit int, offset int) ([]domain.Account, error) {
 	query := `SELECT * FROM accounts ORDER BY created_at LIMIT $1 OFFSET $2`
 
-	rows, err := p.DB.Query(query, limit, offset)
+	rows, err := p.conn().Query(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -19,9 +23,8 @@ func (p *Postgres) GetAllAccounts(limit int, offset int) ([]domain.Account, erro
 	var accounts []domain.Account
 
 	for rows.Next() {
-		var account domain.Account
-
-		if err := rows.Scan(&account.ID, &account.CustomerID, &account.Balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt); err != nil {
+		account, err := scanAccount(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -43,7 +46,7 @@ func (p *Postgres) GetAllAccounts(limit int, offset int) ([]domain.Account, erro
 func (p *Postgres) GetAllAccountsByCustomer(customerID uuid.UUID, limit int, offset int) ([]domain.Account, error) {
 	query := `SELECT * FROM accounts WHERE customer_id = $1 ORDER BY created_at LIMIT $2 OFFSET $3`
 
-	rows, err := p.DB.Query(query, customerID, limit, offset)
+	rows, err := p.conn().Query(query, customerID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +55,8 @@ func (p *Postgres) GetAllAccountsByCustomer(customerID uuid.UUID, limit int, off
 	var accounts []domain.Account
 
 	for rows.Next() {
-		var account domain.Account
-
-		if err := rows.Scan(&account.ID, &account.CustomerID, &account.Balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt); err != nil {
+		account, err := scanAccount(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -73,44 +75,46 @@ func (p *Postgres) GetAllAccountsByCustomer(customerID uuid.UUID, limit int, off
 
 }
 
-func (p *Postgres) GetAllSavingsAccounts() ([]domain.Account, error) {
-	query := `SELECT * FROM accounts WHERE account_type = 3 ORDER BY created_at`
+// GetAccountsToAccrue returns the open accounts that werent accrued through the given day yet. Accounts
+// without interest are accrued too, so a rate set later doesnt apply to the days before it.
+func (p *Postgres) GetAccountsToAccrue(through time.Time) ([]uuid.UUID, error) {
+	query := `
+	SELECT id FROM accounts
+	WHERE status <> 'CLOSED' AND interest_accrued_through < $1
+	ORDER BY created_at`
 
-	rows, err := p.DB.Query(query, )
+	rows, err := p.conn().Query(query, through)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var accounts []domain.Account
+	var accountIDs []uuid.UUID
 
 	for rows.Next() {
-		var account domain.Account
-
-		if err := rows.Scan(&account.ID, &account.CustomerID, &account.Balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt); err != nil {
+		var accountID uuid.UUID
+		if err := rows.Scan(&accountID); err != nil {
 			return nil, err
 		}
 
-		accounts = append(accounts, account)
+		accountIDs = append(accountIDs, accountID)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	if len(accounts) == 0 {
+	if len(accountIDs) == 0 {
 		return nil, sql.ErrNoRows
 	}
 
-	return accounts, nil
+	return accountIDs, nil
 }
 
 func (p *Postgres) GetAccount(accountID uuid.UUID) (domain.Account, error) {
 	query := `SELECT * FROM accounts WHERE id = $1 LIMIT 1`
 
-	var account domain.Account
-
-	err := p.DB.QueryRow(query, accountID).Scan(&account.ID, &account.CustomerID, &account.Balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt)
+	account, err := scanAccount(p.conn().QueryRow(query, accountID))
 	if err != nil {
 		return domain.Account{}, err
 	}
@@ -121,9 +125,18 @@ func (p *Postgres) GetAccount(accountID uuid.UUID) (domain.Account, error) {
 func (p *Postgres) GetAccountByOwner(customerID, accountID uuid.UUID) (domain.Account, error) {
 	query := `SELECT * FROM accounts WHERE id = $1 AND customer_id = $2 LIMIT 1`
 
-	var account domain.Account
+	account, err := scanAccount(p.conn().QueryRow(query, accountID, customerID))
+	if err != nil {
+		return domain.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (p *Postgres) GetAccountForUpdate(accountID uuid.UUID) (domain.Account, error) {
+	query := `SELECT * FROM accounts WHERE id = $1 LIMIT 1 FOR UPDATE`
 
-	err := p.DB.QueryRow(query, accountID, customerID).Scan(&account.ID, &account.CustomerID, &account.Balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt)
+	account, err := scanAccount(p.conn().QueryRow(query, accountID))
 	if err != nil {
 		return domain.Account{}, err
 	}
@@ -134,10 +147,15 @@ func (p *Postgres) GetAccountByOwner(customerID, accountID uuid.UUID) (domain.Ac
 func (p *Postgres) CreateAccount(account domain.Account) (int64, error) {
 	query := `
 	INSERT INTO accounts
-	(id, customer_id, balance, account_type, currency, status, opening_date, last_transaction_date, interest_rate, created_at)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`
+	(id, customer_id, balance, account_type, currency, status, opening_date, last_transaction_date, interest_rate, created_at, nickname, overdraft_limit, overdraft_interest_rate, interest_tiers, day_count, accrued_interest, interest_accrued_through)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)`
+
+	tiers, err := encodeInterestTiers(account.InterestTiers)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := p.DB.Exec(query, account.ID.String(), account.CustomerID.String(), account.Balance, account.Type, account.Currency, account.Status, account.OpeningDate, account.LastTransactionDate, account.InterestRate, account.CreatedAt)
+	_, err = p.conn().Exec(query, account.ID.String(), account.CustomerID.String(), account.Balance.String(), account.Type, account.Currency, account.Status, account.OpeningDate, account.LastTransactionDate, account.InterestRate, account.CreatedAt, account.Nickname, account.OverdraftLimit.String(), account.OverdraftInterestRate, tiers, account.DayCount, account.Accrued().FloatString(domain.INTEREST_DECIMALS), account.InterestAccruedThrough)
 	if err != nil {
 		return 0, err
 	}
@@ -148,11 +166,45 @@ func (p *Postgres) CreateAccount(account domain.Account) (int64, error) {
 func (p *Postgres) UpdateAccount(account domain.Account) (int64, error) {
 	query := `
 	UPDATE accounts
-	SET balance = $1, account_type = $2, currency = $3, status = $4, last_transaction_date = $5, interest_rate = $6
-	WHERE id = $7
+	SET balance = $1, account_type = $2, currency = $3, status = $4, last_transaction_date = $5, interest_rate = $6, nickname = $7, overdraft_limit = $8, overdraft_interest_rate = $9
+	WHERE id = $10
 	`
 
-	result, err := p.DB.Exec(query, account.Balance, account.Type, account.Currency, account.Status, account.LastTransactionDate, account.InterestRate, account.ID)
+	result, err := p.conn().Exec(query, account.Balance.String(), account.Type, account.Currency, account.Status, account.LastTransactionDate, account.InterestRate, account.Nickname, account.OverdraftLimit.String(), account.OverdraftInterestRate, account.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) UpdateAccountStatus(accountID uuid.UUID, status domain.AccountStatus) (int64, error) {
+	query := `UPDATE accounts SET status = $1 WHERE id = $2`
+
+	result, err := p.conn().Exec(query, status, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// UpdateAccruedInterest records the interest accrued through the given day, the accrual
+// columns are kept out of UpdateAccount so transfers never overwrite them.
+func (p *Postgres) UpdateAccruedInterest(accountID uuid.UUID, accrued *big.Rat, through time.Time) (int64, error) {
+	query := `UPDATE accounts SET accrued_interest = $1, interest_accrued_through = $2 WHERE id = $3`
+
+	result, err := p.conn().Exec(query, accrued.FloatString(domain.INTEREST_DECIMALS), through, accountID)
 	if err != nil {
 		return 0, err
 	}
@@ -165,10 +217,13 @@ func (p *Postgres) UpdateAccount(account domain.Account) (int64, error) {
 	return rowsAffected, nil
 }
 
-func (p *Postgres) DeleteAccount( accountID uuid.UUID) (int64, error) {
-	query := `DELETE FROM accounts WHERE id = $1`
+// MarkDormantAccounts makes the active accounts without a transfer since the given time dormant.
+func (p *Postgres) MarkDormantAccounts(inactiveSince time.Time) (int64, error) {
+	query := `
+	UPDATE accounts SET status = 'DORMANT'
+	WHERE status = 'ACTIVE' AND GREATEST(opening_date, last_transaction_date) < $1`
 
-	result, err := p.DB.Exec(query, accountID)
+	result, err := p.conn().Exec(query, inactiveSince)
 	if err != nil {
 		return 0, err
 	}
@@ -179,4 +234,59 @@ func (p *Postgres) DeleteAccount( accountID uuid.UUID) (int64, error) {
 	}
 
 	return rowsAffected, nil
-}
\ No newline at end of file
+}
+
+func scanAccount(row scanner) (domain.Account, error) {
+	var account domain.Account
+	var balance, overdraftLimit, accrued string
+	var tiers []byte
+
+	err := row.Scan(&account.ID, &account.CustomerID, &balance, &account.Type, &account.Currency, &account.Status, &account.OpeningDate, &account.LastTransactionDate, &account.InterestRate, &account.CreatedAt, &account.Nickname, &overdraftLimit, &account.OverdraftInterestRate, &tiers, &account.DayCount, &accrued, &account.InterestAccruedThrough)
+	if err != nil {
+		return domain.Account{}, err
+	}
+
+	// The balance is stored as an exact NUMERIC in major units of the account currency
+	account.Balance, err = domain.ParseMoney(balance, account.Currency)
+	if err != nil {
+		return domain.Account{}, fmt.Errorf("Bad balance format at account id: %s", account.ID.String())
+	}
+
+	account.OverdraftLimit, err = domain.ParseMoney(overdraftLimit, account.Currency)
+	if err != nil {
+		return domain.Account{}, fmt.Errorf("Bad overdraft limit format at account id: %s", account.ID.String())
+	}
+
+	account.InterestTiers, err = decodeInterestTiers(tiers, account.Currency)
+	if err != nil {
+		return domain.Account{}, fmt.Errorf("Bad interest tiers format at account id: %s", account.ID.String())
+	}
+
+	var ok bool
+	if account.AccruedInterest, ok = new(big.Rat).SetString(accrued); !ok {
+		return domain.Account{}, fmt.Errorf("Bad accrued interest format at account id: %s", account.ID.String())
+	}
+
+	account.InterestAccruedThrough = domain.StartOfDay(account.InterestAccruedThrough)
+
+	return account, nil
+}
+
+// Interest tiers are stored as JSON with their thresholds in major units of the account currency.
+func encodeInterestTiers(tiers []domain.InterestTier) ([]byte, error) {
+	encoded := []domain.InterestTierDTO{}
+	for _, tier := range tiers {
+		encoded = append(encoded, domain.InterestTierDTO{Above: tier.Above.Number(), Rate: tier.Rate})
+	}
+
+	return json.Marshal(encoded)
+}
+
+func decodeInterestTiers(data []byte, currency domain.Currency) ([]domain.InterestTier, error) {
+	var decoded []domain.InterestTierRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	return domain.ParseInterestTiers(decoded, currency)
+}
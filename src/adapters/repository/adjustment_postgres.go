@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetAdjustmentsByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Adjustment, error) {
+	query := `SELECT * FROM adjustments WHERE account_id = $1 ORDER BY created_at LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adjustments []domain.Adjustment
+
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(adjustments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return adjustments, nil
+}
+
+func (p *Postgres) CreateAdjustment(adjustment domain.Adjustment) (int64, error) {
+	query := `
+	INSERT INTO adjustments
+	(id, account_id, amount, currency, reason, note, created_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := p.conn().Exec(query, adjustment.ID, adjustment.AccountID, adjustment.Amount.String(), adjustment.Amount.Currency, adjustment.Reason, adjustment.Note, nullUUID(adjustment.CreatedBy), adjustment.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func scanAdjustment(row scanner) (domain.Adjustment, error) {
+	var adjustment domain.Adjustment
+	var amount string
+	var currency domain.Currency
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(&adjustment.ID, &adjustment.AccountID, &amount, &currency, &adjustment.Reason, &adjustment.Note, &createdBy, &adjustment.CreatedAt)
+	if err != nil {
+		return domain.Adjustment{}, err
+	}
+
+	adjustment.Amount, err = domain.ParseMoney(amount, currency)
+	if err != nil {
+		return domain.Adjustment{}, fmt.Errorf("Bad amount format at adjustment id: %s", adjustment.ID.String())
+	}
+
+	adjustment.CreatedBy = createdBy.UUID
+
+	return adjustment, nil
+}
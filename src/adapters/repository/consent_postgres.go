@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetConsents(customerID uuid.UUID) ([]domain.Consent, error) {
+	query := `SELECT * FROM consents WHERE customer_id = $1 ORDER BY created_at`
+
+	rows, err := p.conn().Query(query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []domain.Consent
+
+	for rows.Next() {
+		consent, err := scanConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		consents = append(consents, consent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(consents) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return consents, nil
+}
+
+func (p *Postgres) GetConsent(consentID uuid.UUID) (domain.Consent, error) {
+	query := `SELECT * FROM consents WHERE id = $1 LIMIT 1`
+
+	return scanConsent(p.conn().QueryRow(query, consentID))
+}
+
+func (p *Postgres) CreateConsent(consent domain.Consent) (int64, error) {
+	query := `
+	INSERT INTO consents
+	(id, customer_id, client_name, account_ids, scopes, salt, hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	accountIDs := make([]string, 0, len(consent.AccountIDs))
+	for _, accountID := range consent.AccountIDs {
+		accountIDs = append(accountIDs, accountID.String())
+	}
+
+	scopes := make([]string, 0, len(consent.Scopes))
+	for _, scope := range consent.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	_, err := p.conn().Exec(query, consent.ID, consent.CustomerID, consent.ClientName, pq.Array(accountIDs), pq.Array(scopes), consent.Salt, consent.Hash, consent.CreatedAt, consent.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func (p *Postgres) TouchConsent(consentID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE consents SET last_used_at = $1 WHERE id = $2`
+
+	result, err := p.conn().Exec(query, at, consentID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// RevokeConsent returns 0 affected rows when the customer has no such consent or it is already revoked.
+func (p *Postgres) RevokeConsent(customerID, consentID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE consents SET revoked_at = $1 WHERE id = $2 AND customer_id = $3 AND revoked_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, consentID, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanConsent(row scanner) (domain.Consent, error) {
+	var consent domain.Consent
+	var accountIDs, scopes pq.StringArray
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&consent.ID, &consent.CustomerID, &consent.ClientName, &accountIDs, &scopes, &consent.Salt, &consent.Hash, &consent.CreatedAt, &consent.ExpiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return domain.Consent{}, err
+	}
+
+	for _, id := range accountIDs {
+		accountID, err := uuid.Parse(id)
+		if err != nil {
+			return domain.Consent{}, err
+		}
+
+		consent.AccountIDs = append(consent.AccountIDs, accountID)
+	}
+
+	for _, scope := range scopes {
+		consent.Scopes = append(consent.Scopes, domain.Scope(scope))
+	}
+
+	consent.LastUsedAt = lastUsedAt.Time
+	consent.RevokedAt = revokedAt.Time
+
+	return consent, nil
+}
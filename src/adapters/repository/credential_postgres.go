@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetCredentials(customerID uuid.UUID) (domain.Credentials, error) {
+	query := `SELECT * FROM customer_credentials WHERE customer_id = $1`
+
+	return scanCredentials(p.conn().QueryRow(query, customerID))
+}
+
+func (p *Postgres) GetCredentialsForUpdate(customerID uuid.UUID) (domain.Credentials, error) {
+	query := `SELECT * FROM customer_credentials WHERE customer_id = $1 FOR UPDATE`
+
+	return scanCredentials(p.conn().QueryRow(query, customerID))
+}
+
+// SaveCredentials creates the credentials of the customer or replaces them.
+func (p *Postgres) SaveCredentials(credentials domain.Credentials) (int64, error) {
+	query := `
+	INSERT INTO customer_credentials
+	(customer_id, password_hash, totp_secret, totp_enabled, totp_last_step, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (customer_id) DO UPDATE SET
+		password_hash = EXCLUDED.password_hash,
+		totp_secret = EXCLUDED.totp_secret,
+		totp_enabled = EXCLUDED.totp_enabled,
+		totp_last_step = EXCLUDED.totp_last_step,
+		updated_at = EXCLUDED.updated_at`
+
+	result, err := p.conn().Exec(query, credentials.CustomerID, credentials.PasswordHash, credentials.TOTPSecret, credentials.TOTPEnabled, credentials.TOTPLastStep, credentials.CreatedAt, credentials.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) GetUnusedRecoveryCodes(customerID uuid.UUID) ([]domain.RecoveryCode, error) {
+	query := `SELECT * FROM customer_recovery_codes WHERE customer_id = $1 AND used_at IS NULL`
+
+	rows, err := p.conn().Query(query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []domain.RecoveryCode
+
+	for rows.Next() {
+		var code domain.RecoveryCode
+		var usedAt sql.NullTime
+
+		err := rows.Scan(&code.ID, &code.CustomerID, &code.Salt, &code.Hash, &code.CreatedAt, &usedAt)
+		if err != nil {
+			return nil, err
+		}
+		code.UsedAt = usedAt.Time
+
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
+
+// ReplaceRecoveryCodes deletes all recovery codes of the customer and stores the new ones.
+func (p *Postgres) ReplaceRecoveryCodes(customerID uuid.UUID, codes []domain.RecoveryCode) (int64, error) {
+	_, err := p.conn().Exec(`DELETE FROM customer_recovery_codes WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+	INSERT INTO customer_recovery_codes
+	(id, customer_id, salt, hash, created_at)
+	VALUES ($1, $2, $3, $4, $5)`
+
+	for _, code := range codes {
+		_, err := p.conn().Exec(query, code.ID, code.CustomerID, code.Salt, code.Hash, code.CreatedAt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(codes)), nil
+}
+
+// UseRecoveryCode returns 0 affected rows when the code was already used.
+func (p *Postgres) UseRecoveryCode(codeID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE customer_recovery_codes SET used_at = $1 WHERE id = $2 AND used_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, codeID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) CountSecondFactorFailures(customerID uuid.UUID, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM second_factor_failures WHERE customer_id = $1 AND failed_at > $2`
+
+	var count int
+	if err := p.conn().QueryRow(query, customerID, since).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (p *Postgres) CreateSecondFactorFailure(customerID uuid.UUID, at time.Time) (int64, error) {
+	query := `INSERT INTO second_factor_failures (id, customer_id, failed_at) VALUES ($1, $2, $3)`
+
+	_, err := p.conn().Exec(query, uuid.New(), customerID, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func (p *Postgres) DeleteSecondFactorFailures(customerID uuid.UUID) (int64, error) {
+	query := `DELETE FROM second_factor_failures WHERE customer_id = $1`
+
+	result, err := p.conn().Exec(query, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanCredentials(row scanner) (domain.Credentials, error) {
+	var credentials domain.Credentials
+
+	err := row.Scan(&credentials.CustomerID, &credentials.PasswordHash, &credentials.TOTPSecret, &credentials.TOTPEnabled, &credentials.TOTPLastStep, &credentials.CreatedAt, &credentials.UpdatedAt)
+	if err != nil {
+		return domain.Credentials{}, err
+	}
+
+	return credentials, nil
+}
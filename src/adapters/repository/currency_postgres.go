@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetEnabledCurrencies() ([]domain.Currency, error) {
+	query := `SELECT code FROM currencies WHERE enabled = TRUE ORDER BY code`
+
+	rows, err := p.conn().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var currencies []domain.Currency
+
+	for rows.Next() {
+		var currency domain.Currency
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+
+		currencies = append(currencies, currency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return currencies, nil
+}
+
+func (p *Postgres) SetCurrencyEnabled(code domain.Currency, enabled bool) (int64, error) {
+	query := `
+	INSERT INTO currencies (code, enabled, updated_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (code) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at`
+
+	result, err := p.conn().Exec(query, code, enabled, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -13,7 +13,7 @@ func (p *Postgres) GetCustomer(id uuid.UUID) (domain.Customer, error) {
 
     var customer domain.Customer
 
-    err := p.DB.QueryRow(query, id).Scan(&customer.ID, &customer.FirstName, &customer.LastName, &customer.Birthday, &customer.Email, &customer.Phone, &customer.State, &customer.Address, &customer.CreatedAt, &customer.Token)
+    err := p.conn().QueryRow(query, id).Scan(&customer.ID, &customer.FirstName, &customer.LastName, &customer.Birthday, &customer.Email, &customer.Phone, &customer.State, &customer.Address, &customer.CreatedAt, &customer.Role)
     if err != nil {
         return domain.Customer{}, err
     }
@@ -24,7 +24,7 @@ func (p *Postgres) GetCustomer(id uuid.UUID) (domain.Customer, error) {
 func (p *Postgres) GetAllCustomers(limit int, offset int) ([]domain.Customer, error) {
     query := `SELECT * FROM customers ORDER BY created_at LIMIT $1 OFFSET $2`
 
-    rows, err := p.DB.Query(query, limit, offset)
+    rows, err := p.conn().Query(query, limit, offset)
     if err != nil {
         return nil, err
     }
@@ -35,7 +35,7 @@ func (p *Postgres) GetAllCustomers(limit int, offset int) ([]domain.Customer, er
     for rows.Next() {
         var customer domain.Customer
 
-        if err := rows.Scan(&customer.ID, &customer.FirstName, &customer.LastName, &customer.Birthday, &customer.Email, &customer.Phone, &customer.State, &customer.Address, &customer.CreatedAt, &customer.Token); err != nil {
+        if err := rows.Scan(&customer.ID, &customer.FirstName, &customer.LastName, &customer.Birthday, &customer.Email, &customer.Phone, &customer.State, &customer.Address, &customer.CreatedAt, &customer.Role); err != nil {
             return nil, err
         }
 
@@ -56,10 +56,10 @@ func (p *Postgres) GetAllCustomers(limit int, offset int) ([]domain.Customer, er
 func (p *Postgres) CreateCustomer(customer domain.Customer) (int64, error) {
     query := `
     INSERT INTO customers 
-    (id, first_name, last_name, birthday, email, phone, state, address, created_at, token) 
+    (id, first_name, last_name, birthday, email, phone, state, address, created_at, role) 
     VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-    _, err := p.DB.Exec(query, customer.ID.String(), customer.FirstName, customer.LastName, customer.Birthday, customer.Email, customer.Phone, customer.State, customer.Address, customer.CreatedAt, customer.Token)
+    _, err := p.conn().Exec(query, customer.ID.String(), customer.FirstName, customer.LastName, customer.Birthday, customer.Email, customer.Phone, customer.State, customer.Address, customer.CreatedAt, customer.Role)
     if err != nil {
         return 0, err
     }
@@ -73,7 +73,7 @@ func (p *Postgres) UpdateCustomer(customer domain.Customer) (int64, error) {
     SET first_name = $1, last_name = $2, birthday = $3, email = $4, phone = $5, state = $6, address = $7
     WHERE id = $8`
 
-    result, err := p.DB.Exec(query, customer.FirstName, customer.LastName, customer.Birthday, customer.Email, customer.Phone, customer.State, customer.Address, customer.ID)
+    result, err := p.conn().Exec(query, customer.FirstName, customer.LastName, customer.Birthday, customer.Email, customer.Phone, customer.State, customer.Address, customer.ID)
     if err != nil {
         return 0, err
     }
@@ -86,10 +86,10 @@ func (p *Postgres) UpdateCustomer(customer domain.Customer) (int64, error) {
     return rowsAffected, nil
 }
 
-func (p *Postgres) DeleteCustomer(customerID uuid.UUID) (int64, error) {
-    query := `DELETE FROM customers WHERE id = $1`
+func (p *Postgres) UpdateCustomerRole(customerID uuid.UUID, role domain.Role) (int64, error) {
+    query := `UPDATE customers SET role = $1 WHERE id = $2`
 
-    result, err := p.DB.Exec(query, customerID)
+    result, err := p.conn().Exec(query, role, customerID)
     if err != nil {
         return 0, err
     }
@@ -102,17 +102,18 @@ func (p *Postgres) DeleteCustomer(customerID uuid.UUID) (int64, error) {
     return rowsAffected, nil
 }
 
-func (p *Postgres) AuthCustomer(customerID uuid.UUID, token string) (bool, error) {
-    query := `SELECT EXISTS(SELECT 1 FROM customers WHERE id = $1 AND token = $2)`
-
-    var exists bool
-    err := p.DB.QueryRow(query, customerID, token).Scan(&exists)
-    switch {
-    case err == sql.ErrNoRows:
-        return false, nil
-    case err != nil:
-        return false, err
-    default:
-        return exists, nil
+func (p *Postgres) DeleteCustomer(customerID uuid.UUID) (int64, error) {
+    query := `DELETE FROM customers WHERE id = $1`
+
+    result, err := p.conn().Exec(query, customerID)
+    if err != nil {
+        return 0, err
     }
-}
\ No newline at end of file
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+
+    return rowsAffected, nil
+}
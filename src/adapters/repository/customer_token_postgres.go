@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetCustomerTokens(customerID uuid.UUID) ([]domain.CustomerToken, error) {
+	query := `SELECT * FROM customer_tokens WHERE customer_id = $1 ORDER BY created_at`
+
+	rows, err := p.conn().Query(query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.CustomerToken
+
+	for rows.Next() {
+		token, err := scanCustomerToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return tokens, nil
+}
+
+// GetActiveCustomerTokens returns the tokens that are neither revoked nor expired at the given time.
+func (p *Postgres) GetActiveCustomerTokens(customerID uuid.UUID, at time.Time) ([]domain.CustomerToken, error) {
+	query := `
+	SELECT * FROM customer_tokens
+	WHERE customer_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`
+
+	rows, err := p.conn().Query(query, customerID, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.CustomerToken
+
+	for rows.Next() {
+		token, err := scanCustomerToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (p *Postgres) CreateCustomerToken(token domain.CustomerToken) (int64, error) {
+	query := `
+	INSERT INTO customer_tokens
+	(id, customer_id, name, salt, hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := p.conn().Exec(query, token.ID, token.CustomerID, token.Name, token.Salt, token.Hash, token.CreatedAt, nullTime(token.ExpiresAt))
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func (p *Postgres) TouchCustomerToken(tokenID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE customer_tokens SET last_used_at = $1 WHERE id = $2`
+
+	result, err := p.conn().Exec(query, at, tokenID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// RevokeCustomerToken returns 0 affected rows when the customer has no such token or it is already revoked.
+func (p *Postgres) RevokeCustomerToken(customerID, tokenID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE customer_tokens SET revoked_at = $1 WHERE id = $2 AND customer_id = $3 AND revoked_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, tokenID, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanCustomerToken(row scanner) (domain.CustomerToken, error) {
+	var token domain.CustomerToken
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.CustomerID, &token.Name, &token.Salt, &token.Hash, &token.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return domain.CustomerToken{}, err
+	}
+
+	token.ExpiresAt = expiresAt.Time
+	token.LastUsedAt = lastUsedAt.Time
+	token.RevokedAt = revokedAt.Time
+
+	return token, nil
+}
+
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: !value.IsZero()}
+}
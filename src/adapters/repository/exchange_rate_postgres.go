@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetExchangeRate(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	query := `
+	SELECT from_currency, to_currency, rate, effective_at, source
+	FROM exchange_rates
+	WHERE from_currency = $1 AND to_currency = $2 AND effective_at <= $3
+	ORDER BY effective_at DESC
+	LIMIT 1`
+
+	var exchangeRate domain.ExchangeRate
+	var rate string
+
+	err := p.conn().QueryRow(query, pair.From, pair.To, at).Scan(&exchangeRate.Pair.From, &exchangeRate.Pair.To, &rate, &exchangeRate.EffectiveAt, &exchangeRate.Source)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	exchangeRate.Rate, err = domain.ParseRate(rate)
+	if err != nil {
+		return domain.ExchangeRate{}, fmt.Errorf("Bad rate format for pair: %s", pair.String())
+	}
+
+	return exchangeRate, nil
+}
+
+// CreateExchangeRate inserts the rate or replaces the one effective at the same time.
+func (p *Postgres) CreateExchangeRate(rate domain.ExchangeRate) (int64, error) {
+	query := `
+	INSERT INTO exchange_rates
+	(from_currency, to_currency, rate, effective_at, source, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (from_currency, to_currency, effective_at) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source`
+
+	_, err := p.conn().Exec(query, rate.Pair.From, rate.Pair.To, domain.FormatRate(rate.Rate), rate.EffectiveAt, rate.Source, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+// GetFXQuoteForUpdate locks the quote until the transaction ends, so it can only be used once.
+func (p *Postgres) GetFXQuoteForUpdate(quoteID uuid.UUID) (domain.FXQuote, error) {
+	query := `SELECT * FROM fx_quotes WHERE id = $1 LIMIT 1 FOR UPDATE`
+
+	return scanFXQuote(p.conn().QueryRow(query, quoteID))
+}
+
+func (p *Postgres) CreateFXQuote(quote domain.FXQuote) (int64, error) {
+	query := `
+	INSERT INTO fx_quotes
+	(id, from_currency, to_currency, amount, mid_rate, rate, spread, fee, converted, transaction_id, created_at, expires_at, customer_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := p.conn().Exec(query, quote.ID, quote.Pair.From, quote.Pair.To, quote.Amount.String(), domain.FormatRate(quote.MidRate), domain.FormatRate(quote.Rate), domain.FormatRate(quote.Spread), quote.Fee.String(), quote.Converted.String(), nullUUID(quote.TransactionID), quote.CreatedAt, quote.ExpiresAt, nullUUID(quote.CustomerID))
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func (p *Postgres) UseFXQuote(quoteID uuid.UUID, transactionID uuid.UUID) (int64, error) {
+	query := `UPDATE fx_quotes SET transaction_id = $1 WHERE id = $2 AND transaction_id IS NULL`
+
+	result, err := p.conn().Exec(query, transactionID, quoteID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanFXQuote(row scanner) (domain.FXQuote, error) {
+	var quote domain.FXQuote
+	var amount, midRate, rate, spread, fee, converted string
+	var transactionID, customerID uuid.NullUUID
+
+	err := row.Scan(&quote.ID, &quote.Pair.From, &quote.Pair.To, &amount, &midRate, &rate, &spread, &fee, &converted, &transactionID, &quote.CreatedAt, &quote.ExpiresAt, &customerID)
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	quote.TransactionID = transactionID.UUID
+	quote.CustomerID = customerID.UUID
+
+	if quote.Amount, err = domain.ParseMoney(amount, quote.Pair.From); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad amount format at fx quote id: %s", quote.ID.String())
+	}
+	if quote.Fee, err = domain.ParseMoney(fee, quote.Pair.From); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad fee format at fx quote id: %s", quote.ID.String())
+	}
+	if quote.Converted, err = domain.ParseMoney(converted, quote.Pair.To); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad converted amount format at fx quote id: %s", quote.ID.String())
+	}
+	if quote.MidRate, err = domain.ParseRate(midRate); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad rate format at fx quote id: %s", quote.ID.String())
+	}
+	if quote.Rate, err = domain.ParseRate(rate); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad rate format at fx quote id: %s", quote.ID.String())
+	}
+	if quote.Spread, err = domain.ParseRate(spread); err != nil {
+		return domain.FXQuote{}, fmt.Errorf("Bad spread format at fx quote id: %s", quote.ID.String())
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetIdempotencyKey(principal string, key string) (domain.IdempotencyKey, error) {
+	query := `SELECT * FROM idempotency_keys WHERE principal = $1 AND key = $2 LIMIT 1`
+
+	var idempotencyKey domain.IdempotencyKey
+	var responseCode sql.NullInt64
+	var responseLocation sql.NullString
+
+	err := p.conn().QueryRow(query, principal, key).Scan(&idempotencyKey.Key, &idempotencyKey.RequestHash, &responseCode, &responseLocation, &idempotencyKey.ResponseBody, &idempotencyKey.CreatedAt, &idempotencyKey.ExpiresAt, &idempotencyKey.Principal)
+	if err != nil {
+		return domain.IdempotencyKey{}, err
+	}
+
+	idempotencyKey.ResponseCode = int(responseCode.Int64)
+	idempotencyKey.ResponseLocation = responseLocation.String
+
+	return idempotencyKey, nil
+}
+
+// CreateIdempotencyKey returns 0 affected rows when the principal already took the key.
+func (p *Postgres) CreateIdempotencyKey(key domain.IdempotencyKey) (int64, error) {
+	query := `
+	INSERT INTO idempotency_keys
+	(key, request_hash, created_at, expires_at, principal)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (principal, key) DO NOTHING`
+
+	result, err := p.conn().Exec(query, key.Key, key.RequestHash, key.CreatedAt, key.ExpiresAt, key.Principal)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) UpdateIdempotencyKey(key domain.IdempotencyKey) (int64, error) {
+	query := `
+	UPDATE idempotency_keys
+	SET response_code = $1, response_location = $2, response_body = $3
+	WHERE principal = $4 AND key = $5`
+
+	result, err := p.conn().Exec(query, key.ResponseCode, key.ResponseLocation, key.ResponseBody, key.Principal, key.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) DeleteIdempotencyKey(principal string, key string) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE principal = $1 AND key = $2`
+
+	result, err := p.conn().Exec(query, principal, key)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetJobRuns(job string, limit int, offset int) ([]domain.JobRun, error) {
+	query := `SELECT * FROM job_runs WHERE job = $1 ORDER BY started_at DESC LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, job, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []domain.JobRun
+
+	for rows.Next() {
+		run, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return runs, nil
+}
+
+// CreateJobRun records the start of a run, a scheduled run whose time already has a run isnt
+// recorded and zero rows are returned.
+func (p *Postgres) CreateJobRun(run domain.JobRun) (int64, error) {
+	query := `
+	INSERT INTO job_runs
+	(id, job, trigger, triggered_by, scheduled_for, status, error, started_at, finished_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (job, scheduled_for) DO NOTHING`
+
+	result, err := p.conn().Exec(query, run.ID, run.Job, run.Trigger, nullUUID(run.TriggeredBy), nullTime(run.ScheduledFor), run.Status, run.Error, run.StartedAt, nullTime(run.FinishedAt))
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) FinishJobRun(run domain.JobRun) (int64, error) {
+	query := `UPDATE job_runs SET status = $1, error = $2, finished_at = $3 WHERE id = $4`
+
+	result, err := p.conn().Exec(query, run.Status, run.Error, nullTime(run.FinishedAt), run.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// InterruptJobRuns fails the runs of the job that are still running, only call it holding the
+// lock of the job, when they can only be left over from an instance that went down.
+func (p *Postgres) InterruptJobRuns(job string, at time.Time) (int64, error) {
+	query := `
+	UPDATE job_runs SET status = 'FAILED', error = 'Interrupted before it finished', finished_at = $2
+	WHERE job = $1 AND status = 'RUNNING'`
+
+	result, err := p.conn().Exec(query, job, at)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// LockJob takes the advisory lock of the job so only one instance runs it at a time. Session
+// locks belong to a connection, so the lock keeps a connection of its own until it is unlocked.
+func (p *Postgres) LockJob(job string) (func() error, bool, error) {
+	ctx := context.Background()
+
+	conn, err := p.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, "job:"+job).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock := func() error {
+		defer conn.Close()
+
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, "job:"+job)
+		return err
+	}
+
+	return unlock, true, nil
+}
+
+func scanJobRun(row scanner) (domain.JobRun, error) {
+	var run domain.JobRun
+	var triggeredBy uuid.NullUUID
+	var scheduledFor, finishedAt sql.NullTime
+
+	err := row.Scan(&run.ID, &run.Job, &run.Trigger, &triggeredBy, &scheduledFor, &run.Status, &run.Error, &run.StartedAt, &finishedAt)
+	if err != nil {
+		return domain.JobRun{}, err
+	}
+
+	run.TriggeredBy = triggeredBy.UUID
+	run.ScheduledFor = scheduledFor.Time
+	run.FinishedAt = finishedAt.Time
+
+	return run, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetLedgerEntriesByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.LedgerEntry, error) {
+	query := `SELECT * FROM ledger_entries WHERE account_id = $1 ORDER BY created_at, journal_id LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.LedgerEntry
+
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return entries, nil
+}
+
+// GetLedgerEntriesSince returns the entries of the account posted at or after the given time, oldest first.
+func (p *Postgres) GetLedgerEntriesSince(accountID uuid.UUID, since time.Time) ([]domain.LedgerEntry, error) {
+	query := `SELECT * FROM ledger_entries WHERE account_id = $1 AND created_at >= $2 ORDER BY created_at, journal_id`
+
+	rows, err := p.conn().Query(query, accountID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.LedgerEntry
+
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return entries, nil
+}
+
+func (p *Postgres) GetLedgerBalance(accountID uuid.UUID, currency domain.Currency) (domain.Money, error) {
+	query := `
+	SELECT COALESCE(SUM(CASE WHEN side = 'CREDIT' THEN amount ELSE -amount END), 0)
+	FROM ledger_entries
+	WHERE account_id = $1 AND currency = $2`
+
+	var balance string
+
+	err := p.conn().QueryRow(query, accountID, currency).Scan(&balance)
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	return domain.ParseMoney(balance, currency)
+}
+
+func (p *Postgres) CreateLedgerEntries(entries []domain.LedgerEntry) (int64, error) {
+	query := `
+	INSERT INTO ledger_entries
+	(id, journal_id, journal_kind, transaction_id, account_id, system_account, side, amount, currency, description, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	for _, entry := range entries {
+		_, err := p.conn().Exec(query, entry.ID, entry.JournalID, entry.JournalKind, nullUUID(entry.TransactionID), nullUUID(entry.AccountID), nullString(string(entry.SystemAccount)), entry.Side, entry.Amount.String(), entry.Amount.Currency, entry.Description, entry.CreatedAt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(entries)), nil
+}
+
+func scanLedgerEntry(row scanner) (domain.LedgerEntry, error) {
+	var entry domain.LedgerEntry
+	var transactionID, accountID uuid.NullUUID
+	var systemAccount, description sql.NullString
+	var amount string
+	var currency domain.Currency
+
+	err := row.Scan(&entry.ID, &entry.JournalID, &entry.JournalKind, &transactionID, &accountID, &systemAccount, &entry.Side, &amount, &currency, &description, &entry.CreatedAt)
+	if err != nil {
+		return domain.LedgerEntry{}, err
+	}
+
+	entry.TransactionID = transactionID.UUID
+	entry.AccountID = accountID.UUID
+	entry.SystemAccount = domain.SystemAccount(systemAccount.String)
+	entry.Description = description.String
+
+	entry.Amount, err = domain.ParseMoney(amount, currency)
+	if err != nil {
+		return domain.LedgerEntry{}, fmt.Errorf("Bad amount format at ledger entry id: %s", entry.ID.String())
+	}
+
+	return entry, nil
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetMandatesByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Mandate, error) {
+	query := `SELECT * FROM mandates WHERE payer_account_id = $1 OR creditor_account_id = $1 ORDER BY created_at LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mandates []domain.Mandate
+
+	for rows.Next() {
+		mandate, err := scanMandate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		mandates = append(mandates, mandate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(mandates) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return mandates, nil
+}
+
+func (p *Postgres) GetMandate(mandateID uuid.UUID) (domain.Mandate, error) {
+	query := `SELECT * FROM mandates WHERE id = $1 LIMIT 1`
+
+	return scanMandate(p.conn().QueryRow(query, mandateID))
+}
+
+func (p *Postgres) GetMandateForUpdate(mandateID uuid.UUID) (domain.Mandate, error) {
+	query := `SELECT * FROM mandates WHERE id = $1 LIMIT 1 FOR UPDATE`
+
+	return scanMandate(p.conn().QueryRow(query, mandateID))
+}
+
+func (p *Postgres) CreateMandate(mandate domain.Mandate) (int64, error) {
+	query := `
+	INSERT INTO mandates
+	(id, payer_account_id, creditor_account_id, reference, max_amount, currency, frequency, status, created_at, cancelled_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := p.conn().Exec(query, mandate.ID, mandate.PayerAccountID, mandate.CreditorAccountID, mandate.Reference, mandate.MaxAmount.String(), mandate.MaxAmount.Currency, mandate.Frequency, mandate.Status, mandate.CreatedAt, nullTime(mandate.CancelledAt))
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func (p *Postgres) UpdateMandate(mandate domain.Mandate) (int64, error) {
+	query := `UPDATE mandates SET status = $1, cancelled_at = $2 WHERE id = $3`
+
+	result, err := p.conn().Exec(query, mandate.Status, nullTime(mandate.CancelledAt), mandate.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// CancelMandatesByAccount cancels every active mandate the account pays or collects.
+func (p *Postgres) CancelMandatesByAccount(accountID uuid.UUID, at time.Time) (int64, error) {
+	query := `
+	UPDATE mandates SET status = 'CANCELLED', cancelled_at = $1
+	WHERE (payer_account_id = $2 OR creditor_account_id = $2) AND status = 'ACTIVE'`
+
+	result, err := p.conn().Exec(query, at, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// GetMandateCollections returns the transfers collected under the mandate, the latest first.
+func (p *Postgres) GetMandateCollections(mandateID uuid.UUID, limit int, offset int) ([]domain.Transaction, error) {
+	query := selectTransactions + ` WHERE t.mandate_id = $1 ORDER BY t.created_at DESC LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, mandateID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []domain.Transaction
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return transactions, nil
+}
+
+// CountMandateCollections counts the collections made under the mandate since the given time,
+// refunded ones included. Collections that failed never happened and arent counted.
+func (p *Postgres) CountMandateCollections(mandateID uuid.UUID, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM transactions WHERE mandate_id = $1 AND status <> 'FAILED' AND created_at >= $2`
+
+	var count int
+	if err := p.conn().QueryRow(query, mandateID, since).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func scanMandate(row scanner) (domain.Mandate, error) {
+	var mandate domain.Mandate
+	var maxAmount string
+	var currency domain.Currency
+	var cancelledAt sql.NullTime
+
+	err := row.Scan(&mandate.ID, &mandate.PayerAccountID, &mandate.CreditorAccountID, &mandate.Reference, &maxAmount, &currency, &mandate.Frequency, &mandate.Status, &mandate.CreatedAt, &cancelledAt)
+	if err != nil {
+		return domain.Mandate{}, err
+	}
+
+	mandate.MaxAmount, err = domain.ParseMoney(maxAmount, currency)
+	if err != nil {
+		return domain.Mandate{}, fmt.Errorf("Bad max amount format at mandate id: %s", mandate.ID.String())
+	}
+
+	mandate.CancelledAt = cancelledAt.Time
+
+	return mandate, nil
+}
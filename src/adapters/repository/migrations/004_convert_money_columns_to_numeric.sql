@@ -0,0 +1,13 @@
+-- Balances and amounts used to be stored as FLOAT, which drifts on conversions and interest.
+-- Casting a double precision to NUMERIC keeps its exact shortest decimal representation.
+-- Migrations run on every start, so only convert the columns that are still FLOAT.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns WHERE table_name = 'accounts' AND column_name = 'balance') = 'double precision' THEN
+        ALTER TABLE accounts ALTER COLUMN balance TYPE NUMERIC USING balance::NUMERIC;
+    END IF;
+
+    IF (SELECT data_type FROM information_schema.columns WHERE table_name = 'transactions' AND column_name = 'amount') = 'double precision' THEN
+        ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC USING amount::NUMERIC;
+    END IF;
+END $$;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY,
+    journal_id UUID NOT NULL,
+    journal_kind VARCHAR(32) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    account_id UUID REFERENCES accounts(id),
+    system_account VARCHAR(64),
+    side VARCHAR(6) NOT NULL CHECK (side IN ('DEBIT', 'CREDIT')),
+    amount NUMERIC NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK ((account_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_account_id_idx ON ledger_entries (account_id, created_at);
+CREATE INDEX IF NOT EXISTS ledger_entries_journal_id_idx ON ledger_entries (journal_id);
+
+-- Accounts that existed before the ledger get their current balance booked as an opening journal,
+-- so the derived balance matches the stored one from the start
+WITH opening AS (
+    SELECT gen_random_uuid() AS journal_id, a.id, a.balance, a.currency
+    FROM accounts a
+    WHERE a.balance > 0 AND NOT EXISTS (SELECT 1 FROM ledger_entries l WHERE l.account_id = a.id)
+)
+INSERT INTO ledger_entries (id, journal_id, journal_kind, transaction_id, account_id, system_account, side, amount, currency, description, created_at)
+SELECT gen_random_uuid(), journal_id, 'OPENING', NULL, NULL, 'OPENING_BALANCE', 'DEBIT', balance, currency, 'Opening balance', NOW() FROM opening
+UNION ALL
+SELECT gen_random_uuid(), journal_id, 'OPENING', NULL, id, NULL, 'CREDIT', balance, currency, 'Opening balance', NOW() FROM opening;
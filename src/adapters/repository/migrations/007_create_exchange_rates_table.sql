@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    effective_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    source VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (from_currency, to_currency, effective_at)
+);
+
+-- The rates that used to be hardcoded, effective since forever
+INSERT INTO exchange_rates (from_currency, to_currency, rate, effective_at, source, created_at)
+VALUES
+    ('USD', 'EUR', 0.9369, '1970-01-01T00:00:00Z', 'default', NOW()),
+    ('EUR', 'USD', 1.0674, '1970-01-01T00:00:00Z', 'default', NOW())
+ON CONFLICT DO NOTHING;
+
+-- Transactions made before rates were recorded were all converted with the hardcoded rates. Migrations
+-- run on every start, so they are only backfilled when the column is added, later transactions without
+-- a rate keep it NULL.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'transactions' AND column_name = 'exchange_rate') THEN
+        ALTER TABLE transactions ADD COLUMN exchange_rate NUMERIC;
+
+        UPDATE transactions SET exchange_rate = CASE
+            WHEN currency = 'USD-EUR' THEN 0.9369
+            WHEN currency = 'EUR-USD' THEN 1.0674
+            ELSE 1
+        END;
+    END IF;
+END $$;
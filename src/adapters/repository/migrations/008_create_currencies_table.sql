@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(3) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- The currencies offered before the registry existed
+INSERT INTO currencies (code, enabled) VALUES ('USD', TRUE), ('EUR', TRUE)
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    amount NUMERIC NOT NULL CHECK (amount > 0),
+    mid_rate NUMERIC NOT NULL,
+    rate NUMERIC NOT NULL,
+    spread NUMERIC NOT NULL,
+    fee NUMERIC NOT NULL,
+    converted NUMERIC NOT NULL,
+    transaction_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS quote_id UUID;
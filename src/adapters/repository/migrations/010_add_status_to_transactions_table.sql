@@ -0,0 +1,5 @@
+-- Transactions made before the lifecycle existed were all posted
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'POSTED';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS transactions_status_idx ON transactions (status, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of UUID REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS refunded NUMERIC NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS transactions_reversal_of_idx ON transactions (reversal_of);
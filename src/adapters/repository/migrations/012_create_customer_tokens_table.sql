@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS customer_tokens (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    salt BYTEA NOT NULL,
+    hash BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS customer_tokens_customer_id_idx ON customer_tokens (customer_id);
+
+-- The plaintext tokens customers were created with are kept only as salted hashes
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'customers' AND column_name = 'token') THEN
+        WITH salted AS (
+            SELECT id, token, uuid_send(gen_random_uuid()) AS salt
+            FROM customers
+            WHERE token IS NOT NULL AND token <> ''
+        )
+        INSERT INTO customer_tokens (id, customer_id, name, salt, hash, created_at)
+        SELECT gen_random_uuid(), id, 'default', salt, sha256(salt || convert_to(token, 'UTF8')), NOW() FROM salted;
+
+        ALTER TABLE customers DROP COLUMN token;
+    END IF;
+END $$;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS customer_credentials (
+    customer_id UUID PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL DEFAULT '',
+    totp_secret BYTEA,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS customer_recovery_codes (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    salt BYTEA NOT NULL,
+    hash BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS customer_recovery_codes_customer_id_idx ON customer_recovery_codes (customer_id);
@@ -0,0 +1,4 @@
+-- Every existing customer starts out as a plain customer, staff roles are granted by an admin
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'customer';
+
+CREATE INDEX IF NOT EXISTS customers_role_idx ON customers (role) WHERE role <> 'customer';
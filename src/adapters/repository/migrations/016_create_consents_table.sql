@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS consents (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    client_name VARCHAR(255) NOT NULL,
+    account_ids UUID[] NOT NULL,
+    scopes TEXT[] NOT NULL,
+    salt BYTEA NOT NULL,
+    hash BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS consents_customer_id_idx ON consents (customer_id);
@@ -0,0 +1,11 @@
+-- Account status used to be a bool anyone could flip. Usable accounts become active, the others
+-- frozen so only the staff of the bank can let money move through them again.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns WHERE table_name = 'accounts' AND column_name = 'status') = 'boolean' THEN
+        ALTER TABLE accounts ALTER COLUMN status TYPE VARCHAR(16) USING CASE WHEN status IS FALSE THEN 'FROZEN' ELSE 'ACTIVE' END;
+    END IF;
+END $$;
+
+ALTER TABLE accounts ALTER COLUMN status SET DEFAULT 'ACTIVE';
+ALTER TABLE accounts ALTER COLUMN status SET NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS nickname VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+-- Balance changes made by the staff of the bank, the ledger journal of each has the same ID
+CREATE TABLE IF NOT EXISTS adjustments (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL CHECK (amount <> 0),
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    note TEXT NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS adjustments_account_id_idx ON adjustments (account_id, created_at);
@@ -0,0 +1,8 @@
+-- Deposits and withdrawals have no account on the other side, their channel tells where the money came from or went to
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'TRANSFER';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS channel VARCHAR(16);
+
+ALTER TABLE transactions ALTER COLUMN sender_account_id DROP NOT NULL;
+ALTER TABLE transactions ALTER COLUMN receiver_account_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_receiver_account_id_idx ON transactions (receiver_account_id, created_at);
@@ -0,0 +1,3 @@
+-- Accounts without an arranged overdraft keep a zero limit and can't go below zero
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit NUMERIC NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_interest_rate FLOAT NOT NULL DEFAULT 0;
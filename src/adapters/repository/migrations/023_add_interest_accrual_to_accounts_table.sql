@@ -0,0 +1,6 @@
+-- Interest accrues daily into accrued_interest and gets posted at the end of the month,
+-- interest_accrued_through is the last day accrued so missed days can be caught up
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS interest_tiers JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS day_count VARCHAR(8) NOT NULL DEFAULT 'ACT/365';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS accrued_interest NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS interest_accrued_through DATE NOT NULL DEFAULT CURRENT_DATE - 1;
@@ -0,0 +1,15 @@
+-- Runs of the background jobs, a time of a schedule can only be run once across all instances
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY,
+    job VARCHAR(64) NOT NULL,
+    trigger VARCHAR(16) NOT NULL,
+    triggered_by UUID,
+    scheduled_for TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(16) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (job, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_idx ON job_runs (job, started_at DESC);
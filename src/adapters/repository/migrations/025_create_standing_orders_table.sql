@@ -0,0 +1,36 @@
+-- Recurring transfers customers set up, the executions record every attempt of a transfer of a day
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    receiver_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    frequency VARCHAR(16) NOT NULL,
+    rule VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_due_date DATE,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    attempts INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS standing_orders_account_id_idx ON standing_orders (account_id, created_at);
+CREATE INDEX IF NOT EXISTS standing_orders_due_idx ON standing_orders (next_attempt_at) WHERE status = 'ACTIVE';
+
+CREATE TABLE IF NOT EXISTS standing_order_executions (
+    id UUID PRIMARY KEY,
+    standing_order_id UUID NOT NULL REFERENCES standing_orders(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+    attempt INT NOT NULL,
+    transaction_id UUID NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (standing_order_id, due_date, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS standing_order_executions_order_idx ON standing_order_executions (standing_order_id, created_at DESC);
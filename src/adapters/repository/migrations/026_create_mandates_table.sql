@@ -0,0 +1,20 @@
+-- Direct debit mandates payers give creditor accounts, the collections are the transfers made under them
+CREATE TABLE IF NOT EXISTS mandates (
+    id UUID PRIMARY KEY,
+    payer_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    creditor_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    reference VARCHAR(140) NOT NULL DEFAULT '',
+    max_amount NUMERIC NOT NULL CHECK (max_amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    frequency VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS mandates_payer_account_id_idx ON mandates (payer_account_id, created_at);
+CREATE INDEX IF NOT EXISTS mandates_creditor_account_id_idx ON mandates (creditor_account_id, created_at);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS mandate_id UUID REFERENCES mandates(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_mandate_id_idx ON transactions (mandate_id, created_at) WHERE mandate_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Idempotency keys used to be shared by every caller. They are now scoped to who sent the request,
+-- the responses stored before could have been replayed to anyone and are dropped.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS principal VARCHAR(64) NOT NULL DEFAULT '';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.key_column_usage
+        WHERE table_name = 'idempotency_keys' AND constraint_name = 'idempotency_keys_pkey' AND column_name = 'principal'
+    ) THEN
+        DELETE FROM idempotency_keys WHERE principal = '';
+
+        ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+        ALTER TABLE idempotency_keys ADD PRIMARY KEY (principal, key);
+    END IF;
+END $$;
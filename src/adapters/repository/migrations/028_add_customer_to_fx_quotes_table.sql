@@ -0,0 +1,2 @@
+-- Quotes are bound to the customer who asked for them, only transfers from their accounts can use them
+ALTER TABLE fx_quotes ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id) ON DELETE CASCADE;
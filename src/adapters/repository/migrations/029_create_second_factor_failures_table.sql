@@ -0,0 +1,8 @@
+-- Invalid two-factor codes, too many of them in a short time lock the second factor of the customer
+CREATE TABLE IF NOT EXISTS second_factor_failures (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS second_factor_failures_customer_id_idx ON second_factor_failures (customer_id, failed_at);
@@ -2,13 +2,32 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
 )
 
+const MAX_TX_ATTEMPTS = 5
+
 type Postgres struct {
 	DB *sql.DB
+	tx *sql.Tx // Set when the repository is bound to a unit of work
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// executor is the subset of *sql.DB and *sql.Tx used by the repositories
+type executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
 func NewPostgres(host, port, user, password, dbname, sslmode string) (*Postgres, error) {
@@ -39,7 +58,7 @@ func (p *Postgres) DatabaseHas(table, column string, value any) bool{
 	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 LIMIT 1", column, table, column)
 
 	var result sql.NullString
-	err := p.DB.QueryRow(query, value).Scan(&result)
+	err := p.conn().QueryRow(query, value).Scan(&result)
 
 	return err == nil && result.Valid
 }
@@ -78,11 +97,61 @@ func (p *Postgres) ClearAllTables() error {
 	return nil
 }
 
-func (p *Postgres) BeginTransaction() (*sql.Tx, error) {
+// WithinTx runs fn with repositories bound to a single database transaction.
+// The transaction is committed when fn returns nil and rolled back otherwise.
+// Serialization failures and deadlocks are retried, so fn must be safe to run again.
+// Calling it on repositories that are already bound reuses the outer transaction.
+func (p *Postgres) WithinTx(fn func(repositories ports.IRepositories) error) error {
+	if p.tx != nil {
+		return fn(p)
+	}
+
+	var err error
+	for attempt := 1; attempt <= MAX_TX_ATTEMPTS; attempt++ {
+		err = p.runTx(fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		log.Printf("[EVENT]\tRetrying transaction (attempt %v): %v", attempt, err)
+		time.Sleep(time.Duration(attempt*10) * time.Millisecond)
+	}
+
+	return err
+}
+
+func (p *Postgres) runTx(fn func(repositories ports.IRepositories) error) error {
 	tx, err := p.DB.Begin()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return tx, nil
+	if err := fn(&Postgres{DB: p.DB, tx: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isRetryable reports whether postgres aborted the transaction because of
+// a serialization failure or a deadlock, both of which are safe to retry.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+func (p *Postgres) conn() executor {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.DB
 }
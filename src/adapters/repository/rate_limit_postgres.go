@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetRateLimitBucketForUpdate(key string) (domain.RateLimitBucket, error) {
+	query := `SELECT * FROM rate_limit_buckets WHERE key = $1 LIMIT 1 FOR UPDATE`
+
+	var bucket domain.RateLimitBucket
+
+	err := p.conn().QueryRow(query, key).Scan(&bucket.Key, &bucket.Tokens, &bucket.UpdatedAt, &bucket.FullAt)
+	if err != nil {
+		return domain.RateLimitBucket{}, err
+	}
+
+	return bucket, nil
+}
+
+func (p *Postgres) SaveRateLimitBucket(bucket domain.RateLimitBucket) (int64, error) {
+	query := `
+	INSERT INTO rate_limit_buckets
+	(key, tokens, updated_at, full_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (key) DO UPDATE
+	SET tokens = EXCLUDED.tokens, updated_at = EXCLUDED.updated_at, full_at = EXCLUDED.full_at`
+
+	result, err := p.conn().Exec(query, bucket.Key, bucket.Tokens, bucket.UpdatedAt, bucket.FullAt)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteFullRateLimitBuckets forgets the buckets that refilled by the given time, a missing bucket counts as full.
+func (p *Postgres) DeleteFullRateLimitBuckets(at time.Time) (int64, error) {
+	query := `DELETE FROM rate_limit_buckets WHERE full_at <= $1`
+
+	result, err := p.conn().Exec(query, at)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetRefreshTokenForUpdate(tokenID uuid.UUID) (domain.RefreshToken, error) {
+	query := `SELECT * FROM refresh_tokens WHERE id = $1 FOR UPDATE`
+
+	return scanRefreshToken(p.conn().QueryRow(query, tokenID))
+}
+
+func (p *Postgres) CreateRefreshToken(token domain.RefreshToken) (int64, error) {
+	query := `
+	INSERT INTO refresh_tokens
+	(id, customer_id, family_id, salt, hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := p.conn().Exec(query, token.ID, token.CustomerID, token.FamilyID, token.Salt, token.Hash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// UseRefreshToken returns 0 affected rows when the token was already used.
+func (p *Postgres) UseRefreshToken(tokenID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, tokenID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) RevokeRefreshTokenFamily(familyID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, familyID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// RevokeCustomerRefreshTokens revokes every refresh token family of the customer.
+func (p *Postgres) RevokeCustomerRefreshTokens(customerID uuid.UUID, at time.Time) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE customer_id = $2 AND revoked_at IS NULL`
+
+	result, err := p.conn().Exec(query, at, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanRefreshToken(row scanner) (domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.CustomerID, &token.FamilyID, &token.Salt, &token.Hash, &token.CreatedAt, &token.ExpiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	token.UsedAt = usedAt.Time
+	token.RevokedAt = revokedAt.Time
+
+	return token, nil
+}
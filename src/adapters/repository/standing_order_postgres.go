@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func (p *Postgres) GetStandingOrdersByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.StandingOrder, error) {
+	query := `SELECT * FROM standing_orders WHERE account_id = $1 ORDER BY created_at LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []domain.StandingOrder
+
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return orders, nil
+}
+
+func (p *Postgres) GetStandingOrder(standingOrderID uuid.UUID) (domain.StandingOrder, error) {
+	query := `SELECT * FROM standing_orders WHERE id = $1 LIMIT 1`
+
+	return scanStandingOrder(p.conn().QueryRow(query, standingOrderID))
+}
+
+func (p *Postgres) GetStandingOrderForUpdate(standingOrderID uuid.UUID) (domain.StandingOrder, error) {
+	query := `SELECT * FROM standing_orders WHERE id = $1 LIMIT 1 FOR UPDATE`
+
+	return scanStandingOrder(p.conn().QueryRow(query, standingOrderID))
+}
+
+// GetDueStandingOrders returns the active orders whose next transfer should be attempted by the given time.
+func (p *Postgres) GetDueStandingOrders(at time.Time) ([]uuid.UUID, error) {
+	query := `
+	SELECT id FROM standing_orders
+	WHERE status = 'ACTIVE' AND next_attempt_at <= $1
+	ORDER BY next_attempt_at`
+
+	rows, err := p.conn().Query(query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []uuid.UUID
+
+	for rows.Next() {
+		var orderID uuid.UUID
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orderIDs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return orderIDs, nil
+}
+
+func (p *Postgres) CreateStandingOrder(order domain.StandingOrder) (int64, error) {
+	query := `
+	INSERT INTO standing_orders
+	(id, account_id, receiver_account_id, amount, currency, frequency, rule, start_date, end_date, next_due_date, next_attempt_at, attempts, status, created_at, cancelled_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+
+	_, err := p.conn().Exec(query, order.ID, order.AccountID, order.ReceiverAccountID, order.Amount.String(), order.Amount.Currency, order.Frequency, order.Recurrence.Rule, nullDate(order.StartDate), nullDate(order.EndDate), nullDate(order.NextDueDate), nullTime(order.NextAttemptAt), order.Attempts, order.Status, order.CreatedAt, nullTime(order.CancelledAt))
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// UpdateStandingOrder saves where the schedule of the order is and whether it is still active.
+func (p *Postgres) UpdateStandingOrder(order domain.StandingOrder) (int64, error) {
+	query := `
+	UPDATE standing_orders
+	SET next_due_date = $1, next_attempt_at = $2, attempts = $3, status = $4, cancelled_at = $5
+	WHERE id = $6`
+
+	result, err := p.conn().Exec(query, nullDate(order.NextDueDate), nullTime(order.NextAttemptAt), order.Attempts, order.Status, nullTime(order.CancelledAt), order.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// CancelStandingOrdersByAccount cancels every active standing order paid from the account.
+func (p *Postgres) CancelStandingOrdersByAccount(accountID uuid.UUID, at time.Time) (int64, error) {
+	query := `
+	UPDATE standing_orders
+	SET status = 'CANCELLED', cancelled_at = $1, next_due_date = NULL, next_attempt_at = NULL
+	WHERE account_id = $2 AND status = 'ACTIVE'`
+
+	result, err := p.conn().Exec(query, at, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) GetStandingOrderExecutions(standingOrderID uuid.UUID, limit int, offset int) ([]domain.StandingOrderExecution, error) {
+	query := `SELECT * FROM standing_order_executions WHERE standing_order_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	rows, err := p.conn().Query(query, standingOrderID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []domain.StandingOrderExecution
+
+	for rows.Next() {
+		execution, err := scanStandingOrderExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		executions = append(executions, execution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(executions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return executions, nil
+}
+
+func (p *Postgres) GetStandingOrderExecution(standingOrderID uuid.UUID, dueDate time.Time, attempt int) (domain.StandingOrderExecution, error) {
+	query := `SELECT * FROM standing_order_executions WHERE standing_order_id = $1 AND due_date = $2 AND attempt = $3 LIMIT 1`
+
+	return scanStandingOrderExecution(p.conn().QueryRow(query, standingOrderID, nullDate(dueDate), attempt))
+}
+
+// CreateStandingOrderExecution records the start of an attempt, an attempt that was already
+// started isnt recorded again and zero rows are returned.
+func (p *Postgres) CreateStandingOrderExecution(execution domain.StandingOrderExecution) (int64, error) {
+	query := `
+	INSERT INTO standing_order_executions
+	(id, standing_order_id, due_date, attempt, transaction_id, status, error, created_at, finished_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (standing_order_id, due_date, attempt) DO NOTHING`
+
+	result, err := p.conn().Exec(query, execution.ID, execution.StandingOrderID, nullDate(execution.DueDate), execution.Attempt, execution.TransactionID, execution.Status, execution.Error, execution.CreatedAt, nullTime(execution.FinishedAt))
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (p *Postgres) FinishStandingOrderExecution(execution domain.StandingOrderExecution) (int64, error) {
+	query := `UPDATE standing_order_executions SET status = $1, error = $2, finished_at = $3 WHERE id = $4`
+
+	result, err := p.conn().Exec(query, execution.Status, execution.Error, nullTime(execution.FinishedAt), execution.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanStandingOrder(row scanner) (domain.StandingOrder, error) {
+	var order domain.StandingOrder
+	var amount, rule string
+	var currency domain.Currency
+	var endDate, nextDueDate, nextAttemptAt, cancelledAt sql.NullTime
+
+	err := row.Scan(&order.ID, &order.AccountID, &order.ReceiverAccountID, &amount, &currency, &order.Frequency, &rule, &order.StartDate, &endDate, &nextDueDate, &nextAttemptAt, &order.Attempts, &order.Status, &order.CreatedAt, &cancelledAt)
+	if err != nil {
+		return domain.StandingOrder{}, err
+	}
+
+	order.Amount, err = domain.ParseMoney(amount, currency)
+	if err != nil {
+		return domain.StandingOrder{}, fmt.Errorf("Bad amount format at standing order id: %s", order.ID.String())
+	}
+
+	order.Recurrence, err = domain.ParseRecurrence(rule)
+	if err != nil {
+		return domain.StandingOrder{}, fmt.Errorf("Bad rule at standing order id: %s", order.ID.String())
+	}
+
+	// Dates come back as midnight in UTC, same as the domain keeps them
+	order.StartDate = domain.StartOfDay(order.StartDate)
+	if endDate.Valid {
+		order.EndDate = domain.StartOfDay(endDate.Time)
+	}
+	if nextDueDate.Valid {
+		order.NextDueDate = domain.StartOfDay(nextDueDate.Time)
+	}
+	order.NextAttemptAt = nextAttemptAt.Time
+	order.CancelledAt = cancelledAt.Time
+
+	return order, nil
+}
+
+func scanStandingOrderExecution(row scanner) (domain.StandingOrderExecution, error) {
+	var execution domain.StandingOrderExecution
+	var finishedAt sql.NullTime
+
+	err := row.Scan(&execution.ID, &execution.StandingOrderID, &execution.DueDate, &execution.Attempt, &execution.TransactionID, &execution.Status, &execution.Error, &execution.CreatedAt, &finishedAt)
+	if err != nil {
+		return domain.StandingOrderExecution{}, err
+	}
+
+	execution.DueDate = domain.StartOfDay(execution.DueDate)
+	execution.FinishedAt = finishedAt.Time
+
+	return execution, nil
+}
+
+// nullDate passes the day of the time as a date, so the time zone of the session cannot move it to another day.
+func nullDate(value time.Time) sql.NullString {
+	if value.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: value.Format(time.DateOnly), Valid: true}
+}
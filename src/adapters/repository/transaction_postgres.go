@@ -5,13 +5,20 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 )
 
-func (p *Postgres) GetAllTransactions(limit, offset int) ([]domain.Transaction, error) {
-	query := `SELECT * FROM transactions ORDER BY created_at LIMIT $1 OFFSET $2`
+// GetAllTransactions returns transactions of every status when status is empty.
+// selectTransactions also collects the ids of the reversals of each transaction, failed attempts left out.
+const selectTransactions = `
+	SELECT t.*, ARRAY(SELECT r.id::text FROM transactions r WHERE r.reversal_of = t.id AND r.status <> 'FAILED' ORDER BY r.created_at)
+	FROM transactions t`
+
+func (p *Postgres) GetAllTransactions(status domain.TransactionStatus, limit, offset int) ([]domain.Transaction, error) {
+	query := selectTransactions + ` WHERE ($1 = '' OR t.status = $1) ORDER BY t.created_at LIMIT $2 OFFSET $3`
 	
-	rows ,err := p.DB.Query(query, limit, offset) 
+	rows ,err := p.conn().Query(query, status, limit, offset) 
 	if err != nil {
 		return nil, err
 	}
@@ -20,17 +27,9 @@ func (p *Postgres) GetAllTransactions(limit, offset int) ([]domain.Transaction,
 	var transactions []domain.Transaction
 
 	for rows.Next() {
-		var transaction domain.Transaction
-		var currencyPair string
-
-		if err := rows.Scan(&transaction.ID, &transaction.SenderAccountID, &transaction.ReceiverAccountID, &transaction.Amount, &currencyPair, &transaction.CreatedAt); err != nil {
-			return nil ,err		
-		}
-
-		// Set the currency pair and skip over if its corrupted (It really shouldn't be)
-		transaction.CurrencyPair, err = domain.CurrencyPairParse(currencyPair)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
-			return nil, fmt.Errorf("Bad currency pair format at transaction id: %s", transaction.ID.String())
+			return nil, err
 		}
 
 		transactions = append(transactions, transaction)
@@ -47,11 +46,12 @@ func (p *Postgres) GetAllTransactions(limit, offset int) ([]domain.Transaction,
 	return transactions, nil
 }
 
-func (p *Postgres) GetAllTransactionsFromAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Transaction, error) {
+func (p *Postgres) GetAllTransactionsFromAccount(accountID uuid.UUID, status domain.TransactionStatus, limit int, offset int) ([]domain.Transaction, error) {
 	
-	query := `SELECT * FROM transactions WHERE sender_account_id = $1 ORDER BY created_at LIMIT $2 OFFSET $3`
+	// Deposits have no sender, they are listed with the account they were made to
+	query := selectTransactions + ` WHERE (t.sender_account_id = $1 OR (t.sender_account_id IS NULL AND t.receiver_account_id = $1)) AND ($2 = '' OR t.status = $2) ORDER BY t.created_at LIMIT $3 OFFSET $4`
 	
-	rows ,err := p.DB.Query(query, accountID, limit, offset) 
+	rows ,err := p.conn().Query(query, accountID, status, limit, offset) 
 	if err != nil {
 		return nil, err
 	}
@@ -60,17 +60,9 @@ func (p *Postgres) GetAllTransactionsFromAccount(accountID uuid.UUID, limit int,
 	var transactions []domain.Transaction
 
 	for rows.Next() {
-		var transaction domain.Transaction
-		var currencyPair string
-
-		if err := rows.Scan(&transaction.ID, &transaction.SenderAccountID, &transaction.ReceiverAccountID, &transaction.Amount, &currencyPair, &transaction.CreatedAt); err != nil {
-			return nil ,err		
-		}
-
-		// Set the currency pair and skip over if its corrupted (It really shouldn't be)
-		transaction.CurrencyPair, err = domain.CurrencyPairParse(currencyPair)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
-			return nil, fmt.Errorf("Bad currency pair format at transaction id: %s", transaction.ID.String())
+			return nil, err
 		}
 
 		transactions = append(transactions, transaction)
@@ -88,35 +80,122 @@ func (p *Postgres) GetAllTransactionsFromAccount(accountID uuid.UUID, limit int,
 }
 
 func (p *Postgres) GetTransaction(transactionID uuid.UUID) (domain.Transaction, error) {
-	query := `SELECT * FROM transactions WHERE id = $1 LIMIT 1`
+	query := selectTransactions + ` WHERE t.id = $1 LIMIT 1`
 
-	var transaction domain.Transaction
-	var currencyPair string
-	
-	err := p.DB.QueryRow(query, transactionID).Scan(&transaction.ID, &transaction.SenderAccountID, &transaction.ReceiverAccountID, &transaction.Amount, &currencyPair, &transaction.CreatedAt)
+	transaction, err := scanTransaction(p.conn().QueryRow(query, transactionID))
 	if err != nil {
 		return domain.Transaction{}, err
 	}
-	
-	transaction.CurrencyPair, err = domain.CurrencyPairParse(currencyPair)
-	if err != nil {
-		return domain.Transaction{}, fmt.Errorf("Bad currency pair format at transaction id: %s", transaction.ID.String())
-	}
 
 	return transaction, nil
 }
 
+func (p *Postgres) GetTransactionForUpdate(transactionID uuid.UUID) (domain.Transaction, error) {
+	query := selectTransactions + ` WHERE t.id = $1 LIMIT 1 FOR UPDATE OF t`
+
+	return scanTransaction(p.conn().QueryRow(query, transactionID))
+}
+
 func (p *Postgres) CreateTransaction(transaction domain.Transaction) (int64, error) {
 	query := `
 	INSERT INTO transactions
-	(id, sender_account_id, receiver_account_id, amount, currency, created_at)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	(id, sender_account_id, receiver_account_id, amount, currency, created_at, exchange_rate, fee, quote_id, status, failure_reason, reversal_of, refunded, type, channel, mandate_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
-	_, err := p.DB.Exec(query, transaction.ID, transaction.SenderAccountID, transaction.ReceiverAccountID, transaction.Amount, transaction.CurrencyPair.String(), transaction.CreatedAt)
+	var exchangeRate sql.NullString
+	if transaction.ExchangeRate != nil {
+		exchangeRate = sql.NullString{String: domain.FormatRate(transaction.ExchangeRate), Valid: true}
+	}
+
+	_, err := p.conn().Exec(query, transaction.ID, nullUUID(transaction.SenderAccountID), nullUUID(transaction.ReceiverAccountID), transaction.Amount.String(), transaction.CurrencyPair.String(), transaction.CreatedAt, exchangeRate, transaction.Fee.String(), nullUUID(transaction.QuoteID), transaction.Status, nullString(transaction.FailureReason), nullUUID(transaction.ReversalOf), transaction.Refunded.String(), transaction.Type, nullString(string(transaction.Channel)), nullUUID(transaction.MandateID))
 	if err != nil {
 		return 0, err
 	}
 
 	return 1, nil
-}
\ No newline at end of file
+}
+
+func (p *Postgres) UpdateTransaction(transaction domain.Transaction) (int64, error) {
+	query := `UPDATE transactions SET status = $1, failure_reason = $2, refunded = $3 WHERE id = $4`
+
+	result, err := p.conn().Exec(query, transaction.Status, nullString(transaction.FailureReason), transaction.Refunded.String(), transaction.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+func scanTransaction(row scanner) (domain.Transaction, error) {
+	var transaction domain.Transaction
+	var senderAccountID, receiverAccountID uuid.NullUUID
+	var channel sql.NullString
+	var amount string
+	var currencyPair string
+	var exchangeRate sql.NullString
+	var fee string
+	var quoteID uuid.NullUUID
+	var failureReason sql.NullString
+	var reversalOf uuid.NullUUID
+	var refunded string
+	var mandateID uuid.NullUUID
+	var reversals pq.StringArray
+
+	err := row.Scan(&transaction.ID, &senderAccountID, &receiverAccountID, &amount, &currencyPair, &transaction.CreatedAt, &exchangeRate, &fee, &quoteID, &transaction.Status, &failureReason, &reversalOf, &refunded, &transaction.Type, &channel, &mandateID, &reversals)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	if exchangeRate.Valid {
+		transaction.ExchangeRate, err = domain.ParseRate(exchangeRate.String)
+		if err != nil {
+			return domain.Transaction{}, fmt.Errorf("Bad exchange rate format at transaction id: %s", transaction.ID.String())
+		}
+	}
+
+	// Set the currency pair and skip over if its corrupted (It really shouldn't be)
+	transaction.CurrencyPair, err = domain.CurrencyPairParse(currencyPair)
+	if err != nil {
+		return domain.Transaction{}, fmt.Errorf("Bad currency pair format at transaction id: %s", transaction.ID.String())
+	}
+
+	// The amount is stored as an exact NUMERIC in major units of the sender currency
+	transaction.Amount, err = domain.ParseMoney(amount, transaction.CurrencyPair.From)
+	if err != nil {
+		return domain.Transaction{}, fmt.Errorf("Bad amount format at transaction id: %s", transaction.ID.String())
+	}
+
+	transaction.Fee, err = domain.ParseMoney(fee, transaction.CurrencyPair.From)
+	if err != nil {
+		return domain.Transaction{}, fmt.Errorf("Bad fee format at transaction id: %s", transaction.ID.String())
+	}
+
+	transaction.SenderAccountID = senderAccountID.UUID
+	transaction.ReceiverAccountID = receiverAccountID.UUID
+	transaction.Channel = domain.TransactionChannel(channel.String)
+	transaction.QuoteID = quoteID.UUID
+	transaction.FailureReason = failureReason.String
+	transaction.ReversalOf = reversalOf.UUID
+	transaction.MandateID = mandateID.UUID
+
+	transaction.Refunded, err = domain.ParseMoney(refunded, transaction.CurrencyPair.To)
+	if err != nil {
+		return domain.Transaction{}, fmt.Errorf("Bad refunded amount format at transaction id: %s", transaction.ID.String())
+	}
+
+	for _, reversal := range reversals {
+		id, err := uuid.Parse(reversal)
+		if err != nil {
+			return domain.Transaction{}, fmt.Errorf("Bad reversal id at transaction id: %s", transaction.ID.String())
+		}
+		transaction.Reversals = append(transaction.Reversals, id)
+	}
+
+	return transaction, nil
+}
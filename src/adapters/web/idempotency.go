@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+const IDEMPOTENCY_HEADER = "Idempotency-Key"
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to retry, the first
+// successful response is stored and replayed for every repeat of the same request. It has to come
+// after the authentication, keys are scoped to who sent the request and requests nobody was
+// authenticated for aren't remembered. Routes issuing credentials must not use it, their responses
+// would be stored.
+func (s *Server) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IDEMPOTENCY_HEADER)
+		principal, authenticated := idempotencyPrincipal(r)
+		if r.Method != http.MethodPost || key == "" || !authenticated {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handlers.RespondWithError(w, http.StatusBadRequest, "Failed to read the body: "+err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		idempotencyKey, replay, err := s.IdempotencyService.Begin(principal, key, hashRequest(r, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrBadRequest):
+				handlers.RespondWithError(w, http.StatusBadRequest, err.Error())
+			case errors.Is(err, domain.ErrConflict):
+				handlers.RespondWithError(w, http.StatusConflict, err.Error())
+			case errors.Is(err, domain.ErrUnprocessable):
+				handlers.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		if replay {
+			if idempotencyKey.ResponseLocation != "" {
+				w.Header().Set("Location", idempotencyKey.ResponseLocation)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(idempotencyKey.ResponseCode)
+			w.Write(idempotencyKey.ResponseBody)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Only successes are remembered, after a failure the client can retry with the same key
+		if recorder.code < 200 || recorder.code >= 300 {
+			if err := s.IdempotencyService.Release(idempotencyKey); err != nil {
+				log.Printf("[ERROR]\tFailed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		idempotencyKey.ResponseCode = recorder.code
+		idempotencyKey.ResponseLocation = recorder.Header().Get("Location")
+		idempotencyKey.ResponseBody = recorder.body.Bytes()
+
+		if err := s.IdempotencyService.Complete(idempotencyKey); err != nil {
+			log.Printf("[ERROR]\tFailed to store idempotent response: %v", err)
+		}
+	})
+}
+
+// idempotencyPrincipal returns who the request was authenticated as, partner apps are told apart
+// from the customer who gave them the consent.
+func idempotencyPrincipal(r *http.Request) (string, bool) {
+	if consent, ok := handlers.ConsentFromContext(r.Context()); ok {
+		return "consent:" + consent.ID.String(), true
+	}
+
+	if customerID, ok := handlers.CustomerIDFromContext(r.Context()); ok {
+		return "customer:" + customerID.String(), true
+	}
+
+	// The admin token has a role without a customer
+	if role, ok := handlers.RoleFromContext(r.Context()); ok {
+		return "role:" + string(role), true
+	}
+
+	return "", false
+}
+
+// hashRequest fingerprints the request so a key reused for something else can be rejected.
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -1,13 +1,16 @@
 package web
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/google/uuid"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
-	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 )
 
 func (s *Server) LoadSharedMiddleware() {
@@ -16,47 +19,284 @@ func (s *Server) LoadSharedMiddleware() {
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "Location", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	  }))
+	s.Router.Use(s.RateLimit)
 }
 
+// TokenAuth verifies the signed access token without a database lookup and puts the customer
+// it was issued to into the request context. A {customer_id} in the route must be that customer.
 func (s *Server) TokenAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := customer.GetTokenFromHeader(r.Header.Get("Authorization"))
+		authenticated, err := s.authenticate(r)
 		if err != nil {
-			handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+			respondWithAuthError(w, err)
 			return
 		}
 
-		customerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
-		if err != nil {
-			handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		if authenticated.CustomerID == uuid.Nil {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized! Bad credentials")
 			return
 		}
 
-		authorized, err := s.CustomerService.Auth(customerID, token)
-		if err != nil {
-			handlers.RespondWithError(w, http.StatusInternalServerError, "Something went wrong: "+err.Error())
-			return
+		if param := chi.URLParam(r, "customer_id"); param != "" {
+			routeCustomerID, err := uuid.Parse(param)
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+				return
+			}
+
+			if routeCustomerID != authenticated.CustomerID {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
 		}
 
-		if (!authorized) {
-			handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized! Bad credentials")
+		next.ServeHTTP(w, authenticated.WithContext(r))
+	})
+}
+
+// Authenticate puts the customer and role of the bearer token into the request context, the
+// permission middlewares after it decide what they may see.
+func (s *Server) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated, err := s.authenticate(r)
+		if err != nil {
+			respondWithAuthError(w, err)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, authenticated.WithContext(r))
 	})
 }
 
+// AllowAPIKey opens the route to the API keys of consents granted the scope, it has to come
+// before TokenAuth or Authenticate. API keys are rejected on every other route.
+func (s *Server) AllowAPIKey(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(handlers.WithAPIKeyScope(r.Context(), scope)))
+		})
+	}
+}
+
+// RequirePermission only lets through roles granted the permission.
+func (s *Server) RequirePermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if !role.Can(permission) {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CustomerOrPermission lets customers through to their own {customer_id} and roles granted the permission to any.
+func (s *Server) CustomerOrPermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if role.Can(permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			routeCustomerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+				return
+			}
+
+			customerID, ok := handlers.CustomerIDFromContext(r.Context())
+			if !ok || customerID != routeCustomerID {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AccountOwnerOrPermission lets customers through to their own {account_id} and roles granted the permission to any.
+func (s *Server) AccountOwnerOrPermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if role.Can(permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			accountID, err := uuid.Parse(chi.URLParam(r, "account_id"))
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+				return
+			}
+
+			s.requireAccountOwner(w, r, next, accountID, permission)
+		})
+	}
+}
+
+// TransactionPartyOrPermission lets customers through to a {transaction_id} sent from or to one of
+// their accounts and roles granted the permission to any.
+func (s *Server) TransactionPartyOrPermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if role.Can(permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			customerID, ok := handlers.CustomerIDFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+				return
+			}
+
+			transactionID, err := uuid.Parse(chi.URLParam(r, "transaction_id"))
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+				return
+			}
+
+			transaction, err := s.TransactionService.Get(transactionID)
+			if err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					handlers.RespondWithError(w, http.StatusNotFound, err.Error())
+					return
+				}
+				handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			consent, hasConsent := handlers.ConsentFromContext(r.Context())
+
+			for _, accountID := range transaction.AccountIDs() {
+				if hasConsent && !consent.CoversAccount(accountID) {
+					continue
+				}
+
+				isOwner, err := s.AccountService.IsOwner(customerID, accountID)
+				if err != nil {
+					handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				if isOwner {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+		})
+	}
+}
+
+// OwnAccountsOrPermission limits account listings of customers to their own accounts by
+// filling in the customer_id parameter, roles granted the permission can list every account.
+func (s *Server) OwnAccountsOrPermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if role.Can(permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			customerID, ok := handlers.CustomerIDFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+				return
+			}
+
+			query := r.URL.Query()
+			if param := query.Get("customer_id"); param != "" && param != customerID.String() {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+				return
+			}
+
+			query.Set("customer_id", customerID.String())
+			r.URL.RawQuery = query.Encode()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OwnTransactionsOrPermission makes customers list transactions by one of their own accounts
+// through the account_id parameter, roles granted the permission can list every transaction.
+func (s *Server) OwnTransactionsOrPermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := handlers.RoleFromContext(r.Context())
+			if !ok {
+				handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
+				return
+			}
+
+			if role.Can(permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			param := r.URL.Query().Get("account_id")
+			if param == "" {
+				handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Filter the transactions by one of your accounts")
+				return
+			}
+
+			accountID, err := uuid.Parse(param)
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+				return
+			}
+
+			s.requireAccountOwner(w, r, next, accountID, permission)
+		})
+	}
+}
+
 func (s *Server) AccountOwnerAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		customerID, err := uuid.Parse(chi.URLParam(r, "customer_id"))
-		if err != nil {
-			handlers.RespondWithError(w, http.StatusBadRequest, "Failed to parse UUID: "+err.Error())
+		customerID, ok := handlers.CustomerIDFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized!")
 			return
 		}
 
@@ -66,6 +306,12 @@ func (s *Server) AccountOwnerAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Partner apps only get to the accounts the customer consented to
+		if consent, ok := handlers.ConsentFromContext(r.Context()); ok && !consent.CoversAccount(accountID) {
+			handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! The consent doesnt cover the account")
+			return
+		}
+
 		isOwner, err := s.AccountService.IsOwner(customerID, accountID)
 		if err != nil {
 			handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
@@ -79,4 +325,107 @@ func (s *Server) AccountOwnerAuth(next http.Handler) http.Handler {
 
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// principal is who a request was authenticated as.
+type principal struct {
+	CustomerID uuid.UUID // Nil for the admin token
+	Role       domain.Role
+	Consent    *domain.Consent // Set when a partner app authenticated with an API key
+}
+
+func (p principal) WithContext(r *http.Request) *http.Request {
+	ctx := handlers.WithRole(r.Context(), p.Role)
+	if p.CustomerID != uuid.Nil {
+		ctx = handlers.WithCustomerID(ctx, p.CustomerID)
+	}
+	if p.Consent != nil {
+		ctx = handlers.WithConsent(ctx, *p.Consent)
+	}
+
+	return r.WithContext(ctx)
+}
+
+// authenticate returns who the bearer token belongs to. The admin token stands for the admin
+// role without a customer, so the first admin can be appointed before anyone holds the role.
+// API keys act as their customer without any staff role, and only on routes opened to their scope.
+func (s *Server) authenticate(r *http.Request) (principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return principal{}, domain.UnauthorizedError(errors.New("Bad credentials"))
+	}
+
+	if s.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1 {
+		return principal{Role: domain.ROLE_ADMIN}, nil
+	}
+
+	if strings.HasPrefix(token, domain.API_KEY_PREFIX) {
+		scope, ok := handlers.APIKeyScopeFromContext(r.Context())
+		if !ok {
+			return principal{}, domain.ForbiddenError(errors.New("API keys cannot be used here"))
+		}
+
+		consent, err := s.ConsentService.Authenticate(token)
+		if err != nil {
+			return principal{}, err
+		}
+
+		if !consent.HasScope(scope) {
+			return principal{}, domain.ForbiddenError(errors.New("API key is missing the scope " + string(scope)))
+		}
+
+		return principal{CustomerID: consent.CustomerID, Role: domain.ROLE_CUSTOMER, Consent: &consent}, nil
+	}
+
+	claims, err := s.AuthService.Verify(token)
+	if err != nil {
+		return principal{}, err
+	}
+
+	customerID, err := claims.CustomerID()
+	if err != nil {
+		return principal{}, domain.UnauthorizedError(err)
+	}
+
+	return principal{CustomerID: customerID, Role: claims.CustomerRole()}, nil
+}
+
+func respondWithAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		handlers.RespondWithError(w, http.StatusUnauthorized, "Not authorized! Bad credentials")
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		handlers.RespondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
+}
+
+// requireAccountOwner serves the request only when the authenticated customer owns the account.
+func (s *Server) requireAccountOwner(w http.ResponseWriter, r *http.Request, next http.Handler, accountID uuid.UUID, permission domain.Permission) {
+	customerID, ok := handlers.CustomerIDFromContext(r.Context())
+	if !ok {
+		handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+		return
+	}
+
+	if consent, ok := handlers.ConsentFromContext(r.Context()); ok && !consent.CoversAccount(accountID) {
+		handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! The consent doesnt cover the account")
+		return
+	}
+
+	isOwner, err := s.AccountService.IsOwner(customerID, accountID)
+	if err != nil {
+		handlers.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !isOwner {
+		handlers.RespondWithError(w, http.StatusForbidden, "Forbidden! Missing permission "+string(permission))
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+// RateLimit spends a token of the client IP and, for requests with a valid access token, of the
+// customer on every request. Reads and writes are limited separately and the RateLimit-* headers
+// tell the client what is left. When the store fails the request is let through.
+func (s *Server) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.RateLimitService == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		keys := []string{"ip:" + clientIP(r)}
+		if customerID := s.rateLimitedCustomer(r); customerID != "" {
+			keys = append(keys, "customer:"+customerID)
+		}
+
+		result, err := s.RateLimitService.Allow(keys, isWrite(r.Method))
+		if err != nil {
+			log.Println("[WARNING]\tRate limiter failed, letting the request through: " + err.Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if result.Limit == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", formatSeconds(result.Reset))
+
+		if !result.Allowed {
+			retryAfter := formatSeconds(result.RetryAfter)
+			w.Header().Set("Retry-After", retryAfter)
+			handlers.RespondWithError(w, http.StatusTooManyRequests, "Too many requests! Retry in "+retryAfter+" seconds")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitedCustomer returns the customer of a valid access token without touching the database.
+// API keys and the admin token are only limited by the client IP.
+func (s *Server) rateLimitedCustomer(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || s.AuthService == nil || strings.HasPrefix(token, domain.API_KEY_PREFIX) {
+		return ""
+	}
+
+	claims, err := s.AuthService.Verify(token)
+	if err != nil {
+		return ""
+	}
+
+	customerID, err := claims.CustomerID()
+	if err != nil {
+		return ""
+	}
+
+	return customerID.String()
+}
+
+// clientIP is the address the request came from, headers like X-Forwarded-For are ignored as
+// anyone can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func isWrite(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+func formatSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
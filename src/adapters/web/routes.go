@@ -3,41 +3,137 @@ package web
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 )
 
 func (s *Server) LoadRoutes() {
 	accountHandler := handlers.NewAccountHandler(s.AccountService)
 	customerHandler := handlers.NewCustomerHandler(s.CustomerService)
 	transactionsHandler := handlers.NewTransactionHandler(s.TransactionService)
+	ledgerHandler := handlers.NewLedgerHandler(s.LedgerService)
+	currencyHandler := handlers.NewCurrencyHandler(s.CurrencyService)
+	fxHandler := handlers.NewFXHandler(s.FXService)
+	authHandler := handlers.NewAuthHandler(s.AuthService)
+	credentialHandler := handlers.NewCredentialHandler(s.CredentialService)
+	consentHandler := handlers.NewConsentHandler(s.ConsentService)
+	jobHandler := handlers.NewJobHandler(s.JobService)
+	standingOrderHandler := handlers.NewStandingOrderHandler(s.StandingOrderService)
+	mandateHandler := handlers.NewMandateHandler(s.MandateService)
 
 	s.Router.Route("/api", func(r chi.Router) {
+		// POSTs after the authentication honour the Idempotency-Key header, except for the routes
+		// issuing tokens, secrets and API keys which must never be stored
+
+		// Authentication api endpoints
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/login", authHandler.Login)
+			r.Post("/password", authHandler.PasswordLogin)
+			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/logout", authHandler.Logout)
+			r.Get("/jwks", authHandler.Keys)
+		})
+
 		r.Route("/customer", func(r chi.Router) {
-			r.Get("/", customerHandler.Index) // Params: limit, offset
-			r.Get("/{customer_id}", customerHandler.Get)
+			r.With(s.Authenticate, s.RequirePermission(domain.PERMISSION_READ_CUSTOMERS)).Get("/", customerHandler.Index) // Params: limit, offset
+			r.With(s.Authenticate, s.CustomerOrPermission(domain.PERMISSION_READ_CUSTOMERS)).Get("/{customer_id}", customerHandler.Get)
 			r.Post("/", customerHandler.Create)
 			r.With(s.TokenAuth).Put("/{customer_id}", customerHandler.Update)
 			r.With(s.TokenAuth).Delete("/{customer_id}", customerHandler.Delete)
+
+			// Endpoints for managing the tokens of a customer
+			r.With(s.TokenAuth).Route("/{customer_id}/token", func(r chi.Router) {
+				r.Get("/", customerHandler.IndexTokens)
+				r.Post("/", customerHandler.CreateToken)
+				r.Delete("/{token_id}", customerHandler.RevokeToken)
+			})
 	
+			// Endpoints for managing the password and two-factor authentication of a customer
+			r.With(s.TokenAuth).Put("/{customer_id}/password", credentialHandler.SetPassword)
+			r.With(s.TokenAuth).Route("/{customer_id}/2fa", func(r chi.Router) {
+				r.Post("/", credentialHandler.EnrollTOTP)
+				r.Post("/confirm", credentialHandler.ConfirmTOTP)
+				r.Delete("/", credentialHandler.DisableTOTP)
+			})
+
+			// Endpoints for managing the consents a customer gave partner apps
+			r.With(s.TokenAuth).Route("/{customer_id}/consent", func(r chi.Router) {
+				r.Get("/", consentHandler.Index)
+				r.Post("/", consentHandler.Create)
+				r.Delete("/{consent_id}", consentHandler.Revoke)
+			})
+
 			// Endpoints for manipulating account by a customer and creating a transaction
-			r.With(s.TokenAuth).Route("/{customer_id}/account", func(r chi.Router) {
-				r.Post("/", accountHandler.Create)
-				r.With(s.AccountOwnerAuth).Put("/{account_id}", accountHandler.Update)
-				r.With(s.AccountOwnerAuth).Delete("/{account_id}", accountHandler.Delete)
+			r.Route("/{customer_id}/account", func(r chi.Router) {
+				r.With(s.TokenAuth, s.Idempotency).Post("/", accountHandler.Create)
+				r.With(s.TokenAuth, s.AccountOwnerAuth).Put("/{account_id}", accountHandler.Update)
+				r.With(s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Post("/{account_id}/reactivate", accountHandler.Reactivate)
+				r.With(s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Post("/{account_id}/close", accountHandler.Close)
+				r.With(s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Post("/{account_id}/withdrawal", transactionsHandler.Withdraw)
 				
-				r.With(s.AccountOwnerAuth).Post("/{account_id}/transaction", transactionsHandler.Create)
+				r.With(s.AllowAPIKey(domain.SCOPE_INITIATE_PAYMENT), s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Post("/{account_id}/transaction", transactionsHandler.Create)
+
+				// Endpoints for managing the standing orders of an account
+				r.With(s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Route("/{account_id}/standing-orders", func(r chi.Router) {
+					r.Get("/", standingOrderHandler.Index) // Params: limit, offset
+					r.Post("/", standingOrderHandler.Create)
+					r.Get("/{standing_order_id}", standingOrderHandler.Get)
+					r.Delete("/{standing_order_id}", standingOrderHandler.Cancel)
+					r.Get("/{standing_order_id}/executions", standingOrderHandler.Executions) // Params: limit, offset
+				})
+
+				// Endpoints for the direct debit mandates an account pays or collects
+				r.With(s.TokenAuth, s.AccountOwnerAuth, s.Idempotency).Route("/{account_id}/mandates", func(r chi.Router) {
+					r.Get("/", mandateHandler.Index) // Params: limit, offset
+					r.Post("/", mandateHandler.Create)
+					r.Get("/{mandate_id}", mandateHandler.Get)
+					r.Delete("/{mandate_id}", mandateHandler.Cancel)
+					r.Get("/{mandate_id}/collections", mandateHandler.Collections) // Params: limit, offset
+					r.Post("/{mandate_id}/collections", mandateHandler.Collect)
+					r.Post("/{mandate_id}/collections/{transaction_id}/refund", mandateHandler.Refund)
+				})
 			})
 		})
 
 		// Account api endpoints
+		// Customers only see their own accounts, staff every account and partner apps the accounts of their consent
 		r.Route("/account", func(r chi.Router) {
-			r.Get("/", accountHandler.Index) // Params: limit, offset, customer_id
-			r.Get("/{account_id}", accountHandler.Get)
+			r.With(s.Authenticate, s.OwnAccountsOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/", accountHandler.Index) // Params: limit, offset, customer_id
+			r.With(s.AllowAPIKey(domain.SCOPE_READ_BALANCE), s.Authenticate, s.AccountOwnerOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/{account_id}", accountHandler.Get)
+			r.With(s.AllowAPIKey(domain.SCOPE_READ_TRANSACTIONS), s.Authenticate, s.AccountOwnerOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/{account_id}/ledger", ledgerHandler.Index) // Params: limit, offset
+			r.With(s.Authenticate, s.AccountOwnerOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/{account_id}/ledger/verify", ledgerHandler.Verify)
+			r.With(s.Authenticate, s.AccountOwnerOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/{account_id}/adjustment", accountHandler.Adjustments) // Params: limit, offset
 		})
 
 		// Transactions api endpoints
+		// Customers only see the transactions of their own accounts, staff every transaction and partner apps the transactions of the accounts of their consent
 		r.Route("/transaction", func(r chi.Router) {
-			r.Get("/", transactionsHandler.Index) // Params: limit, offset, account_id
-			r.Get("/{transaction_id}", transactionsHandler.Get)
+			r.With(s.AllowAPIKey(domain.SCOPE_READ_TRANSACTIONS), s.Authenticate, s.OwnTransactionsOrPermission(domain.PERMISSION_READ_TRANSACTIONS)).Get("/", transactionsHandler.Index) // Params: limit, offset, account_id, status
+			r.With(s.AllowAPIKey(domain.SCOPE_READ_TRANSACTIONS), s.Authenticate, s.TransactionPartyOrPermission(domain.PERMISSION_READ_TRANSACTIONS)).Get("/{transaction_id}", transactionsHandler.Get)
+			r.With(s.Authenticate, s.RequirePermission(domain.PERMISSION_REVERSE_TRANSACTIONS), s.Idempotency).Post("/{transaction_id}/reversal", transactionsHandler.Reverse)
+		})
+
+		// Currencies api endpoints
+		r.Route("/currency", func(r chi.Router) {
+			r.Get("/", currencyHandler.Index) // Params: enabled
+		})
+
+		// Foreign exchange api endpoints
+		r.Route("/fx", func(r chi.Router) {
+			r.With(s.TokenAuth).Post("/quote", fxHandler.Quote)
+		})
+
+		// Endpoints for the staff of the bank
+		r.With(s.Authenticate).Route("/admin", func(r chi.Router) {
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_CURRENCIES)).Put("/currency/{code}", currencyHandler.Update)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_ROLES)).Put("/customer/{customer_id}/role", customerHandler.SetRole)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_ACCOUNTS), s.Idempotency).Post("/account/{account_id}/freeze", accountHandler.Freeze)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_ACCOUNTS), s.Idempotency).Post("/account/{account_id}/unfreeze", accountHandler.Unfreeze)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_DEPOSITS), s.Idempotency).Post("/account/{account_id}/deposit", transactionsHandler.Deposit)
+			r.With(s.RequirePermission(domain.PERMISSION_ADJUST_BALANCES), s.Idempotency).Post("/account/{account_id}/adjustment", accountHandler.Adjust)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_OVERDRAFTS)).Put("/account/{account_id}/overdraft", accountHandler.SetOverdraft)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_JOBS)).Get("/job", jobHandler.Index)
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_JOBS)).Get("/job/{job}/run", jobHandler.Runs) // Params: limit, offset
+			r.With(s.RequirePermission(domain.PERMISSION_MANAGE_JOBS), s.Idempotency).Post("/job/{job}/run", jobHandler.Trigger)
 		})
 	})
 }
@@ -14,6 +14,20 @@ type Server struct {
 	AccountService ports.IAccountService
 	CustomerService ports.ICustomerService
 	TransactionService ports.ITransactionService
+	LedgerService ports.ILedgerService
+	ExchangeService ports.IExchangeService
+	FXService ports.IFXService
+	IdempotencyService ports.IIdempotencyService
+	CurrencyService ports.ICurrencyService
+	AuthService ports.IAuthService
+	CredentialService ports.ICredentialService
+	ConsentService ports.IConsentService
+	InterestService ports.IInterestService
+	JobService ports.IJobService
+	StandingOrderService ports.IStandingOrderService
+	MandateService ports.IMandateService
+	RateLimitService ports.IRateLimitService // Requests are not limited when nil
+	AdminToken string // Bearer token acting with the admin role, disabled when empty
 }
 
 func NewServer(addr string, router *chi.Mux) *Server {
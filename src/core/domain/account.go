@@ -1,40 +1,81 @@
 package domain
 
 import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID                  uuid.UUID
-	CustomerID          uuid.UUID
-	Balance             float64
-	Type                AccountType
-	Currency            Currency
-	Status              bool
-	OpeningDate         time.Time
-	LastTransactionDate time.Time
-	InterestRate        float64
-	CreatedAt           time.Time
+	ID                     uuid.UUID
+	CustomerID             uuid.UUID
+	Balance                Money
+	Type                   AccountType
+	Currency               Currency
+	Status                 AccountStatus
+	Nickname               string // Chosen by the customer, empty when not set
+	OpeningDate            time.Time
+	LastTransactionDate    time.Time
+	InterestRate           float64
+	InterestTiers          []InterestTier // Higher rates for the parts of the balance above their thresholds
+	DayCount               DayCount
+	AccruedInterest        *big.Rat  // Interest earned or charged since the last posting, in major units, negative for overdraft interest
+	InterestAccruedThrough time.Time // The last day interest was accrued for
+	OverdraftLimit         Money     // How far below zero the balance may go, zero without an arranged overdraft
+	OverdraftInterestRate  float64   // Yearly rate charged on negative balances
+	CreatedAt              time.Time
 }
 
+// CreateAccountRequest opens an empty account, money only gets on it through deposits and transfers.
 type CreateAccountRequest struct {
-	Balance float64
 	Type AccountType
 	Currency Currency
 	InterestRate float64
+	InterestTiers []InterestTierRequest // Optional, savings accounts only
+	DayCount string // Optional, ACT/365 by default
 }
 
+// UpdateAccountRequest holds the settings customers can change themselves. Balances only change
+// through transfers and adjustments of the staff of the bank.
 type UpdateAccountRequest struct {
-	Balance float64
-	Type 	AccountType
-	Currency Currency
-	Status	bool
-	LastTransactionDate time.Time
+	Nickname *string      // Optional, an empty string removes the nickname
+	Status   AccountStatus // Optional, FROZEN blocks the account and ACTIVE reactivates a dormant one
+}
+
+// SetOverdraftRequest arranges an overdraft for the account, a zero limit cancels it.
+type SetOverdraftRequest struct {
+	Limit        json.Number // In the account currency
 	InterestRate float64
 }
 
+type CloseAccountRequest struct {
+	SweepAccountID uuid.UUID // Receives the remaining balance, only needed when the balance isnt zero
+}
+
+const MAX_NICKNAME_LENGTH = 64
+
+const DORMANCY_PERIOD = 2 * 365 * 24 * time.Hour // Active accounts without a transfer for this long become dormant
+
+type AccountStatus string
+
+const (
+	AccountActive  AccountStatus = "ACTIVE"
+	AccountFrozen  AccountStatus = "FROZEN"  // Blocked by the staff of the bank, only they can unfreeze it
+	AccountDormant AccountStatus = "DORMANT" // Unused for a long time, the owner has to reactivate it
+	AccountClosed  AccountStatus = "CLOSED"
+)
+
+// accountTransitions lists the statuses an account can move to from each status.
+var accountTransitions = map[AccountStatus][]AccountStatus{
+	AccountActive:  {AccountFrozen, AccountDormant, AccountClosed},
+	AccountFrozen:  {AccountActive},
+	AccountDormant: {AccountActive, AccountFrozen, AccountClosed},
+}
+
 type AccountType int
 
 var AccountLookupMap = map[AccountType]string{
@@ -43,6 +84,35 @@ var AccountLookupMap = map[AccountType]string{
 	3: "Savings",
 }
 
+func (s AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	return slices.Contains(accountTransitions[s], next)
+}
+
+// TransitionTo moves the account to the next status, rejecting transitions the lifecycle doesnt allow.
+func (a *Account) TransitionTo(next AccountStatus) error {
+	if !a.Status.CanTransitionTo(next) {
+		return errors.New("Account cannot go from " + string(a.Status) + " to " + string(next))
+	}
+
+	a.Status = next
+	return nil
+}
+
+// Available returns how much can still be sent from the account, the overdraft included.
+func (a Account) Available() Money {
+	return a.Balance.Add(a.OverdraftLimit)
+}
+
+// CanCover reports whether the amount can be sent from the account without going past its overdraft limit.
+func (a Account) CanCover(amount Money) bool {
+	return !a.Available().Sub(amount).IsNegative()
+}
+
+// IsActive reports whether money can move from and to the account.
+func (a Account) IsActive() bool {
+	return a.Status == AccountActive
+}
+
 /* ------------------------------------------------------------ */
 func (a Account) Validate() *ValidationErrors {
     var errors []string
@@ -55,17 +125,41 @@ func (a Account) Validate() *ValidationErrors {
 		errors = append(errors, "CustomerID cannot be nil")
 	}
 	
-    if a.Balance < 0 {
+    if a.OverdraftLimit.IsNegative() {
+        errors = append(errors, "OverdraftLimit cannot be negative")
+    } else if a.OverdraftLimit.IsZero() && a.Balance.IsNegative() {
         errors = append(errors, "Balance cannot be negative")
+    } else if a.Available().IsNegative() {
+        errors = append(errors, "Balance cannot be below the overdraft limit")
+    }
+
+    if !a.OverdraftLimit.IsZero() && a.OverdraftLimit.Currency != a.Currency {
+        errors = append(errors, "OverdraftLimit currency must match the account currency")
+    }
+
+    if a.OverdraftInterestRate < 0 {
+        errors = append(errors, "OverdraftInterestRate cannot be negative")
+    }
+
+    if a.Type == 3 && !a.OverdraftLimit.IsZero() {
+        errors = append(errors, "Savings account cannot have an overdraft")
+    }
+
+    if a.Balance.Currency != a.Currency {
+        errors = append(errors, "Balance currency must match the account currency")
     }
 
     if _, ok := AccountLookupMap[a.Type]; !ok {
         errors = append(errors, "Invalid account type")
     }
 
-    if _, ok := CurrencyLookupMap[a.Currency]; !ok {
-		errors = append(errors, "This currency is not supported!")
-	}
+    if len(a.Nickname) > MAX_NICKNAME_LENGTH {
+        errors = append(errors, "Nickname is too long")
+    }
+
+    if _, ok := accountTransitions[a.Status]; !ok && a.Status != AccountClosed {
+        errors = append(errors, "Invalid account status")
+    }
 
     if a.InterestRate < 0 {
         errors = append(errors, "InterestRate cannot be negative")
@@ -73,6 +167,12 @@ func (a Account) Validate() *ValidationErrors {
 		errors = append(errors, "Non-savings account cannot have interest rate")
 	}
 
+    errors = append(errors, validateInterestTiers(a)...)
+
+    if !slices.Contains(DayCounts, a.DayCount) {
+        errors = append(errors, "Unknown day count convention "+string(a.DayCount))
+    }
+
     if len(errors) > 0 {
         return &ValidationErrors{Errors: errors}
     }
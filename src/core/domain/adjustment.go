@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MAX_ADJUSTMENT_NOTE_LENGTH = 500
+
+// AdjustmentReason tells why the staff of the bank changed the balance of an account by hand.
+type AdjustmentReason string
+
+const (
+	AdjustmentCorrection AdjustmentReason = "CORRECTION" // Fixes a booking error of the bank
+	AdjustmentGoodwill   AdjustmentReason = "GOODWILL"   // Compensates the customer
+	AdjustmentFeeRefund  AdjustmentReason = "FEE_REFUND"
+	AdjustmentChargeback AdjustmentReason = "CHARGEBACK"
+	AdjustmentWriteOff   AdjustmentReason = "WRITE_OFF"
+)
+
+var AdjustmentReasons = []AdjustmentReason{AdjustmentCorrection, AdjustmentGoodwill, AdjustmentFeeRefund, AdjustmentChargeback, AdjustmentWriteOff}
+
+// Adjustment is a change of a balance made by the staff of the bank outside of any transfer. Its
+// ledger journal has the same ID, so the entries can be traced back to who made it and why.
+type Adjustment struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+	Amount    Money // Credited to the account, negative amounts are debited
+	Reason    AdjustmentReason
+	Note      string
+	CreatedBy uuid.UUID // uuid.Nil when made with the admin token
+	CreatedAt time.Time
+}
+
+type AdjustmentDTO struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+	Amount    json.Number
+	Currency  string
+	Reason    string
+	Note      string
+	CreatedBy *uuid.UUID `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+type CreateAdjustmentRequest struct {
+	Amount json.Number // Negative to debit the account
+	Reason AdjustmentReason
+	Note   string
+}
+
+/* ------------------------------------------------------------ */
+func (a Adjustment) Validate() *ValidationErrors {
+	var errors []string
+
+	if a.ID == uuid.Nil || a.AccountID == uuid.Nil {
+		errors = append(errors, "Adjustment and account ID cannot be nil")
+	}
+
+	if a.Amount.IsZero() {
+		errors = append(errors, "Amount cannot be zero")
+	}
+
+	if !slices.Contains(AdjustmentReasons, a.Reason) {
+		errors = append(errors, "Unknown adjustment reason "+string(a.Reason))
+	}
+
+	if a.Note == "" {
+		errors = append(errors, "Note is required")
+	} else if len(a.Note) > MAX_ADJUSTMENT_NOTE_LENGTH {
+		errors = append(errors, "Note is too long")
+	}
+
+	if a.CreatedAt.IsZero() {
+		errors = append(errors, "CreatedAt must be set")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ACCESS_TOKEN_TTL  = 15 * time.Minute
+	REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+)
+
+// Claims are the registered JWT claims of an access token, the subject is the customer ID.
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+	Role      Role   `json:"role,omitempty"` // Tokens without a role belong to a plain customer
+}
+
+// RefreshToken lets a customer get a new access token without logging in again. Every
+// refresh replaces the token with a new one of the same family, a token that is used
+// twice was stolen and revokes the whole family.
+type RefreshToken struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	FamilyID   uuid.UUID // The refresh token the family started with at login
+	Salt       []byte
+	Hash       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	UsedAt     time.Time // Zero until the token is exchanged for a new one
+	RevokedAt  time.Time // Zero while the token isn't revoked
+}
+
+// TokenPair is what a customer gets when logging in or refreshing.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	IssuedAt         time.Time
+}
+
+type TokenPairDTO struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds until the access token expires
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LoginRequest struct {
+	CustomerID uuid.UUID
+	Token      string // One of the API tokens of the customer
+	Code       string // TOTP or recovery code, required when two-factor authentication is enabled
+}
+
+type RefreshRequest struct {
+	RefreshToken string
+}
+
+// JWK is a public key in the JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv,omitempty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (c Claims) CustomerID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+func (c Claims) CustomerRole() Role {
+	if c.Role == "" {
+		return ROLE_CUSTOMER
+	}
+
+	return c.Role
+}
+
+func (t RefreshToken) IsUsed() bool {
+	return !t.UsedAt.IsZero()
+}
+
+func (t RefreshToken) IsRevoked() bool {
+	return !t.RevokedAt.IsZero()
+}
+
+func (t RefreshToken) IsExpired(at time.Time) bool {
+	return !at.Before(t.ExpiresAt)
+}
+
+/* ------------------------------------------------------------ */
+// Validate checks the claims of an access token at the given time.
+func (c Claims) Validate(issuer string, at time.Time) error {
+	if c.Issuer != issuer {
+		return errors.New("token was issued by someone else")
+	}
+
+	if _, err := c.CustomerID(); err != nil {
+		return errors.New("token subject is not a customer")
+	}
+
+	if !c.CustomerRole().IsValid() {
+		return errors.New("token role is not valid")
+	}
+
+	if c.ExpiresAt == 0 || at.Unix() >= c.ExpiresAt {
+		return errors.New("token expired")
+	}
+
+	if c.IssuedAt > at.Unix() {
+		return errors.New("token is not valid yet")
+	}
+
+	return nil
+}
+
+func (t RefreshToken) Validate() *ValidationErrors {
+	var errors []string
+
+	if t.ID == uuid.Nil || t.CustomerID == uuid.Nil || t.FamilyID == uuid.Nil {
+		errors = append(errors, "Token, customer and family ID cannot be nil")
+	}
+
+	if len(t.Salt) == 0 || len(t.Hash) == 0 {
+		errors = append(errors, "Token must be hashed")
+	}
+
+	if !t.ExpiresAt.After(t.CreatedAt) {
+		errors = append(errors, "ExpiresAt must be in the future")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
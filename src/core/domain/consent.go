@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MAX_CONSENT_TTL        = 90 * 24 * time.Hour // Customers renew the consent of a partner app at least every 90 days
+	MAX_CLIENT_NAME_LENGTH = 255
+	API_KEY_PREFIX         = "ak_" // Tells API keys apart from access tokens in the Authorization header
+)
+
+// Scope is an operation a partner app may do with the accounts of a consent.
+type Scope string
+
+const (
+	SCOPE_READ_BALANCE      Scope = "balance:read"
+	SCOPE_READ_TRANSACTIONS Scope = "transactions:read"
+	SCOPE_INITIATE_PAYMENT  Scope = "payments:create"
+)
+
+var Scopes = []Scope{SCOPE_READ_BALANCE, SCOPE_READ_TRANSACTIONS, SCOPE_INITIATE_PAYMENT}
+
+// Consent is the permission a customer gave a partner app to use some of their accounts. The app
+// authenticates with the API key of the consent, only a salted hash of it is kept.
+type Consent struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	ClientName string
+	AccountIDs []uuid.UUID
+	Scopes     []Scope
+	Salt       []byte
+	Hash       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time // Zero until the API key is used
+	RevokedAt  time.Time // Zero while the consent isn't revoked
+}
+
+type ConsentDTO struct {
+	ID         uuid.UUID
+	ClientName string
+	AccountIDs []uuid.UUID
+	Scopes     []Scope
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time `json:",omitempty"`
+	RevokedAt  *time.Time `json:",omitempty"`
+}
+
+type CreateConsentRequest struct {
+	ClientName string
+	AccountIDs []uuid.UUID
+	Scopes     []Scope
+	ExpiresAt  time.Time // Optional, the consent lasts the longest allowed time without it
+}
+
+func (c Consent) IsRevoked() bool {
+	return !c.RevokedAt.IsZero()
+}
+
+func (c Consent) IsExpired(at time.Time) bool {
+	return !at.Before(c.ExpiresAt)
+}
+
+func (c Consent) IsActive(at time.Time) bool {
+	return !c.IsRevoked() && !c.IsExpired(at)
+}
+
+func (c Consent) HasScope(scope Scope) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+func (c Consent) CoversAccount(accountID uuid.UUID) bool {
+	return slices.Contains(c.AccountIDs, accountID)
+}
+
+/* ------------------------------------------------------------ */
+func (c Consent) Validate() *ValidationErrors {
+	var errors []string
+
+	if c.ID == uuid.Nil || c.CustomerID == uuid.Nil {
+		errors = append(errors, "Consent and customer ID cannot be nil")
+	}
+
+	if c.ClientName == "" {
+		errors = append(errors, "ClientName is required")
+	} else if len(c.ClientName) > MAX_CLIENT_NAME_LENGTH {
+		errors = append(errors, "ClientName is too long")
+	}
+
+	if len(c.AccountIDs) == 0 {
+		errors = append(errors, "At least one account is required")
+	}
+
+	if len(c.Scopes) == 0 {
+		errors = append(errors, "At least one scope is required")
+	}
+	for _, scope := range c.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			errors = append(errors, "Unknown scope "+string(scope))
+		}
+	}
+
+	if len(c.Salt) == 0 || len(c.Hash) == 0 {
+		errors = append(errors, "API key must be hashed")
+	}
+
+	if !c.ExpiresAt.After(c.CreatedAt) {
+		errors = append(errors, "ExpiresAt must be in the future")
+	} else if c.ExpiresAt.Sub(c.CreatedAt) > MAX_CONSENT_TTL {
+		errors = append(errors, "ExpiresAt must be at most 90 days away")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MIN_PASSWORD_LENGTH = 12
+	MAX_PASSWORD_LENGTH = 128
+	RECOVERY_CODE_COUNT = 10
+
+	// Invalid two-factor codes allowed within SECOND_FACTOR_LOCKOUT, after that no code is accepted
+	// until the oldest of them is older than the lockout
+	MAX_SECOND_FACTOR_ATTEMPTS = 5
+	SECOND_FACTOR_LOCKOUT      = 15 * time.Minute
+)
+
+// Credentials are the password and the second factor a customer can log in with.
+type Credentials struct {
+	CustomerID   uuid.UUID
+	PasswordHash string // Encoded argon2id hash, empty while no password is set
+	TOTPSecret   []byte // Set once two-factor enrolment starts
+	TOTPEnabled  bool   // Set once the enrolment is confirmed with a code
+	TOTPLastStep int64  // Time step of the last accepted code, so a code cannot be used twice
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode replaces a TOTP code once, when the customer lost their authenticator.
+type RecoveryCode struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Salt       []byte
+	Hash       []byte
+	CreatedAt  time.Time
+	UsedAt     time.Time // Zero while the code is unused
+}
+
+// TOTPEnrollment is shown once when the customer starts the two-factor enrolment.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"` // Base32 secret for authenticators that cannot scan the URI
+	URI    string `json:"uri"`    // otpauth:// URI, usually shown as a QR code
+}
+
+type SetPasswordRequest struct {
+	CurrentPassword string // Required when changing an existing password
+	NewPassword     string
+}
+
+type PasswordLoginRequest struct {
+	CustomerID uuid.UUID
+	Password   string
+	Code       string // TOTP or recovery code, required when two-factor authentication is enabled
+}
+
+type TwoFactorCodeRequest struct {
+	Code string // TOTP or recovery code
+}
+
+func (c Credentials) HasPassword() bool {
+	return c.PasswordHash != ""
+}
+
+func (c Credentials) HasTwoFactor() bool {
+	return c.TOTPEnabled
+}
+
+func (c RecoveryCode) IsUsed() bool {
+	return !c.UsedAt.IsZero()
+}
+
+/* ------------------------------------------------------------ */
+func ValidatePassword(password string) *ValidationErrors {
+	var errors []string
+
+	length := utf8.RuneCountInString(password)
+
+	if length < MIN_PASSWORD_LENGTH {
+		errors = append(errors, "Password must be at least 12 characters long")
+	}
+
+	if length > MAX_PASSWORD_LENGTH {
+		errors = append(errors, "Password must be at most 128 characters long")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
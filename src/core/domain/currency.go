@@ -2,7 +2,9 @@ package domain
 
 import (
 	"errors"
+	"slices"
 	"strings"
+	"sync"
 )
 
 type Currency string
@@ -12,14 +14,64 @@ type CurrencyPair struct {
 	To   Currency
 }
 
-var CurrencyLookupMap = map[Currency]string{
-	"USD": "USD",
-	"EUR": "EUR",
+type CurrencyInfo struct {
+	Code        Currency
+	NumericCode string
+	MinorUnits  int // Number of decimal places of the minor unit
+	Name        string
+	Enabled     bool // Whether the bank offers accounts and transfers in this currency
 }
 
-var ConversionRateMap = map[CurrencyPair]float64{
-	{"USD", "EUR"}: 0.9369,
-	{"EUR", "USD"}: 1.0674,
+// Currencies is the registry of every ISO 4217 currency. Which of them the bank offers is kept in
+// the database and shared by every instance of the server, see the CurrencyService.
+var Currencies = NewCurrencyRegistry(ISO4217)
+
+// CurrencyRegistry is safe for concurrent use.
+type CurrencyRegistry struct {
+	mu         sync.RWMutex
+	currencies map[Currency]CurrencyInfo
+}
+
+func NewCurrencyRegistry(currencies []CurrencyInfo) *CurrencyRegistry {
+	registry := &CurrencyRegistry{
+		currencies: make(map[Currency]CurrencyInfo, len(currencies)),
+	}
+
+	for _, currency := range currencies {
+		registry.currencies[currency.Code] = currency
+	}
+
+	return registry
+}
+
+func (r *CurrencyRegistry) Lookup(code Currency) (CurrencyInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currency, ok := r.currencies[code]
+	return currency, ok
+}
+
+func (r *CurrencyRegistry) IsKnown(code Currency) bool {
+	_, ok := r.Lookup(code)
+	return ok
+}
+
+// All returns the currencies sorted by their code.
+func (r *CurrencyRegistry) All() []CurrencyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currencies := make([]CurrencyInfo, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		currencies = append(currencies, currency)
+	}
+
+	slices.SortFunc(currencies, func(a, b CurrencyInfo) int {
+		return strings.Compare(string(a.Code), string(b.Code))
+	})
+
+	return currencies
 }
 
 func NewCurrencyPair(from Currency , to Currency) CurrencyPair {
@@ -29,15 +81,15 @@ func NewCurrencyPair(from Currency , to Currency) CurrencyPair {
 	}
 }
 
-func (c CurrencyPair) Calculate(amount float64) float64 {
-	if c.From == c.To {
-		return amount
+func (c Currency) Exponent() int {
+	if currency, ok := Currencies.Lookup(c); ok {
+		return currency.MinorUnits
 	}
-	return amount * ConversionRateMap[c]
+	return 2
 }
 
 func (c CurrencyPair) String() string {
-	return CurrencyLookupMap[c.From] + "-" + CurrencyLookupMap[c.To]
+	return string(c.From) + "-" + string(c.To)
 }
 
 func CurrencyPairParse(pair string) (CurrencyPair, error) {
@@ -47,9 +99,17 @@ func CurrencyPairParse(pair string) (CurrencyPair, error) {
 		return CurrencyPair{}, errors.New("Bad formatting use: ###-@@@")
 	}
 
+	if !Currencies.IsKnown(Currency(pairSplit[0])) || !Currencies.IsKnown(Currency(pairSplit[1])) {
+		return CurrencyPair{}, errors.New("Unknown currency in pair: " + pair)
+	}
+
 	return CurrencyPair{
 		From: Currency(pairSplit[0]),
 		To:   Currency(pairSplit[1]),
 	}, nil
 	
 }
+
+type UpdateCurrencyRequest struct {
+	Enabled bool
+}
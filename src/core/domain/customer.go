@@ -17,6 +17,7 @@ type Customer struct {
 	State     string
 	Address   string
 	CreatedAt time.Time
+	Role      Role
 	Token     string
 }
 
@@ -41,7 +42,8 @@ type UpdateCustomerRequest struct {
 }
 
 /* ------------------------------------------------------------ */
-func (r Customer) Validate() *ValidationErrors{
+// Validate checks the customer at the given time, which decides whether they are old enough.
+func (r Customer) Validate(at time.Time) *ValidationErrors{
 	var errors []string
 
 	if r.ID == uuid.Nil {
@@ -59,7 +61,7 @@ func (r Customer) Validate() *ValidationErrors{
 	if r.Birthday.IsZero() {
 		errors = append(errors, "birthday is required")
 	} else {
-		age := calculateAge(r.Birthday)
+		age := calculateAge(r.Birthday, at)
 		if age < 18 {
 			errors = append(errors, "age must be at least 18")
 		}
@@ -96,14 +98,17 @@ func (r Customer) Validate() *ValidationErrors{
 		errors = append(errors, "address is required")
 	}
 
+	if r.Role != "" && !r.Role.IsValid() {
+		errors = append(errors, "role is not valid")
+	}
+
 	if len(errors) > 0 {
         return &ValidationErrors{Errors: errors}
     }
     return nil
 }
 
-func calculateAge(birthday time.Time) int {
-	today := time.Now()
+func calculateAge(birthday, today time.Time) int {
 	age := today.Year() - birthday.Year()
 	if today.Month() < birthday.Month() || (today.Month() == birthday.Month() && today.Day() < birthday.Day()) {
 		age--
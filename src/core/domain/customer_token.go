@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MAX_TOKEN_NAME_LENGTH = 255
+
+// CustomerToken is a bearer token of a customer. Only a salted hash of the
+// token is kept, the token itself is shown once when it is created.
+type CustomerToken struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Name       string
+	Salt       []byte
+	Hash       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // Zero when the token never expires
+	LastUsedAt time.Time // Zero until the token is used
+	RevokedAt  time.Time // Zero while the token isn't revoked
+}
+
+type CustomerTokenDTO struct {
+	ID         uuid.UUID
+	Name       string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time `json:",omitempty"`
+	LastUsedAt *time.Time `json:",omitempty"`
+	RevokedAt  *time.Time `json:",omitempty"`
+}
+
+type CreateCustomerTokenRequest struct {
+	Name      string
+	ExpiresAt time.Time // Optional, the token never expires without it
+}
+
+func (t CustomerToken) IsRevoked() bool {
+	return !t.RevokedAt.IsZero()
+}
+
+func (t CustomerToken) IsExpired(at time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !at.Before(t.ExpiresAt)
+}
+
+func (t CustomerToken) IsActive(at time.Time) bool {
+	return !t.IsRevoked() && !t.IsExpired(at)
+}
+
+/* ------------------------------------------------------------ */
+func (t CustomerToken) Validate() *ValidationErrors {
+	var errors []string
+
+	if t.ID == uuid.Nil || t.CustomerID == uuid.Nil {
+		errors = append(errors, "Token and customer ID cannot be nil")
+	}
+
+	if t.Name == "" {
+		errors = append(errors, "Name is required")
+	} else if len(t.Name) > MAX_TOKEN_NAME_LENGTH {
+		errors = append(errors, "Name is too long")
+	}
+
+	if len(t.Salt) == 0 || len(t.Hash) == 0 {
+		errors = append(errors, "Token must be hashed")
+	}
+
+	if !t.ExpiresAt.IsZero() && !t.ExpiresAt.After(t.CreatedAt) {
+		errors = append(errors, "ExpiresAt must be in the future")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,31 +11,52 @@ type DTO any
 
 /* ------------------------------------------------------------ */
 type AccountDTO struct {
-	ID                  uuid.UUID
-	CustomerID          uuid.UUID
-	Balance             float64
-	Type                string
-	Currency            string
-	Status              bool
-	OpeningDate         time.Time
-	LastTransactionDate time.Time
-	InterestRate        float64
-	CreatedAt           time.Time
+	ID                    uuid.UUID
+	CustomerID            uuid.UUID
+	Balance               json.Number
+	Type                  string
+	Currency              string
+	Status                string
+	Nickname              string `json:",omitempty"`
+	OpeningDate           time.Time
+	LastTransactionDate   time.Time
+	InterestRate          float64
+	InterestTiers         []InterestTierDTO `json:",omitempty"`
+	DayCount              string
+	AccruedInterest       json.Number
+	OverdraftLimit        json.Number `json:",omitempty"`
+	OverdraftInterestRate float64     `json:",omitempty"`
+	CreatedAt             time.Time
 }
 
 func (a Account) ToDTO() DTO {
-	return AccountDTO{
+	dto := AccountDTO{
 		ID: a.ID,
 		CustomerID: a.CustomerID,
-		Balance: a.Balance,
+		Balance: a.Balance.Number(),
 		Type: AccountLookupMap[a.Type],
-		Currency: CurrencyLookupMap[a.Currency],
-		Status: a.Status,
+		Currency: string(a.Currency),
+		Status: string(a.Status),
+		Nickname: a.Nickname,
 		OpeningDate: a.OpeningDate,
 		LastTransactionDate: a.LastTransactionDate,
 		InterestRate: a.InterestRate,
+		DayCount: string(a.DayCount),
+		AccruedInterest: FormatInterest(a.Accrued()),
 		CreatedAt: a.CreatedAt,
 	}
+
+	for _, tier := range a.InterestTiers {
+		dto.InterestTiers = append(dto.InterestTiers, InterestTierDTO{Above: tier.Above.Number(), Rate: tier.Rate})
+	}
+
+	// Only accounts with an arranged overdraft show it
+	if !a.OverdraftLimit.IsZero() {
+		dto.OverdraftLimit = a.OverdraftLimit.Number()
+		dto.OverdraftInterestRate = a.OverdraftInterestRate
+	}
+
+	return dto
 }
 /* ------------------------------------------------------------ */
 type CustomerDTO struct {
@@ -46,6 +68,7 @@ type CustomerDTO struct {
 	Phone     string    
 	State     string    
 	Address   string    
+	Role      string
 	CreatedAt time.Time 
 }
 
@@ -59,17 +82,308 @@ func (c Customer) ToDTO() DTO {
 		Phone:     c.Phone,
 		State:     c.State,
 		Address:   c.Address,
+		Role:      string(c.Role),
 		CreatedAt: c.CreatedAt,
 	}
 }
 /* ------------------------------------------------------------ */
 func (c Transaction) ToDTO() DTO {
-	return TransactionDTO{
+	dto := TransactionDTO{
 		ID:  c.ID,
-		SenderAccountID: c.SenderAccountID,
-		ReceiverAccountID: c.ReceiverAccountID,
-		Amount: c.Amount,
+		Type: string(c.Type),
+		Channel: string(c.Channel),
+		Amount: c.Amount.Number(),
 		CurrencyPair: c.CurrencyPair.String(),
+		Fee: c.Fee.Number(),
+		Status: string(c.Status),
+		FailureReason: c.FailureReason,
 		CreatedAt: c.CreatedAt,
 	}
-}
\ No newline at end of file
+
+	if c.SenderAccountID != uuid.Nil {
+		dto.SenderAccountID = &c.SenderAccountID
+	}
+
+	if c.ReceiverAccountID != uuid.Nil {
+		dto.ReceiverAccountID = &c.ReceiverAccountID
+	}
+
+	if c.ExchangeRate != nil {
+		dto.ExchangeRate = json.Number(FormatRate(c.ExchangeRate))
+	}
+
+	if c.QuoteID != uuid.Nil {
+		dto.QuoteID = &c.QuoteID
+	}
+
+	if c.ReversalOf != uuid.Nil {
+		dto.ReversalOf = &c.ReversalOf
+	}
+
+	if len(c.Reversals) > 0 {
+		dto.Reversals = c.Reversals
+		dto.Refunded = c.Refunded.Number()
+	}
+
+	if c.MandateID != uuid.Nil {
+		dto.MandateID = &c.MandateID
+	}
+
+	return dto
+}/* ------------------------------------------------------------ */
+type LedgerEntryDTO struct {
+	ID            uuid.UUID
+	JournalID     uuid.UUID
+	JournalKind   string
+	TransactionID *uuid.UUID
+	AccountID     *uuid.UUID
+	SystemAccount string
+	Side          string
+	Amount        json.Number
+	Currency      string
+	Description   string
+	CreatedAt     time.Time
+}
+
+func (e LedgerEntry) ToDTO() DTO {
+	dto := LedgerEntryDTO{
+		ID:            e.ID,
+		JournalID:     e.JournalID,
+		JournalKind:   string(e.JournalKind),
+		SystemAccount: string(e.SystemAccount),
+		Side:          string(e.Side),
+		Amount:        e.Amount.Number(),
+		Currency:      string(e.Amount.Currency),
+		Description:   e.Description,
+		CreatedAt:     e.CreatedAt,
+	}
+
+	if e.TransactionID != uuid.Nil {
+		dto.TransactionID = &e.TransactionID
+	}
+	if e.AccountID != uuid.Nil {
+		dto.AccountID = &e.AccountID
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+type LedgerBalanceDTO struct {
+	AccountID uuid.UUID
+	Stored    json.Number
+	Derived   json.Number
+	Currency  string
+	Balanced  bool
+}
+
+func (b LedgerBalance) ToDTO() DTO {
+	return LedgerBalanceDTO{
+		AccountID: b.AccountID,
+		Stored:    b.Stored.Number(),
+		Derived:   b.Derived.Number(),
+		Currency:  string(b.Stored.Currency),
+		Balanced:  b.IsBalanced(),
+	}
+}
+/* ------------------------------------------------------------ */
+type CurrencyDTO struct {
+	Code        string
+	NumericCode string
+	MinorUnits  int
+	Name        string
+	Enabled     bool
+}
+
+func (c CurrencyInfo) ToDTO() DTO {
+	return CurrencyDTO{
+		Code:        string(c.Code),
+		NumericCode: c.NumericCode,
+		MinorUnits:  c.MinorUnits,
+		Name:        c.Name,
+		Enabled:     c.Enabled,
+	}
+}
+/* ------------------------------------------------------------ */
+func (q FXQuote) ToDTO() DTO {
+	return FXQuoteDTO{
+		ID:           q.ID,
+		CurrencyPair: q.Pair.String(),
+		Amount:       q.Amount.Number(),
+		MidRate:      json.Number(FormatRate(q.MidRate)),
+		Rate:         json.Number(FormatRate(q.Rate)),
+		Spread:       json.Number(FormatRate(q.Spread)),
+		Fee:          q.Fee.Number(),
+		Converted:    q.Converted.Number(),
+		CreatedAt:    q.CreatedAt,
+		ExpiresAt:    q.ExpiresAt,
+	}
+}
+/* ------------------------------------------------------------ */
+func (t CustomerToken) ToDTO() DTO {
+	dto := CustomerTokenDTO{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+
+	if !t.ExpiresAt.IsZero() {
+		dto.ExpiresAt = &t.ExpiresAt
+	}
+	if !t.LastUsedAt.IsZero() {
+		dto.LastUsedAt = &t.LastUsedAt
+	}
+	if !t.RevokedAt.IsZero() {
+		dto.RevokedAt = &t.RevokedAt
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (c Consent) ToDTO() DTO {
+	dto := ConsentDTO{
+		ID:         c.ID,
+		ClientName: c.ClientName,
+		AccountIDs: c.AccountIDs,
+		Scopes:     c.Scopes,
+		CreatedAt:  c.CreatedAt,
+		ExpiresAt:  c.ExpiresAt,
+	}
+
+	if !c.LastUsedAt.IsZero() {
+		dto.LastUsedAt = &c.LastUsedAt
+	}
+	if !c.RevokedAt.IsZero() {
+		dto.RevokedAt = &c.RevokedAt
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (a Adjustment) ToDTO() DTO {
+	dto := AdjustmentDTO{
+		ID:        a.ID,
+		AccountID: a.AccountID,
+		Amount:    a.Amount.Number(),
+		Currency:  string(a.Amount.Currency),
+		Reason:    string(a.Reason),
+		Note:      a.Note,
+		CreatedAt: a.CreatedAt,
+	}
+
+	if a.CreatedBy != uuid.Nil {
+		dto.CreatedBy = &a.CreatedBy
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (p TokenPair) ToDTO() DTO {
+	return TokenPairDTO{
+		AccessToken:  p.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(p.AccessExpiresAt.Sub(p.IssuedAt).Round(time.Second).Seconds()),
+		RefreshToken: p.RefreshToken,
+	}
+}
+/* ------------------------------------------------------------ */
+func (j Job) ToDTO() DTO {
+	return JobDTO{
+		Name:      j.Name,
+		Schedule:  j.Schedule.Expression,
+		NextRunAt: j.NextRunAt,
+	}
+}
+/* ------------------------------------------------------------ */
+func (r JobRun) ToDTO() DTO {
+	dto := JobRunDTO{
+		ID:        r.ID,
+		Job:       r.Job,
+		Trigger:   string(r.Trigger),
+		Status:    string(r.Status),
+		Error:     r.Error,
+		StartedAt: r.StartedAt,
+	}
+
+	if r.TriggeredBy != uuid.Nil {
+		dto.TriggeredBy = &r.TriggeredBy
+	}
+	if !r.ScheduledFor.IsZero() {
+		dto.ScheduledFor = &r.ScheduledFor
+	}
+	if !r.FinishedAt.IsZero() {
+		dto.FinishedAt = &r.FinishedAt
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (o StandingOrder) ToDTO() DTO {
+	dto := StandingOrderDTO{
+		ID:                o.ID,
+		AccountID:         o.AccountID,
+		ReceiverAccountID: o.ReceiverAccountID,
+		Amount:            o.Amount.Number(),
+		Currency:          string(o.Amount.Currency),
+		Frequency:         string(o.Frequency),
+		StartDate:         o.StartDate.Format(time.DateOnly),
+		Attempts:          o.Attempts,
+		Status:            string(o.Status),
+		CreatedAt:         o.CreatedAt,
+	}
+
+	if o.Frequency == StandingOrderCustom {
+		dto.Rule = o.Recurrence.Rule
+	}
+	if !o.EndDate.IsZero() {
+		dto.EndDate = o.EndDate.Format(time.DateOnly)
+	}
+	if !o.NextDueDate.IsZero() {
+		dto.NextDueDate = o.NextDueDate.Format(time.DateOnly)
+	}
+	if !o.NextAttemptAt.IsZero() {
+		dto.NextAttemptAt = &o.NextAttemptAt
+	}
+	if !o.CancelledAt.IsZero() {
+		dto.CancelledAt = &o.CancelledAt
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (e StandingOrderExecution) ToDTO() DTO {
+	dto := StandingOrderExecutionDTO{
+		ID:            e.ID,
+		DueDate:       e.DueDate.Format(time.DateOnly),
+		Attempt:       e.Attempt,
+		TransactionID: e.TransactionID,
+		Status:        string(e.Status),
+		Error:         e.Error,
+		CreatedAt:     e.CreatedAt,
+	}
+
+	if !e.FinishedAt.IsZero() {
+		dto.FinishedAt = &e.FinishedAt
+	}
+
+	return dto
+}
+/* ------------------------------------------------------------ */
+func (m Mandate) ToDTO() DTO {
+	dto := MandateDTO{
+		ID:                m.ID,
+		PayerAccountID:    m.PayerAccountID,
+		CreditorAccountID: m.CreditorAccountID,
+		Reference:         m.Reference,
+		MaxAmount:         m.MaxAmount.Number(),
+		Currency:          string(m.MaxAmount.Currency),
+		Frequency:         string(m.Frequency),
+		Status:            string(m.Status),
+		CreatedAt:         m.CreatedAt,
+	}
+
+	if !m.CancelledAt.IsZero() {
+		dto.CancelledAt = &m.CancelledAt
+	}
+
+	return dto
+}
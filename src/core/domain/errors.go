@@ -11,6 +11,10 @@ var (
 	ErrInternalFailure = errors.New("Error internal failure")
 	ErrNotFound        = errors.New("Error not found")
 	ErrValidation      = errors.New("Error validation failed")
+	ErrConflict        = errors.New("Error conflict")
+	ErrUnprocessable   = errors.New("Error unprocessable entity")
+	ErrUnauthorized    = errors.New("Error unauthorized")
+	ErrForbidden       = errors.New("Error forbidden")
 )
 
 type ValidationErrors struct {
@@ -22,21 +26,48 @@ func (e *ValidationErrors) Error() string {
 }
 
 func InternalFailure(err error) error {
-	return fmt.Errorf("%w: %s", ErrInternalFailure, err.Error())
+	return fmt.Errorf("%w: %w", ErrInternalFailure, err)
 }
 
 func BadRequestError(err error) error {
-	return fmt.Errorf("%w: %s", ErrBadRequest, err.Error())
+	return fmt.Errorf("%w: %w", ErrBadRequest, err)
 }
 
 func NotFoundError(err error) error {
-	return fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+	return fmt.Errorf("%w: %w", ErrNotFound, err)
+}
+
+func ConflictError(err error) error {
+	return fmt.Errorf("%w: %w", ErrConflict, err)
+}
+
+func UnprocessableError(err error) error {
+	return fmt.Errorf("%w: %w", ErrUnprocessable, err)
+}
+
+func UnauthorizedError(err error) error {
+	return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+}
+
+func ForbiddenError(err error) error {
+	return fmt.Errorf("%w: %w", ErrForbidden, err)
 }
 
 func ValidationError(err *ValidationErrors) error {
 	return fmt.Errorf("%w: %s", ErrValidation, err.Error())
 }
 
+// AsDomainError keeps errors that already carry a domain error as they are
+// and reports anything else as an internal failure.
+func AsDomainError(err error) error {
+	for _, domainErr := range []error{ErrBadRequest, ErrInternalFailure, ErrNotFound, ErrValidation, ErrConflict, ErrUnprocessable, ErrUnauthorized, ErrForbidden} {
+		if errors.Is(err, domainErr) {
+			return err
+		}
+	}
+	return InternalFailure(err)
+}
+
 func ExtractValidationErrorsToList(err error) []string {
 	return strings.Split(strings.Replace(err.Error(), ErrValidation.Error()+": ", "", -1), ";")
 }
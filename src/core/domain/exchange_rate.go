@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Rates are kept with a fixed number of decimals, so the rate stored on a
+// transaction is exactly the one its amount was converted with.
+const RATE_DECIMALS = 10
+
+type ExchangeRate struct {
+	Pair        CurrencyPair
+	Rate        *big.Rat // Units of Pair.To for one unit of Pair.From
+	EffectiveAt time.Time
+	Source      string
+}
+
+func NewExchangeRate(pair CurrencyPair, rate *big.Rat, effectiveAt time.Time, source string) ExchangeRate {
+	return ExchangeRate{
+		Pair:        pair,
+		Rate:        RoundRate(rate),
+		EffectiveAt: effectiveAt,
+		Source:      source,
+	}
+}
+
+// IdentityRate is used when no conversion takes place.
+func IdentityRate(currency Currency, at time.Time) ExchangeRate {
+	return NewExchangeRate(NewCurrencyPair(currency, currency), big.NewRat(1, 1), at, "identity")
+}
+
+// Inverse returns the rate of the opposite direction.
+func (r ExchangeRate) Inverse() ExchangeRate {
+	return NewExchangeRate(NewCurrencyPair(r.Pair.To, r.Pair.From), new(big.Rat).Inv(r.Rate), r.EffectiveAt, r.Source)
+}
+
+// Cross chains this rate with one starting at its To currency, e.g. EUR-USD with
+// USD-JPY gives EUR-JPY. The result is effective from the later of the two rates.
+func (r ExchangeRate) Cross(next ExchangeRate) ExchangeRate {
+	effectiveAt := r.EffectiveAt
+	if next.EffectiveAt.After(effectiveAt) {
+		effectiveAt = next.EffectiveAt
+	}
+
+	rate := new(big.Rat).Mul(r.Rate, next.Rate)
+
+	return NewExchangeRate(NewCurrencyPair(r.Pair.From, next.Pair.To), rate, effectiveAt, "cross:"+string(r.Pair.To))
+}
+
+// Convert converts money in the From currency of the pair into the To currency.
+func (r ExchangeRate) Convert(amount Money) Money {
+	return amount.Convert(r.Pair.To, r.Rate)
+}
+
+/* ------------------------------------------------------------ */
+func (r ExchangeRate) Validate() *ValidationErrors {
+	var errors []string
+
+	if !Currencies.IsKnown(r.Pair.From) {
+		errors = append(errors, "This currency is not supported!")
+	}
+
+	if !Currencies.IsKnown(r.Pair.To) {
+		errors = append(errors, "This currency is not supported!")
+	}
+
+	if r.Rate == nil || r.Rate.Sign() <= 0 {
+		errors = append(errors, "Rate must be bigger than 0")
+	}
+
+	if r.EffectiveAt.IsZero() {
+		errors = append(errors, "EffectiveAt must be set")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
+
+// ParseRate parses a decimal rate like "0.9369".
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return nil, errors.New("Invalid rate format: " + value)
+	}
+
+	return RoundRate(rate), nil
+}
+
+// FormatRate formats a rate as a decimal without trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	formatted := rate.FloatString(RATE_DECIMALS)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// RoundRate rounds the rate half to even to RATE_DECIMALS decimals.
+func RoundRate(rate *big.Rat) *big.Rat {
+	scale := new(big.Rat).SetInt64(pow10(RATE_DECIMALS))
+	scaled := roundHalfEven(new(big.Rat).Mul(rate, scale))
+
+	return new(big.Rat).SetFrac(scaled, scale.Num())
+}
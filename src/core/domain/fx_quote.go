@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A quote locks the price of a conversion for this long.
+const FX_QUOTE_TTL = 30 * time.Second
+
+// FXPricing is what the bank charges on top of the market rate of a conversion.
+type FXPricing struct {
+	Spread  *big.Rat // Fraction taken off the market rate, e.g. 0.005 for 0.5 %
+	FeeRate *big.Rat // Fraction of the amount charged as a fee in the sender currency
+}
+
+// FXQuote is the priced conversion of an amount. The fee is deducted from the amount
+// and the rest is converted with the rate after the spread.
+type FXQuote struct {
+	ID            uuid.UUID
+	Pair          CurrencyPair
+	Amount        Money    // Amount in Pair.From, fee included
+	MidRate       *big.Rat // The market rate the quote was priced from
+	Rate          *big.Rat // The rate the customer gets
+	Spread        *big.Rat
+	Fee           Money // Fee in Pair.From
+	Converted     Money // Amount credited in Pair.To
+	TransactionID uuid.UUID // uuid.Nil until the quote is used by a transaction
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	CustomerID    uuid.UUID // The customer who asked for the quote, only transfers from their accounts can use it
+}
+
+type FXQuoteDTO struct {
+	ID           uuid.UUID
+	CurrencyPair string
+	Amount       json.Number
+	MidRate      json.Number
+	Rate         json.Number
+	Spread       json.Number
+	Fee          json.Number
+	Converted    json.Number
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+type CreateFXQuoteRequest struct {
+	From   string
+	To     string
+	Amount json.Number // Amount in the From currency
+}
+
+// NewFXQuote prices the conversion of the amount with the market rate. Amounts that
+// stay in the same currency are never charged.
+func NewFXQuote(amount Money, market ExchangeRate, pricing FXPricing, at time.Time) FXQuote {
+	quote := FXQuote{
+		ID:        uuid.New(),
+		Pair:      NewCurrencyPair(amount.Currency, market.Pair.To),
+		Amount:    amount,
+		MidRate:   market.Rate,
+		Rate:      market.Rate,
+		Spread:    new(big.Rat),
+		Fee:       NewMoney(0, amount.Currency),
+		CreatedAt: at,
+		ExpiresAt: at.Add(FX_QUOTE_TTL),
+	}
+
+	if quote.Pair.From != quote.Pair.To {
+		if pricing.Spread != nil {
+			quote.Spread = RoundRate(pricing.Spread)
+			quote.Rate = RoundRate(new(big.Rat).Mul(market.Rate, new(big.Rat).Sub(big.NewRat(1, 1), quote.Spread)))
+		}
+		if pricing.FeeRate != nil {
+			quote.Fee = amount.Mul(pricing.FeeRate)
+		}
+	}
+
+	quote.Converted = amount.Sub(quote.Fee).Convert(quote.Pair.To, quote.Rate)
+
+	return quote
+}
+
+func (q FXQuote) IsExpired(at time.Time) bool {
+	return !at.Before(q.ExpiresAt)
+}
+
+func (q FXQuote) IsUsed() bool {
+	return q.TransactionID != uuid.Nil
+}
+
+// Matches reports whether the quote was made for converting exactly this amount into the currency.
+func (q FXQuote) Matches(amount Money, to Currency) bool {
+	return q.Pair.From == amount.Currency && q.Pair.To == to && q.Amount.Cmp(amount) == 0
+}
+
+/* ------------------------------------------------------------ */
+func (q FXQuote) Validate() *ValidationErrors {
+	var errors []string
+
+	if q.ID == uuid.Nil || q.CustomerID == uuid.Nil {
+		errors = append(errors, "ID and customer ID cannot be nil")
+	}
+
+	if q.Amount.IsNegative() || q.Amount.IsZero() {
+		errors = append(errors, "Amount must be bigger than 0!")
+	}
+
+	if q.Amount.Cmp(MoneyFromMajor(MAX_TRANSFER_AMOUNT, q.Amount.Currency)) > 0 {
+		errors = append(errors, "Amount must not be bigger than the maximum transfer amount")
+	}
+
+	if q.Converted.IsNegative() || q.Converted.IsZero() {
+		errors = append(errors, "Converted amount must be bigger than 0!")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+)
+
+const MAX_IDEMPOTENCY_KEY_LENGTH = 255
+
+// How long a stored response can be replayed
+const IDEMPOTENCY_KEY_TTL = 24 * time.Hour
+
+type IdempotencyKey struct {
+	Key              string
+	RequestHash      string
+	ResponseCode     int // 0 while the original request is still being processed
+	ResponseLocation string
+	ResponseBody     []byte
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	Principal        string // Who sent the request, the same key of another caller is a different key
+}
+
+func (k IdempotencyKey) IsCompleted() bool {
+	return k.ResponseCode != 0
+}
+
+func (k IdempotencyKey) IsExpired(now time.Time) bool {
+	return now.After(k.ExpiresAt)
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const INTEREST_DECIMALS = 12 // Accrued interest is kept this exactly until it is posted in minor units
+
+// DayCount is the convention deciding which part of the yearly rate a single day earns.
+type DayCount string
+
+const (
+	DayCountActual365 DayCount = "ACT/365"
+	DayCountActual360 DayCount = "ACT/360"
+	DayCount30360     DayCount = "30/360" // Every month counts as 30 days, so month ends can count as zero to three days
+)
+
+var DayCounts = []DayCount{DayCountActual365, DayCountActual360, DayCount30360}
+
+// InterestTier pays its yearly rate on the part of the balance above the threshold, up to the
+// threshold of the next tier. The part below the first tier earns the InterestRate of the account.
+type InterestTier struct {
+	Above Money
+	Rate  float64
+}
+
+type InterestTierRequest struct {
+	Above json.Number // In the account currency
+	Rate  float64
+}
+
+type InterestTierDTO struct {
+	Above json.Number
+	Rate  float64
+}
+
+func ParseDayCount(dayCount string) (DayCount, error) {
+	parsed := DayCount(strings.ToUpper(strings.TrimSpace(dayCount)))
+
+	if slices.Contains(DayCounts, parsed) {
+		return parsed, nil
+	}
+
+	return "", errors.New("Unknown day count convention: " + dayCount)
+}
+
+// YearFraction returns the part of a year the day counts as under the convention.
+func (d DayCount) YearFraction(day time.Time) *big.Rat {
+	switch d {
+	case DayCountActual360:
+		return big.NewRat(1, 360)
+	case DayCount30360:
+		return big.NewRat(days30360(day, day.AddDate(0, 0, 1)), 360)
+	default:
+		return big.NewRat(1, 365)
+	}
+}
+
+// days30360 counts the days between the dates as if every month had 30 days.
+func days30360(start, end time.Time) int64 {
+	d1, d2 := start.Day(), end.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+
+	years := int64(end.Year() - start.Year())
+	months := int64(end.Month() - start.Month())
+
+	return 360*years + 30*months + int64(d2-d1)
+}
+
+// YearlyInterest returns the interest a whole year at the balance would earn, in major units.
+// Positive balances earn the tiered rates and negative ones are charged the overdraft rate.
+func (a Account) YearlyInterest(balance Money) *big.Rat {
+	if balance.IsNegative() {
+		return yearlyInterest(balance, a.OverdraftInterestRate)
+	}
+
+	interest := new(big.Rat)
+	from, rate := NewMoney(0, balance.Currency), a.InterestRate
+
+	for _, tier := range a.InterestTiers {
+		if balance.Cmp(tier.Above) <= 0 {
+			break
+		}
+
+		interest.Add(interest, yearlyInterest(tier.Above.Sub(from), rate))
+		from, rate = tier.Above, tier.Rate
+	}
+
+	return interest.Add(interest, yearlyInterest(balance.Sub(from), rate))
+}
+
+// DailyInterest returns the exact interest of a day ending at the balance, in major units.
+func (a Account) DailyInterest(balance Money, day time.Time) *big.Rat {
+	return new(big.Rat).Mul(a.YearlyInterest(balance), a.DayCount.YearFraction(day))
+}
+
+// Accrued returns the interest accrued since the last posting, zero when nothing was accrued yet.
+func (a Account) Accrued() *big.Rat {
+	if a.AccruedInterest == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(a.AccruedInterest)
+}
+
+// EarnsInterest reports whether the account earns or can be charged any interest.
+func (a Account) EarnsInterest() bool {
+	return a.InterestRate != 0 || len(a.InterestTiers) > 0 || a.OverdraftInterestRate != 0
+}
+
+func yearlyInterest(amount Money, rate float64) *big.Rat {
+	return new(big.Rat).Mul(amount.Rat(), RatFromFloat(rate))
+}
+
+// ParseInterestTiers parses the thresholds of the tiers in the account currency.
+func ParseInterestTiers(tiers []InterestTierRequest, currency Currency) ([]InterestTier, error) {
+	var parsed []InterestTier
+
+	for _, tier := range tiers {
+		above, err := ParseMoney(string(tier.Above), currency)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, InterestTier{Above: above, Rate: tier.Rate})
+	}
+
+	return parsed, nil
+}
+
+// StartOfDay returns the midnight the day of the time started with, in UTC.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// IsMonthEnd reports whether the day is the last one of its month, when accrued interest gets posted.
+func IsMonthEnd(day time.Time) bool {
+	return day.AddDate(0, 0, 1).Day() == 1
+}
+
+// RoundInterest rounds accrued interest half to even to INTEREST_DECIMALS decimals, it is rounded
+// after every day so catching up on many days accrues exactly as much as accruing them one by one.
+func RoundInterest(interest *big.Rat) *big.Rat {
+	scale := new(big.Rat).SetInt64(pow10(INTEREST_DECIMALS))
+	scaled := roundHalfEven(new(big.Rat).Mul(interest, scale))
+
+	return new(big.Rat).SetFrac(scaled, scale.Num())
+}
+
+// FormatInterest formats accrued interest as a decimal without trailing zeros.
+func FormatInterest(interest *big.Rat) json.Number {
+	formatted := strings.TrimRight(RoundInterest(interest).FloatString(INTEREST_DECIMALS), "0")
+	return json.Number(strings.TrimSuffix(formatted, "."))
+}
+
+/* ------------------------------------------------------------ */
+func validateInterestTiers(a Account) []string {
+	var errors []string
+
+	if len(a.InterestTiers) > 0 && a.Type != 3 {
+		errors = append(errors, "Non-savings account cannot have interest tiers")
+	}
+
+	for i, tier := range a.InterestTiers {
+		if tier.Above.Currency != a.Currency {
+			errors = append(errors, "Interest tier currency must match the account currency")
+		}
+
+		if tier.Above.IsNegative() || tier.Above.IsZero() {
+			errors = append(errors, "Interest tier threshold must be bigger than 0")
+		} else if i > 0 && tier.Above.Cmp(a.InterestTiers[i-1].Above) <= 0 {
+			errors = append(errors, "Interest tiers must be in ascending order")
+		}
+
+		if tier.Rate < 0 {
+			errors = append(errors, "Interest tier rate cannot be negative")
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,172 @@
+package domain
+
+// ISO4217 lists the active ISO 4217 currencies that have a defined minor unit.
+// Funds codes without minor units (XDR, precious metals...) are left out on purpose.
+var ISO4217 = []CurrencyInfo{
+	{Code: "AED", NumericCode: "784", MinorUnits: 2, Name: "UAE Dirham"},
+	{Code: "AFN", NumericCode: "971", MinorUnits: 2, Name: "Afghani"},
+	{Code: "ALL", NumericCode: "008", MinorUnits: 2, Name: "Lek"},
+	{Code: "AMD", NumericCode: "051", MinorUnits: 2, Name: "Armenian Dram"},
+	{Code: "ANG", NumericCode: "532", MinorUnits: 2, Name: "Netherlands Antillean Guilder"},
+	{Code: "AOA", NumericCode: "973", MinorUnits: 2, Name: "Kwanza"},
+	{Code: "ARS", NumericCode: "032", MinorUnits: 2, Name: "Argentine Peso"},
+	{Code: "AUD", NumericCode: "036", MinorUnits: 2, Name: "Australian Dollar"},
+	{Code: "AWG", NumericCode: "533", MinorUnits: 2, Name: "Aruban Florin"},
+	{Code: "AZN", NumericCode: "944", MinorUnits: 2, Name: "Azerbaijan Manat"},
+	{Code: "BAM", NumericCode: "977", MinorUnits: 2, Name: "Convertible Mark"},
+	{Code: "BBD", NumericCode: "052", MinorUnits: 2, Name: "Barbados Dollar"},
+	{Code: "BDT", NumericCode: "050", MinorUnits: 2, Name: "Taka"},
+	{Code: "BGN", NumericCode: "975", MinorUnits: 2, Name: "Bulgarian Lev"},
+	{Code: "BHD", NumericCode: "048", MinorUnits: 3, Name: "Bahraini Dinar"},
+	{Code: "BIF", NumericCode: "108", MinorUnits: 0, Name: "Burundi Franc"},
+	{Code: "BMD", NumericCode: "060", MinorUnits: 2, Name: "Bermudian Dollar"},
+	{Code: "BND", NumericCode: "096", MinorUnits: 2, Name: "Brunei Dollar"},
+	{Code: "BOB", NumericCode: "068", MinorUnits: 2, Name: "Boliviano"},
+	{Code: "BOV", NumericCode: "984", MinorUnits: 2, Name: "Mvdol"},
+	{Code: "BRL", NumericCode: "986", MinorUnits: 2, Name: "Brazilian Real"},
+	{Code: "BSD", NumericCode: "044", MinorUnits: 2, Name: "Bahamian Dollar"},
+	{Code: "BTN", NumericCode: "064", MinorUnits: 2, Name: "Ngultrum"},
+	{Code: "BWP", NumericCode: "072", MinorUnits: 2, Name: "Pula"},
+	{Code: "BYN", NumericCode: "933", MinorUnits: 2, Name: "Belarusian Ruble"},
+	{Code: "BZD", NumericCode: "084", MinorUnits: 2, Name: "Belize Dollar"},
+	{Code: "CAD", NumericCode: "124", MinorUnits: 2, Name: "Canadian Dollar"},
+	{Code: "CDF", NumericCode: "976", MinorUnits: 2, Name: "Congolese Franc"},
+	{Code: "CHE", NumericCode: "947", MinorUnits: 2, Name: "WIR Euro"},
+	{Code: "CHF", NumericCode: "756", MinorUnits: 2, Name: "Swiss Franc"},
+	{Code: "CHW", NumericCode: "948", MinorUnits: 2, Name: "WIR Franc"},
+	{Code: "CLF", NumericCode: "990", MinorUnits: 4, Name: "Unidad de Fomento"},
+	{Code: "CLP", NumericCode: "152", MinorUnits: 0, Name: "Chilean Peso"},
+	{Code: "CNY", NumericCode: "156", MinorUnits: 2, Name: "Yuan Renminbi"},
+	{Code: "COP", NumericCode: "170", MinorUnits: 2, Name: "Colombian Peso"},
+	{Code: "COU", NumericCode: "970", MinorUnits: 2, Name: "Unidad de Valor Real"},
+	{Code: "CRC", NumericCode: "188", MinorUnits: 2, Name: "Costa Rican Colon"},
+	{Code: "CUP", NumericCode: "192", MinorUnits: 2, Name: "Cuban Peso"},
+	{Code: "CVE", NumericCode: "132", MinorUnits: 2, Name: "Cabo Verde Escudo"},
+	{Code: "CZK", NumericCode: "203", MinorUnits: 2, Name: "Czech Koruna"},
+	{Code: "DJF", NumericCode: "262", MinorUnits: 0, Name: "Djibouti Franc"},
+	{Code: "DKK", NumericCode: "208", MinorUnits: 2, Name: "Danish Krone"},
+	{Code: "DOP", NumericCode: "214", MinorUnits: 2, Name: "Dominican Peso"},
+	{Code: "DZD", NumericCode: "012", MinorUnits: 2, Name: "Algerian Dinar"},
+	{Code: "EGP", NumericCode: "818", MinorUnits: 2, Name: "Egyptian Pound"},
+	{Code: "ERN", NumericCode: "232", MinorUnits: 2, Name: "Nakfa"},
+	{Code: "ETB", NumericCode: "230", MinorUnits: 2, Name: "Ethiopian Birr"},
+	{Code: "EUR", NumericCode: "978", MinorUnits: 2, Name: "Euro"},
+	{Code: "FJD", NumericCode: "242", MinorUnits: 2, Name: "Fiji Dollar"},
+	{Code: "FKP", NumericCode: "238", MinorUnits: 2, Name: "Falkland Islands Pound"},
+	{Code: "GBP", NumericCode: "826", MinorUnits: 2, Name: "Pound Sterling"},
+	{Code: "GEL", NumericCode: "981", MinorUnits: 2, Name: "Lari"},
+	{Code: "GHS", NumericCode: "936", MinorUnits: 2, Name: "Ghana Cedi"},
+	{Code: "GIP", NumericCode: "292", MinorUnits: 2, Name: "Gibraltar Pound"},
+	{Code: "GMD", NumericCode: "270", MinorUnits: 2, Name: "Dalasi"},
+	{Code: "GNF", NumericCode: "324", MinorUnits: 0, Name: "Guinean Franc"},
+	{Code: "GTQ", NumericCode: "320", MinorUnits: 2, Name: "Quetzal"},
+	{Code: "GYD", NumericCode: "328", MinorUnits: 2, Name: "Guyana Dollar"},
+	{Code: "HKD", NumericCode: "344", MinorUnits: 2, Name: "Hong Kong Dollar"},
+	{Code: "HNL", NumericCode: "340", MinorUnits: 2, Name: "Lempira"},
+	{Code: "HTG", NumericCode: "332", MinorUnits: 2, Name: "Gourde"},
+	{Code: "HUF", NumericCode: "348", MinorUnits: 2, Name: "Forint"},
+	{Code: "IDR", NumericCode: "360", MinorUnits: 2, Name: "Rupiah"},
+	{Code: "ILS", NumericCode: "376", MinorUnits: 2, Name: "New Israeli Sheqel"},
+	{Code: "INR", NumericCode: "356", MinorUnits: 2, Name: "Indian Rupee"},
+	{Code: "IQD", NumericCode: "368", MinorUnits: 3, Name: "Iraqi Dinar"},
+	{Code: "IRR", NumericCode: "364", MinorUnits: 2, Name: "Iranian Rial"},
+	{Code: "ISK", NumericCode: "352", MinorUnits: 0, Name: "Iceland Krona"},
+	{Code: "JMD", NumericCode: "388", MinorUnits: 2, Name: "Jamaican Dollar"},
+	{Code: "JOD", NumericCode: "400", MinorUnits: 3, Name: "Jordanian Dinar"},
+	{Code: "JPY", NumericCode: "392", MinorUnits: 0, Name: "Yen"},
+	{Code: "KES", NumericCode: "404", MinorUnits: 2, Name: "Kenyan Shilling"},
+	{Code: "KGS", NumericCode: "417", MinorUnits: 2, Name: "Som"},
+	{Code: "KHR", NumericCode: "116", MinorUnits: 2, Name: "Riel"},
+	{Code: "KMF", NumericCode: "174", MinorUnits: 0, Name: "Comorian Franc"},
+	{Code: "KPW", NumericCode: "408", MinorUnits: 2, Name: "North Korean Won"},
+	{Code: "KRW", NumericCode: "410", MinorUnits: 0, Name: "Won"},
+	{Code: "KWD", NumericCode: "414", MinorUnits: 3, Name: "Kuwaiti Dinar"},
+	{Code: "KYD", NumericCode: "136", MinorUnits: 2, Name: "Cayman Islands Dollar"},
+	{Code: "KZT", NumericCode: "398", MinorUnits: 2, Name: "Tenge"},
+	{Code: "LAK", NumericCode: "418", MinorUnits: 2, Name: "Lao Kip"},
+	{Code: "LBP", NumericCode: "422", MinorUnits: 2, Name: "Lebanese Pound"},
+	{Code: "LKR", NumericCode: "144", MinorUnits: 2, Name: "Sri Lanka Rupee"},
+	{Code: "LRD", NumericCode: "430", MinorUnits: 2, Name: "Liberian Dollar"},
+	{Code: "LSL", NumericCode: "426", MinorUnits: 2, Name: "Loti"},
+	{Code: "LYD", NumericCode: "434", MinorUnits: 3, Name: "Libyan Dinar"},
+	{Code: "MAD", NumericCode: "504", MinorUnits: 2, Name: "Moroccan Dirham"},
+	{Code: "MDL", NumericCode: "498", MinorUnits: 2, Name: "Moldovan Leu"},
+	{Code: "MGA", NumericCode: "969", MinorUnits: 2, Name: "Malagasy Ariary"},
+	{Code: "MKD", NumericCode: "807", MinorUnits: 2, Name: "Denar"},
+	{Code: "MMK", NumericCode: "104", MinorUnits: 2, Name: "Kyat"},
+	{Code: "MNT", NumericCode: "496", MinorUnits: 2, Name: "Tugrik"},
+	{Code: "MOP", NumericCode: "446", MinorUnits: 2, Name: "Pataca"},
+	{Code: "MRU", NumericCode: "929", MinorUnits: 2, Name: "Ouguiya"},
+	{Code: "MUR", NumericCode: "480", MinorUnits: 2, Name: "Mauritius Rupee"},
+	{Code: "MVR", NumericCode: "462", MinorUnits: 2, Name: "Rufiyaa"},
+	{Code: "MWK", NumericCode: "454", MinorUnits: 2, Name: "Malawi Kwacha"},
+	{Code: "MXN", NumericCode: "484", MinorUnits: 2, Name: "Mexican Peso"},
+	{Code: "MXV", NumericCode: "979", MinorUnits: 2, Name: "Mexican Unidad de Inversion"},
+	{Code: "MYR", NumericCode: "458", MinorUnits: 2, Name: "Malaysian Ringgit"},
+	{Code: "MZN", NumericCode: "943", MinorUnits: 2, Name: "Mozambique Metical"},
+	{Code: "NAD", NumericCode: "516", MinorUnits: 2, Name: "Namibia Dollar"},
+	{Code: "NGN", NumericCode: "566", MinorUnits: 2, Name: "Naira"},
+	{Code: "NIO", NumericCode: "558", MinorUnits: 2, Name: "Cordoba Oro"},
+	{Code: "NOK", NumericCode: "578", MinorUnits: 2, Name: "Norwegian Krone"},
+	{Code: "NPR", NumericCode: "524", MinorUnits: 2, Name: "Nepalese Rupee"},
+	{Code: "NZD", NumericCode: "554", MinorUnits: 2, Name: "New Zealand Dollar"},
+	{Code: "OMR", NumericCode: "512", MinorUnits: 3, Name: "Rial Omani"},
+	{Code: "PAB", NumericCode: "590", MinorUnits: 2, Name: "Balboa"},
+	{Code: "PEN", NumericCode: "604", MinorUnits: 2, Name: "Sol"},
+	{Code: "PGK", NumericCode: "598", MinorUnits: 2, Name: "Kina"},
+	{Code: "PHP", NumericCode: "608", MinorUnits: 2, Name: "Philippine Peso"},
+	{Code: "PKR", NumericCode: "586", MinorUnits: 2, Name: "Pakistan Rupee"},
+	{Code: "PLN", NumericCode: "985", MinorUnits: 2, Name: "Zloty"},
+	{Code: "PYG", NumericCode: "600", MinorUnits: 0, Name: "Guarani"},
+	{Code: "QAR", NumericCode: "634", MinorUnits: 2, Name: "Qatari Rial"},
+	{Code: "RON", NumericCode: "946", MinorUnits: 2, Name: "Romanian Leu"},
+	{Code: "RSD", NumericCode: "941", MinorUnits: 2, Name: "Serbian Dinar"},
+	{Code: "RUB", NumericCode: "643", MinorUnits: 2, Name: "Russian Ruble"},
+	{Code: "RWF", NumericCode: "646", MinorUnits: 0, Name: "Rwanda Franc"},
+	{Code: "SAR", NumericCode: "682", MinorUnits: 2, Name: "Saudi Riyal"},
+	{Code: "SBD", NumericCode: "090", MinorUnits: 2, Name: "Solomon Islands Dollar"},
+	{Code: "SCR", NumericCode: "690", MinorUnits: 2, Name: "Seychelles Rupee"},
+	{Code: "SDG", NumericCode: "938", MinorUnits: 2, Name: "Sudanese Pound"},
+	{Code: "SEK", NumericCode: "752", MinorUnits: 2, Name: "Swedish Krona"},
+	{Code: "SGD", NumericCode: "702", MinorUnits: 2, Name: "Singapore Dollar"},
+	{Code: "SHP", NumericCode: "654", MinorUnits: 2, Name: "Saint Helena Pound"},
+	{Code: "SLE", NumericCode: "925", MinorUnits: 2, Name: "Leone"},
+	{Code: "SOS", NumericCode: "706", MinorUnits: 2, Name: "Somali Shilling"},
+	{Code: "SRD", NumericCode: "968", MinorUnits: 2, Name: "Surinam Dollar"},
+	{Code: "SSP", NumericCode: "728", MinorUnits: 2, Name: "South Sudanese Pound"},
+	{Code: "STN", NumericCode: "930", MinorUnits: 2, Name: "Dobra"},
+	{Code: "SVC", NumericCode: "222", MinorUnits: 2, Name: "El Salvador Colon"},
+	{Code: "SYP", NumericCode: "760", MinorUnits: 2, Name: "Syrian Pound"},
+	{Code: "SZL", NumericCode: "748", MinorUnits: 2, Name: "Lilangeni"},
+	{Code: "THB", NumericCode: "764", MinorUnits: 2, Name: "Baht"},
+	{Code: "TJS", NumericCode: "972", MinorUnits: 2, Name: "Somoni"},
+	{Code: "TMT", NumericCode: "934", MinorUnits: 2, Name: "Turkmenistan New Manat"},
+	{Code: "TND", NumericCode: "788", MinorUnits: 3, Name: "Tunisian Dinar"},
+	{Code: "TOP", NumericCode: "776", MinorUnits: 2, Name: "Pa'anga"},
+	{Code: "TRY", NumericCode: "949", MinorUnits: 2, Name: "Turkish Lira"},
+	{Code: "TTD", NumericCode: "780", MinorUnits: 2, Name: "Trinidad and Tobago Dollar"},
+	{Code: "TWD", NumericCode: "901", MinorUnits: 2, Name: "New Taiwan Dollar"},
+	{Code: "TZS", NumericCode: "834", MinorUnits: 2, Name: "Tanzanian Shilling"},
+	{Code: "UAH", NumericCode: "980", MinorUnits: 2, Name: "Hryvnia"},
+	{Code: "UGX", NumericCode: "800", MinorUnits: 0, Name: "Uganda Shilling"},
+	{Code: "USD", NumericCode: "840", MinorUnits: 2, Name: "US Dollar"},
+	{Code: "USN", NumericCode: "997", MinorUnits: 2, Name: "US Dollar (Next day)"},
+	{Code: "UYI", NumericCode: "940", MinorUnits: 0, Name: "Uruguay Peso en Unidades Indexadas"},
+	{Code: "UYU", NumericCode: "858", MinorUnits: 2, Name: "Peso Uruguayo"},
+	{Code: "UYW", NumericCode: "927", MinorUnits: 4, Name: "Unidad Previsional"},
+	{Code: "UZS", NumericCode: "860", MinorUnits: 2, Name: "Uzbekistan Sum"},
+	{Code: "VED", NumericCode: "926", MinorUnits: 2, Name: "Bolivar Soberano"},
+	{Code: "VES", NumericCode: "928", MinorUnits: 2, Name: "Bolivar Soberano"},
+	{Code: "VND", NumericCode: "704", MinorUnits: 0, Name: "Dong"},
+	{Code: "VUV", NumericCode: "548", MinorUnits: 0, Name: "Vatu"},
+	{Code: "WST", NumericCode: "882", MinorUnits: 2, Name: "Tala"},
+	{Code: "XAF", NumericCode: "950", MinorUnits: 0, Name: "CFA Franc BEAC"},
+	{Code: "XCD", NumericCode: "951", MinorUnits: 2, Name: "East Caribbean Dollar"},
+	{Code: "XCG", NumericCode: "532", MinorUnits: 2, Name: "Caribbean Guilder"},
+	{Code: "XOF", NumericCode: "952", MinorUnits: 0, Name: "CFA Franc BCEAO"},
+	{Code: "XPF", NumericCode: "953", MinorUnits: 0, Name: "CFP Franc"},
+	{Code: "YER", NumericCode: "886", MinorUnits: 2, Name: "Yemeni Rial"},
+	{Code: "ZAR", NumericCode: "710", MinorUnits: 2, Name: "Rand"},
+	{Code: "ZMW", NumericCode: "967", MinorUnits: 2, Name: "Zambian Kwacha"},
+	{Code: "ZWG", NumericCode: "924", MinorUnits: 2, Name: "Zimbabwe Gold"},
+}
@@ -0,0 +1,262 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const JOB_POLL_INTERVAL = 15 * time.Second // How often the scheduler looks for jobs that are due
+
+const MAX_JOB_NAME_LENGTH = 64
+
+// JobFunc does the work of a job, it gets the time the run started at.
+type JobFunc func(at time.Time) error
+
+// Job is background work the scheduler runs on its schedule, or when the staff triggers it.
+type Job struct {
+	Name      string
+	Schedule  Schedule
+	Run       JobFunc
+	NextRunAt time.Time // Filled in by the scheduler when listing the jobs
+}
+
+type JobDTO struct {
+	Name      string
+	Schedule  string
+	NextRunAt time.Time
+}
+
+type JobRunStatus string
+
+const (
+	JobRunning   JobRunStatus = "RUNNING"
+	JobSucceeded JobRunStatus = "SUCCEEDED"
+	JobFailed    JobRunStatus = "FAILED"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "SCHEDULE"
+	JobTriggerManual   JobTrigger = "MANUAL"
+)
+
+// JobRun records a single run of a job. Scheduled runs remember the time they were due at, so
+// a time of the schedule is only ever run once, however many instances of the server there are.
+type JobRun struct {
+	ID           uuid.UUID
+	Job          string
+	Trigger      JobTrigger
+	TriggeredBy  uuid.UUID // uuid.Nil for scheduled runs and runs triggered with the admin token
+	ScheduledFor time.Time // Zero for manual runs
+	Status       JobRunStatus
+	Error        string // Why the run failed, empty otherwise
+	StartedAt    time.Time
+	FinishedAt   time.Time // Zero while running
+}
+
+type JobRunDTO struct {
+	ID           uuid.UUID
+	Job          string
+	Trigger      string
+	TriggeredBy  *uuid.UUID `json:",omitempty"`
+	ScheduledFor *time.Time `json:",omitempty"`
+	Status       string
+	Error        string `json:",omitempty"`
+	StartedAt    time.Time
+	FinishedAt   *time.Time `json:",omitempty"`
+}
+
+func NewJobRun(job string, trigger JobTrigger, triggeredBy uuid.UUID, scheduledFor, at time.Time) JobRun {
+	return JobRun{
+		ID:           uuid.New(),
+		Job:          job,
+		Trigger:      trigger,
+		TriggeredBy:  triggeredBy,
+		ScheduledFor: scheduledFor,
+		Status:       JobRunning,
+		StartedAt:    at,
+	}
+}
+
+// Finish records how the run ended, a nil error means it succeeded.
+func (r *JobRun) Finish(err error, at time.Time) {
+	r.Status = JobSucceeded
+	r.FinishedAt = at
+
+	if err != nil {
+		r.Status = JobFailed
+		r.Error = err.Error()
+	}
+}
+
+// Schedule is a cron expression of minute, hour, day of month, month and day of week, all of
+// them in UTC. Fields take *, values, ranges like 1-5, lists like 1,15 and steps like */10.
+type Schedule struct {
+	Expression string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool // Restricting only one of day of month and day of week doesnt match every day of the other
+	anyWeekday bool
+}
+
+var scheduleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+func ParseSchedule(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	fields := strings.Fields(expression)
+	if alias, ok := scheduleAliases[expression]; ok {
+		fields = strings.Fields(alias)
+	}
+
+	if len(fields) != 5 {
+		return Schedule{}, errors.New("Schedule must have 5 fields: " + expression)
+	}
+
+	schedule := Schedule{Expression: expression}
+	var err error
+
+	if schedule.minutes, err = parseScheduleField(fields[0], 0, 59); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.hours, err = parseScheduleField(fields[1], 0, 23); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.days, err = parseScheduleField(fields[2], 1, 31); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.months, err = parseScheduleField(fields[3], 1, 12); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.weekdays, err = parseScheduleField(fields[4], 0, 7); err != nil {
+		return Schedule{}, err
+	}
+
+	// Both 0 and 7 are sunday
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+
+	schedule.anyDay = strings.HasPrefix(fields[2], "*")
+	schedule.anyWeekday = strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+func parseScheduleField(field string, min, max int) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		values, step, hasStep := strings.Cut(part, "/")
+
+		interval := 1
+		if hasStep {
+			var err error
+			if interval, err = strconv.Atoi(step); err != nil || interval <= 0 {
+				return 0, errors.New("Invalid schedule step: " + part)
+			}
+		}
+
+		low, high := min, max
+		if values != "*" {
+			first, last, isRange := strings.Cut(values, "-")
+
+			var err error
+			if low, err = strconv.Atoi(first); err != nil {
+				return 0, errors.New("Invalid schedule value: " + part)
+			}
+
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(last); err != nil {
+					return 0, errors.New("Invalid schedule value: " + part)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, errors.New("Schedule value out of range: " + part)
+		}
+
+		for value := low; value <= high; value += interval {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the first time after the given one the schedule is due at, zero when it never is.
+func (s Schedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hours&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<t.Weekday()) != 0
+
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func (s Schedule) IsZero() bool {
+	return s.minutes == 0
+}
+
+/* ------------------------------------------------------------ */
+func (j Job) Validate() *ValidationErrors {
+	var errors []string
+
+	if strings.TrimSpace(j.Name) == "" || len(j.Name) > MAX_JOB_NAME_LENGTH {
+		errors = append(errors, "Job name must have between 1 and 64 characters")
+	}
+
+	if j.Schedule.IsZero() {
+		errors = append(errors, "Job must have a schedule")
+	}
+
+	if j.Run == nil {
+		errors = append(errors, "Job must have something to run")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer accounts are liabilities of the bank: a credit increases
+// their balance and a debit decreases it.
+type EntrySide string
+
+const (
+	Debit  EntrySide = "DEBIT"
+	Credit EntrySide = "CREDIT"
+)
+
+type JournalKind string
+
+const (
+	JournalOpening           JournalKind = "OPENING" // Balances accounts were opened with, before deposits existed
+	JournalTransfer          JournalKind = "TRANSFER"
+	JournalInterest          JournalKind = "INTEREST"
+	JournalOverdraftInterest JournalKind = "OVERDRAFT_INTEREST"
+	JournalAdjustment        JournalKind = "ADJUSTMENT"
+	JournalReversal          JournalKind = "REVERSAL"
+	JournalDeposit           JournalKind = "DEPOSIT"
+	JournalWithdrawal        JournalKind = "WITHDRAWAL"
+)
+
+// SystemAccount is an internal account of the bank that takes the other
+// side of postings which don't move money between two customers.
+type SystemAccount string
+
+const (
+	SystemOpeningBalance  SystemAccount = "OPENING_BALANCE"
+	SystemFXPosition      SystemAccount = "FX_POSITION"
+	SystemInterestExpense SystemAccount = "INTEREST_EXPENSE"
+	SystemInterestIncome  SystemAccount = "INTEREST_INCOME"
+	SystemAdjustment      SystemAccount = "ADJUSTMENT"
+	SystemFeeIncome       SystemAccount = "FEE_INCOME"
+
+	// Money deposited or withdrawn goes through the account of its channel
+	SystemCash             SystemAccount = "CASH"
+	SystemCardClearing     SystemAccount = "CARD_CLEARING"
+	SystemExternalClearing SystemAccount = "EXTERNAL_CLEARING"
+)
+
+var channelAccounts = map[TransactionChannel]SystemAccount{
+	ChannelCash:     SystemCash,
+	ChannelCard:     SystemCardClearing,
+	ChannelExternal: SystemExternalClearing,
+}
+
+type LedgerEntry struct {
+	ID            uuid.UUID
+	JournalID     uuid.UUID
+	JournalKind   JournalKind
+	TransactionID uuid.UUID     // uuid.Nil when the journal isn't tied to a transaction
+	AccountID     uuid.UUID     // uuid.Nil for system accounts
+	SystemAccount SystemAccount // Empty for customer accounts
+	Side          EntrySide
+	Amount        Money
+	Description   string
+	CreatedAt     time.Time
+}
+
+// Journal is a group of ledger entries posted together, its debits
+// and credits must balance for every currency involved.
+type Journal struct {
+	ID            uuid.UUID
+	Kind          JournalKind
+	TransactionID uuid.UUID
+	Description   string
+	Entries       []LedgerEntry
+	CreatedAt     time.Time
+}
+
+type LedgerBalance struct {
+	AccountID uuid.UUID
+	Stored    Money // The balance on the account row
+	Derived   Money // The balance computed from the ledger entries
+}
+
+func NewJournal(kind JournalKind, transactionID uuid.UUID, description string, createdAt time.Time) Journal {
+	return Journal{
+		ID:            uuid.New(),
+		Kind:          kind,
+		TransactionID: transactionID,
+		Description:   description,
+		CreatedAt:     createdAt,
+	}
+}
+
+// Debit adds a debit of the customer account, or of the system account when accountID is nil.
+func (j *Journal) Debit(accountID uuid.UUID, system SystemAccount, amount Money) {
+	j.post(accountID, system, Debit, amount)
+}
+
+// Credit adds a credit of the customer account, or of the system account when accountID is nil.
+func (j *Journal) Credit(accountID uuid.UUID, system SystemAccount, amount Money) {
+	j.post(accountID, system, Credit, amount)
+}
+
+func (j *Journal) post(accountID uuid.UUID, system SystemAccount, side EntrySide, amount Money) {
+	// Negative amounts are posted on the other side, so every entry stays positive
+	if amount.IsNegative() {
+		amount = amount.Neg()
+		if side == Debit {
+			side = Credit
+		} else {
+			side = Debit
+		}
+	}
+
+	if amount.IsZero() {
+		return
+	}
+
+	if accountID != uuid.Nil {
+		system = ""
+	}
+
+	j.Entries = append(j.Entries, LedgerEntry{
+		ID:            uuid.New(),
+		JournalID:     j.ID,
+		JournalKind:   j.Kind,
+		TransactionID: j.TransactionID,
+		AccountID:     accountID,
+		SystemAccount: system,
+		Side:          side,
+		Amount:        amount,
+		Description:   j.Description,
+		CreatedAt:     j.CreatedAt,
+	})
+}
+
+// NewTransferJournal moves the amount from the sender to the receiver, the fee of the
+// transaction goes to the fee income of the bank. When the currencies differ, both legs
+// go through the FX position of the bank so each currency balances on its own. Deposits
+// and withdrawals take the account of their channel in place of the missing account,
+// interest is paid from the interest expense of the bank and charged to its interest income.
+func NewTransferJournal(transaction Transaction, credited Money) Journal {
+	kind, description := JournalTransfer, "Transfer "+transaction.CurrencyPair.String()
+	switch {
+	case transaction.IsReversal():
+		kind, description = JournalReversal, "Reversal "+transaction.CurrencyPair.String()
+	case transaction.Type == TransactionDeposit:
+		kind, description = JournalDeposit, "Deposit "+string(transaction.Channel)
+	case transaction.Type == TransactionWithdrawal:
+		kind, description = JournalWithdrawal, "Withdrawal "+string(transaction.Channel)
+	case transaction.Type == TransactionInterest && transaction.SenderAccountID == uuid.Nil:
+		kind, description = JournalInterest, "Interest"
+	case transaction.Type == TransactionInterest:
+		kind, description = JournalOverdraftInterest, "Overdraft interest"
+	}
+
+	journal := NewJournal(kind, transaction.ID, description, transaction.CreatedAt)
+	channel := channelAccounts[transaction.Channel]
+
+	switch kind {
+	case JournalInterest:
+		channel = SystemInterestExpense
+	case JournalOverdraftInterest:
+		channel = SystemInterestIncome
+	}
+
+	journal.Debit(transaction.SenderAccountID, channel, transaction.Amount)
+	journal.Credit(uuid.Nil, SystemFeeIncome, transaction.Fee)
+
+	if transaction.Amount.Currency == credited.Currency {
+		journal.Credit(transaction.ReceiverAccountID, channel, credited)
+		return journal
+	}
+
+	journal.Credit(uuid.Nil, SystemFXPosition, transaction.Amount.Sub(transaction.Fee))
+	journal.Debit(uuid.Nil, SystemFXPosition, credited)
+	journal.Credit(transaction.ReceiverAccountID, channel, credited)
+
+	return journal
+}
+
+// NewAdjustmentJournal books the adjustment against the adjustment account of the bank. The journal
+// takes the ID of the adjustment and its description names the reason.
+func NewAdjustmentJournal(adjustment Adjustment) Journal {
+	journal := NewJournal(JournalAdjustment, uuid.Nil, "Adjustment "+string(adjustment.Reason)+": "+adjustment.Note, adjustment.CreatedAt)
+	journal.ID = adjustment.ID
+
+	journal.Debit(uuid.Nil, SystemAdjustment, adjustment.Amount)
+	journal.Credit(adjustment.AccountID, "", adjustment.Amount)
+
+	return journal
+}
+
+// Signed returns the amount of the entry as it changed the balance of its account, debits are negative.
+func (e LedgerEntry) Signed() Money {
+	if e.Side == Debit {
+		return e.Amount.Neg()
+	}
+	return e.Amount
+}
+
+/* ------------------------------------------------------------ */
+func (j Journal) Validate() *ValidationErrors {
+	var errors []string
+
+	if j.ID == uuid.Nil {
+		errors = append(errors, "Journal ID cannot be nil")
+	}
+
+	if len(j.Entries) < 2 {
+		errors = append(errors, "Journal must have at least two entries")
+	}
+
+	totals := make(map[Currency]int64)
+
+	for _, entry := range j.Entries {
+		if entry.AccountID == uuid.Nil && entry.SystemAccount == "" {
+			errors = append(errors, "Ledger entry must belong to an account")
+		}
+
+		if entry.Amount.IsNegative() || entry.Amount.IsZero() {
+			errors = append(errors, "Ledger entry amount must be bigger than 0")
+		}
+
+		switch entry.Side {
+		case Debit:
+			totals[entry.Amount.Currency] += entry.Amount.Amount
+		case Credit:
+			totals[entry.Amount.Currency] -= entry.Amount.Amount
+		default:
+			errors = append(errors, "Invalid ledger entry side")
+		}
+	}
+
+	for currency, total := range totals {
+		if total != 0 {
+			errors = append(errors, "Journal doesnt balance in "+string(currency))
+		}
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
+
+func (b LedgerBalance) IsBalanced() bool {
+	return b.Stored.Currency == b.Derived.Currency && b.Stored.Cmp(b.Derived) == 0
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MAX_MANDATE_REFERENCE_LENGTH = 140
+
+type MandateFrequency string
+
+const (
+	MandateOnce    MandateFrequency = "ONCE" // A single collection
+	MandateWeekly  MandateFrequency = "WEEKLY"
+	MandateMonthly MandateFrequency = "MONTHLY"
+	MandateYearly  MandateFrequency = "YEARLY"
+)
+
+var MandateFrequencies = []MandateFrequency{MandateOnce, MandateWeekly, MandateMonthly, MandateYearly}
+
+type MandateStatus string
+
+const (
+	MandateActive    MandateStatus = "ACTIVE"
+	MandateCancelled MandateStatus = "CANCELLED"
+)
+
+// Mandate is the authorisation the payer gives the creditor account to collect payments from their
+// account, a direct debit. Each collection is at most the max amount and there is at most one in
+// every week, month or year of the frequency, weeks start on mondays and periods are in UTC. The
+// payer can cancel the mandate at any time and have collections refunded for a number of days.
+type Mandate struct {
+	ID                uuid.UUID
+	PayerAccountID    uuid.UUID
+	CreditorAccountID uuid.UUID
+	Reference         string // Tells the payer what the collections are for, like a subscription number
+	MaxAmount         Money  // In the currency of the payer account
+	Frequency         MandateFrequency
+	Status            MandateStatus
+	CreatedAt         time.Time
+	CancelledAt       time.Time // Zero while the mandate isnt cancelled
+}
+
+type MandateDTO struct {
+	ID                uuid.UUID
+	PayerAccountID    uuid.UUID
+	CreditorAccountID uuid.UUID
+	Reference         string `json:",omitempty"`
+	MaxAmount         json.Number
+	Currency          string
+	Frequency         string
+	Status            string
+	CreatedAt         time.Time
+	CancelledAt       *time.Time `json:",omitempty"`
+}
+
+type CreateMandateRequest struct {
+	CreditorAccountID uuid.UUID
+	Reference         string
+	MaxAmount         json.Number // In the currency of the account
+	Frequency         MandateFrequency
+	Code              string // TOTP or recovery code, required for max amounts above the step-up threshold
+}
+
+type CreateCollectionRequest struct {
+	Amount json.Number // In the currency of the payer account
+}
+
+func (m Mandate) IsActive() bool {
+	return m.Status == MandateActive
+}
+
+// IsParty reports whether the account is the payer or the creditor of the mandate.
+func (m Mandate) IsParty(accountID uuid.UUID) bool {
+	return accountID == m.PayerAccountID || accountID == m.CreditorAccountID
+}
+
+// PeriodStart returns when the week, month or year the time falls in started, zero for one-off
+// mandates whose only period never ends.
+func (m Mandate) PeriodStart(at time.Time) time.Time {
+	day := StartOfDay(at)
+
+	switch m.Frequency {
+	case MandateWeekly:
+		return weekStart(day)
+	case MandateMonthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case MandateYearly:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
+
+// Allows checks a collection of the amount against the limits of the mandate, collected is how
+// many collections were already made in the current period.
+func (m Mandate) Allows(amount Money, collected int) error {
+	if amount.Currency != m.MaxAmount.Currency {
+		return errors.New("Collection must be in the currency of the mandate " + string(m.MaxAmount.Currency))
+	}
+
+	if amount.Cmp(m.MaxAmount) > 0 {
+		return errors.New("Collection cannot be more than the mandate allows: " + m.MaxAmount.String() + " " + string(m.MaxAmount.Currency))
+	}
+
+	if collected > 0 {
+		if m.Frequency == MandateOnce {
+			return errors.New("Mandate was already collected")
+		}
+		return errors.New("Mandate was already collected this " + strings.TrimSuffix(strings.ToLower(string(m.Frequency)), "ly"))
+	}
+
+	return nil
+}
+
+// Cancel stops the mandate, the creditor cannot collect anything anymore.
+func (m *Mandate) Cancel(at time.Time) error {
+	if !m.IsActive() {
+		return errors.New("Mandate is already " + strings.ToLower(string(m.Status)))
+	}
+
+	m.Status = MandateCancelled
+	m.CancelledAt = at
+	return nil
+}
+
+/* ------------------------------------------------------------ */
+func (m Mandate) Validate() *ValidationErrors {
+	var errors []string
+
+	if m.ID == uuid.Nil || m.PayerAccountID == uuid.Nil || m.CreditorAccountID == uuid.Nil {
+		errors = append(errors, "Mandate, payer and creditor account ID cannot be nil")
+	} else if m.PayerAccountID == m.CreditorAccountID {
+		errors = append(errors, "Mandate cannot be given to its own account")
+	}
+
+	if len(m.Reference) > MAX_MANDATE_REFERENCE_LENGTH {
+		errors = append(errors, "Reference must not be longer than "+strconv.Itoa(MAX_MANDATE_REFERENCE_LENGTH)+" characters")
+	}
+
+	if m.MaxAmount.IsNegative() || m.MaxAmount.IsZero() {
+		errors = append(errors, "MaxAmount must be bigger than 0")
+	} else if m.MaxAmount.Cmp(MoneyFromMajor(MAX_TRANSFER_AMOUNT, m.MaxAmount.Currency)) > 0 {
+		errors = append(errors, "MaxAmount must not be bigger than: "+strconv.Itoa(MAX_TRANSFER_AMOUNT))
+	}
+
+	if !slices.Contains(MandateFrequencies, m.Frequency) {
+		errors = append(errors, "Unknown mandate frequency "+string(m.Frequency))
+	}
+
+	if m.CreatedAt.IsZero() {
+		errors = append(errors, "CreatedAt must be set")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
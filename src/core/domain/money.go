@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact monetary value stored in the minor units of its currency
+// (cents for USD, EUR...). All arithmetic on balances and amounts must go
+// through this type so no float rounding can leak into the books.
+type Money struct {
+	Amount   int64 // Amount in minor units
+	Currency Currency
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+// MoneyFromMajor creates money from whole units of the currency (e.g. dollars).
+func MoneyFromMajor(units int64, currency Currency) Money {
+	return NewMoney(units*pow10(currency.Exponent()), currency)
+}
+
+// ParseMoney parses a decimal string like "1000.25" into money of the given
+// currency, rounding half to even when it has more decimals than the currency allows.
+// An empty string is treated as zero.
+func ParseMoney(value string, currency Currency) (Money, error) {
+	if strings.TrimSpace(value) == "" {
+		return NewMoney(0, currency), nil
+	}
+
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, errors.New("Invalid amount format: " + value)
+	}
+
+	return ratToMoney(rat, currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(other Money) Money {
+	return NewMoney(m.Amount+other.Amount, m.Currency)
+}
+
+func (m Money) Sub(other Money) Money {
+	return NewMoney(m.Amount-other.Amount, m.Currency)
+}
+
+func (m Money) Neg() Money {
+	return NewMoney(-m.Amount, m.Currency)
+}
+
+// Cmp compares the minor units of both values, returning -1, 0 or +1.
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Mul multiplies the money by an exact factor, rounding half to even.
+func (m Money) Mul(factor *big.Rat) Money {
+	result := new(big.Rat).Mul(m.Rat(), factor)
+
+	money, _ := ratToMoney(result, m.Currency)
+	return money
+}
+
+// Convert converts the money into another currency using an exact rate,
+// rounding half to even to the minor units of the target currency.
+func (m Money) Convert(to Currency, rate *big.Rat) Money {
+	result := new(big.Rat).Mul(m.Rat(), rate)
+
+	money, _ := ratToMoney(result, to)
+	return money
+}
+
+// Rat returns the value in major units as an exact rational number.
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), big.NewInt(pow10(m.Currency.Exponent())))
+}
+
+// String formats the money as a plain decimal in major units, e.g. "1000.25".
+func (m Money) String() string {
+	exponent := m.Currency.Exponent()
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// Number returns the money as a JSON number so the API keeps exposing plain decimals.
+func (m Money) Number() json.Number {
+	return json.Number(m.String())
+}
+
+// RatFromFloat converts a float to an exact rational using its shortest decimal
+// representation, so 0.025 becomes exactly 25/1000 instead of its binary approximation.
+func RatFromFloat(value float64) *big.Rat {
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return rat
+}
+
+// MoneyFromRat rounds an exact value in major units half to even to the minor units of the currency.
+func MoneyFromRat(rat *big.Rat, currency Currency) (Money, error) {
+	return ratToMoney(rat, currency)
+}
+
+func ratToMoney(rat *big.Rat, currency Currency) (Money, error) {
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt64(pow10(currency.Exponent())))
+	rounded := roundHalfEven(scaled)
+
+	if !rounded.IsInt64() {
+		return Money{}, errors.New("Amount is out of range")
+	}
+
+	return NewMoney(rounded.Int64(), currency), nil
+}
+
+func roundHalfEven(rat *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(rat.Num(), rat.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Compare twice the remainder with the denominator to find out which side of the half we are on
+	doubled := new(big.Int).Abs(remainder)
+	doubled.Lsh(doubled, 1)
+
+	cmp := doubled.Cmp(rat.Denom())
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		if rat.Sign() < 0 {
+			return quotient.Sub(quotient, big.NewInt(1))
+		}
+		return quotient.Add(quotient, big.NewInt(1))
+	}
+
+	return quotient
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
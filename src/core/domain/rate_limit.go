@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RATE_LIMIT_SWEEP_INTERVAL = time.Minute // How often stores forget the buckets that filled up again
+
+// RateLimit is a token bucket holding Burst requests that refills completely within Period.
+// A zero RateLimit doesnt limit anything.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// RateLimitPolicy holds separate limits for reading and for writing requests, they apply to
+// every customer and every client IP on their own.
+type RateLimitPolicy struct {
+	Read  RateLimit
+	Write RateLimit
+}
+
+// RateLimitBucket is what is left of the limit of a single key.
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+	FullAt    time.Time // From then on the bucket is as good as new and can be forgotten
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request is allowed, zero when this one was
+}
+
+// ParseRateLimit parses a limit like "60/1m", meaning 60 requests a minute. An empty value or
+// "0" turns the limit off.
+func ParseRateLimit(value string) (RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return RateLimit{}, nil
+	}
+
+	burst, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, errors.New("Invalid rate limit format: " + value)
+	}
+
+	limit := RateLimit{}
+	var err error
+
+	if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst <= 0 {
+		return RateLimit{}, errors.New("Invalid rate limit burst: " + burst)
+	}
+
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return RateLimit{}, errors.New("Invalid rate limit period: " + period)
+	}
+
+	return limit, nil
+}
+
+func (l RateLimit) IsZero() bool {
+	return l.Burst == 0
+}
+
+// Take spends a token of the bucket for a request made at the given time. Buckets start out full
+// and get Burst tokens back every Period, so the bucket of a new key is just a zero bucket.
+func (l RateLimit) Take(bucket RateLimitBucket, at time.Time) (RateLimitBucket, RateLimitResult) {
+	perSecond := float64(l.Burst) / l.Period.Seconds()
+
+	tokens := float64(l.Burst)
+	if !bucket.UpdatedAt.IsZero() {
+		elapsed := math.Max(at.Sub(bucket.UpdatedAt).Seconds(), 0)
+		tokens = math.Min(bucket.Tokens+elapsed*perSecond, float64(l.Burst))
+	}
+
+	result := RateLimitResult{Limit: l.Burst}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / perSecond)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((float64(l.Burst) - tokens) / perSecond)
+
+	bucket.Tokens = tokens
+	bucket.UpdatedAt = at
+	bucket.FullAt = at.Add(result.Reset)
+
+	return bucket, result
+}
+
+// Stricter reports whether the result leaves less room than the other one.
+func (r RateLimitResult) Stricter(other RateLimitResult) bool {
+	if r.Allowed != other.Allowed {
+		return !r.Allowed
+	}
+	if !r.Allowed {
+		return r.RetryAfter > other.RetryAfter
+	}
+
+	return r.Remaining < other.Remaining
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Ceil(value * float64(time.Second)))
+}
@@ -0,0 +1,272 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MAX_RECURRENCE_YEARS = 10 // How far ahead a rule is searched for its next day before it counts as never due
+
+type RecurrenceFrequency string
+
+const (
+	RecurDaily   RecurrenceFrequency = "DAILY"
+	RecurWeekly  RecurrenceFrequency = "WEEKLY"
+	RecurMonthly RecurrenceFrequency = "MONTHLY"
+	RecurYearly  RecurrenceFrequency = "YEARLY"
+)
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Recurrence is the part of an RFC 5545 RRULE that makes sense for days, like
+// FREQ=MONTHLY;BYDAY=-1FR for the last friday of every month. It takes FREQ, INTERVAL, BYDAY,
+// BYMONTHDAY, BYMONTH, COUNT and UNTIL, and it starts on the day it is given, like a DTSTART.
+// Without BYDAY or BYMONTHDAY, monthly and yearly rules starting on the 29th to the 31st fall on
+// the last day of shorter months instead of skipping them, the way banks run standing orders.
+type Recurrence struct {
+	Rule      string
+	frequency RecurrenceFrequency
+	interval  int
+	weekdays  []recurrenceWeekday
+	monthDays []int // Negative days count from the end of the month
+	months    []time.Month
+	count     int       // Zero when there is no limit
+	until     time.Time // Zero when there is no limit
+}
+
+// recurrenceWeekday is a day of BYDAY, the nth one of the month when nth isnt zero.
+type recurrenceWeekday struct {
+	nth     int
+	weekday time.Weekday
+}
+
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	recurrence := Recurrence{Rule: rule, interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Recurrence{}, errors.New("Invalid recurrence rule part: " + part)
+		}
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			recurrence.frequency = RecurrenceFrequency(value)
+			if !slices.Contains([]RecurrenceFrequency{RecurDaily, RecurWeekly, RecurMonthly, RecurYearly}, recurrence.frequency) {
+				return Recurrence{}, errors.New("Unsupported recurrence frequency: " + value)
+			}
+		case "INTERVAL":
+			if recurrence.interval, err = strconv.Atoi(value); err != nil || recurrence.interval <= 0 {
+				return Recurrence{}, errors.New("Invalid recurrence interval: " + value)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := recurrenceWeekdays[day[max(len(day)-2, 0):]]
+				if !ok {
+					return Recurrence{}, errors.New("Invalid recurrence weekday: " + day)
+				}
+
+				nth := 0
+				if len(day) > 2 {
+					if nth, err = strconv.Atoi(day[:len(day)-2]); err != nil || nth == 0 || nth < -5 || nth > 5 {
+						return Recurrence{}, errors.New("Invalid recurrence weekday: " + day)
+					}
+				}
+
+				recurrence.weekdays = append(recurrence.weekdays, recurrenceWeekday{nth: nth, weekday: weekday})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return Recurrence{}, errors.New("Invalid recurrence day of month: " + day)
+				}
+
+				recurrence.monthDays = append(recurrence.monthDays, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(value, ",") {
+				number, err := strconv.Atoi(month)
+				if err != nil || number < 1 || number > 12 {
+					return Recurrence{}, errors.New("Invalid recurrence month: " + month)
+				}
+
+				recurrence.months = append(recurrence.months, time.Month(number))
+			}
+		case "COUNT":
+			if recurrence.count, err = strconv.Atoi(value); err != nil || recurrence.count <= 0 {
+				return Recurrence{}, errors.New("Invalid recurrence count: " + value)
+			}
+		case "UNTIL":
+			if recurrence.until, err = parseRecurrenceUntil(value); err != nil {
+				return Recurrence{}, errors.New("Invalid recurrence end: " + value)
+			}
+		default:
+			return Recurrence{}, errors.New("Unsupported recurrence rule part: " + name)
+		}
+	}
+
+	if recurrence.frequency == "" {
+		return Recurrence{}, errors.New("Recurrence rule must have a FREQ")
+	}
+
+	if recurrence.count > 0 && !recurrence.until.IsZero() {
+		return Recurrence{}, errors.New("Recurrence rule cannot have both COUNT and UNTIL")
+	}
+
+	if recurrence.frequency == RecurWeekly && len(recurrence.monthDays) > 0 {
+		return Recurrence{}, errors.New("Weekly recurrence rule cannot have BYMONTHDAY")
+	}
+
+	for _, day := range recurrence.weekdays {
+		if day.nth != 0 && recurrence.frequency != RecurMonthly {
+			return Recurrence{}, errors.New("Only monthly recurrence rules can have numbered weekdays")
+		}
+	}
+
+	return recurrence, nil
+}
+
+func parseRecurrenceUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return StartOfDay(until), nil
+	}
+
+	return time.Parse("20060102", value)
+}
+
+// Next returns the first day the recurrence starting on start falls on after the given day, zero
+// when it never does again. Both days are taken in UTC.
+func (r Recurrence) Next(start, after time.Time) time.Time {
+	start, after = StartOfDay(start), StartOfDay(after)
+
+	day := start
+	if r.count == 0 && after.After(start) {
+		day = after.AddDate(0, 0, 1)
+	}
+
+	limit := after.AddDate(MAX_RECURRENCE_YEARS, 0, 0)
+	if !r.until.IsZero() && r.until.Before(limit) {
+		limit = r.until
+	}
+
+	// With a COUNT every earlier day has to be counted, so the search starts at the start
+	occurrences := 0
+	for ; !day.After(limit); day = day.AddDate(0, 0, 1) {
+		if !r.matches(start, day) {
+			continue
+		}
+
+		occurrences++
+		if r.count > 0 && occurrences > r.count {
+			break
+		}
+
+		if day.After(after) {
+			return day
+		}
+	}
+
+	return time.Time{}
+}
+
+// matches reports whether the recurrence starting on start falls on the day.
+func (r Recurrence) matches(start, day time.Time) bool {
+	if day.Before(start) {
+		return false
+	}
+
+	switch r.frequency {
+	case RecurDaily:
+		if int(day.Sub(start).Hours()/24)%r.interval != 0 {
+			return false
+		}
+	case RecurWeekly:
+		if int(weekStart(day).Sub(weekStart(start)).Hours()/24/7)%r.interval != 0 {
+			return false
+		}
+	case RecurMonthly:
+		if monthsBetween(start, day)%r.interval != 0 {
+			return false
+		}
+	case RecurYearly:
+		if (day.Year()-start.Year())%r.interval != 0 {
+			return false
+		}
+	}
+
+	if len(r.months) > 0 {
+		if !slices.Contains(r.months, day.Month()) {
+			return false
+		}
+	} else if r.frequency == RecurYearly && day.Month() != start.Month() {
+		return false
+	}
+
+	if len(r.monthDays) > 0 && !slices.ContainsFunc(r.monthDays, func(monthDay int) bool { return isMonthDay(day, monthDay) }) {
+		return false
+	}
+
+	if len(r.weekdays) > 0 {
+		return slices.ContainsFunc(r.weekdays, func(weekday recurrenceWeekday) bool { return weekday.matches(day) })
+	}
+
+	if len(r.monthDays) > 0 {
+		return true
+	}
+
+	// Without BYDAY or BYMONTHDAY the rule falls on the day of the week or month it started on
+	switch r.frequency {
+	case RecurWeekly:
+		return day.Weekday() == start.Weekday()
+	case RecurMonthly, RecurYearly:
+		return day.Day() == min(start.Day(), daysIn(day))
+	default:
+		return true
+	}
+}
+
+func (w recurrenceWeekday) matches(day time.Time) bool {
+	if day.Weekday() != w.weekday {
+		return false
+	}
+
+	switch {
+	case w.nth > 0:
+		return (day.Day()-1)/7+1 == w.nth
+	case w.nth < 0:
+		return (daysIn(day)-day.Day())/7+1 == -w.nth
+	default:
+		return true
+	}
+}
+
+func isMonthDay(day time.Time, monthDay int) bool {
+	if monthDay < 0 {
+		monthDay = daysIn(day) + monthDay + 1
+	}
+	return day.Day() == monthDay
+}
+
+// daysIn returns how many days the month of the day has.
+func daysIn(day time.Time) int {
+	return time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// weekStart returns the monday starting the week of the day, weeks start on mondays like the RRULE default.
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func monthsBetween(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+}
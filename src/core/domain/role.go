@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Role of a customer of the bank, staff members are customers with one of the staff roles.
+type Role string
+
+const (
+	ROLE_CUSTOMER Role = "customer"
+	ROLE_TELLER   Role = "teller"
+	ROLE_AUDITOR  Role = "auditor"
+	ROLE_ADMIN    Role = "admin"
+)
+
+// Permission lets a role act on the resources of every customer, not only its own.
+type Permission string
+
+const (
+	PERMISSION_READ_CUSTOMERS       Permission = "customers:read"
+	PERMISSION_READ_ACCOUNTS        Permission = "accounts:read"
+	PERMISSION_READ_TRANSACTIONS    Permission = "transactions:read"
+	PERMISSION_REVERSE_TRANSACTIONS Permission = "transactions:reverse"
+	PERMISSION_MANAGE_ACCOUNTS      Permission = "accounts:manage"
+	PERMISSION_MANAGE_DEPOSITS      Permission = "deposits:manage"
+	PERMISSION_ADJUST_BALANCES      Permission = "balances:adjust"
+	PERMISSION_MANAGE_OVERDRAFTS    Permission = "overdrafts:manage"
+	PERMISSION_MANAGE_CURRENCIES    Permission = "currencies:manage"
+	PERMISSION_MANAGE_ROLES         Permission = "roles:manage"
+	PERMISSION_MANAGE_JOBS          Permission = "jobs:manage"
+)
+
+// RolePermissions lists what each role may do, customers only ever get to their own resources.
+var RolePermissions = map[Role][]Permission{
+	ROLE_CUSTOMER: {},
+	ROLE_TELLER: {
+		PERMISSION_READ_CUSTOMERS,
+		PERMISSION_READ_ACCOUNTS,
+		PERMISSION_READ_TRANSACTIONS,
+		PERMISSION_REVERSE_TRANSACTIONS,
+		PERMISSION_MANAGE_ACCOUNTS,
+		PERMISSION_MANAGE_DEPOSITS,
+	},
+	ROLE_AUDITOR: {
+		PERMISSION_READ_CUSTOMERS,
+		PERMISSION_READ_ACCOUNTS,
+		PERMISSION_READ_TRANSACTIONS,
+	},
+	ROLE_ADMIN: {
+		PERMISSION_READ_CUSTOMERS,
+		PERMISSION_READ_ACCOUNTS,
+		PERMISSION_READ_TRANSACTIONS,
+		PERMISSION_REVERSE_TRANSACTIONS,
+		PERMISSION_MANAGE_ACCOUNTS,
+		PERMISSION_MANAGE_DEPOSITS,
+		PERMISSION_ADJUST_BALANCES,
+		PERMISSION_MANAGE_OVERDRAFTS,
+		PERMISSION_MANAGE_CURRENCIES,
+		PERMISSION_MANAGE_ROLES,
+		PERMISSION_MANAGE_JOBS,
+	},
+}
+
+type UpdateRoleRequest struct {
+	Role Role
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if !role.IsValid() {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+
+	return role, nil
+}
+
+func (r Role) IsValid() bool {
+	_, ok := RolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(RolePermissions[r], permission)
+}
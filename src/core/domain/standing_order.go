@@ -0,0 +1,284 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StandingOrderFrequency string
+
+const (
+	StandingOrderDaily   StandingOrderFrequency = "DAILY"
+	StandingOrderWeekly  StandingOrderFrequency = "WEEKLY"
+	StandingOrderMonthly StandingOrderFrequency = "MONTHLY"
+	StandingOrderCustom  StandingOrderFrequency = "CUSTOM" // Follows the RRULE of the order
+)
+
+var StandingOrderFrequencies = []StandingOrderFrequency{StandingOrderDaily, StandingOrderWeekly, StandingOrderMonthly, StandingOrderCustom}
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "ACTIVE"
+	StandingOrderCompleted StandingOrderStatus = "COMPLETED" // Its last day has passed
+	StandingOrderCancelled StandingOrderStatus = "CANCELLED"
+)
+
+// StandingOrder transfers the same amount from an account on the days of its recurrence, through
+// the same checks as any other transfer. A transfer the account cannot cover is retried as the
+// retry policy allows, other failures move on to the next day.
+type StandingOrder struct {
+	ID                uuid.UUID
+	AccountID         uuid.UUID // Pays the transfers
+	ReceiverAccountID uuid.UUID
+	Amount            Money // In the currency of the account
+	Frequency         StandingOrderFrequency
+	Recurrence        Recurrence
+	StartDate         time.Time
+	EndDate           time.Time // Zero when it runs until it is cancelled
+	NextDueDate       time.Time // The day of the next transfer, zero once completed or cancelled
+	NextAttemptAt     time.Time // When the next transfer is attempted, later than its day while retrying
+	Attempts          int       // Failed attempts of the next transfer
+	Status            StandingOrderStatus
+	CreatedAt         time.Time
+	CancelledAt       time.Time // Zero while the order isnt cancelled
+}
+
+type StandingOrderDTO struct {
+	ID                uuid.UUID
+	AccountID         uuid.UUID
+	ReceiverAccountID uuid.UUID
+	Amount            json.Number
+	Currency          string
+	Frequency         string
+	Rule              string `json:",omitempty"`
+	StartDate         string
+	EndDate           string     `json:",omitempty"`
+	NextDueDate       string     `json:",omitempty"`
+	NextAttemptAt     *time.Time `json:",omitempty"`
+	Attempts          int
+	Status            string
+	CreatedAt         time.Time
+	CancelledAt       *time.Time `json:",omitempty"`
+}
+
+type CreateStandingOrderRequest struct {
+	ReceiverAccountID uuid.UUID
+	Amount            json.Number // In the currency of the account
+	Frequency         StandingOrderFrequency
+	Rule              string    // RRULE like FREQ=MONTHLY;BYMONTHDAY=1, only for the CUSTOM frequency
+	StartDate         time.Time // The first day it can be due on, today or later
+	EndDate           time.Time // Optional, the last day it can be due on
+	Code              string    // TOTP or recovery code, required for amounts above the step-up threshold
+}
+
+type StandingOrderExecutionStatus string
+
+const (
+	ExecutionPending   StandingOrderExecutionStatus = "PENDING"
+	ExecutionSucceeded StandingOrderExecutionStatus = "SUCCEEDED"
+	ExecutionRetrying  StandingOrderExecutionStatus = "RETRYING" // Failed for lack of funds, it will be attempted again
+	ExecutionFailed    StandingOrderExecutionStatus = "FAILED"
+)
+
+// StandingOrderExecution records an attempt of a standing order to make the transfer of a day. The
+// ID of the transfer is picked before it is made, so an attempt cut off by the server going down
+// can be resolved by looking the transfer up.
+type StandingOrderExecution struct {
+	ID              uuid.UUID
+	StandingOrderID uuid.UUID
+	DueDate         time.Time
+	Attempt         int
+	TransactionID   uuid.UUID
+	Status          StandingOrderExecutionStatus
+	Error           string // Why the attempt failed, empty otherwise
+	CreatedAt       time.Time
+	FinishedAt      time.Time // Zero while pending
+}
+
+type StandingOrderExecutionDTO struct {
+	ID            uuid.UUID
+	DueDate       string
+	Attempt       int
+	TransactionID uuid.UUID
+	Status        string
+	Error         string `json:",omitempty"`
+	CreatedAt     time.Time
+	FinishedAt    *time.Time `json:",omitempty"`
+}
+
+// RetryPolicy decides how often and how far apart standing orders retry transfers the account
+// couldnt cover. A retry is never made once the next transfer of the order is due.
+type RetryPolicy struct {
+	Retries  int
+	Interval time.Duration
+}
+
+// ParseRetryPolicy parses a policy like "3/24h", meaning 3 retries a day apart. "0" turns retries off.
+func ParseRetryPolicy(value string) (RetryPolicy, error) {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return RetryPolicy{}, nil
+	}
+
+	retries, interval, ok := strings.Cut(value, "/")
+	if !ok {
+		return RetryPolicy{}, errors.New("Invalid retry policy format: " + value)
+	}
+
+	policy := RetryPolicy{}
+	var err error
+
+	if policy.Retries, err = strconv.Atoi(retries); err != nil || policy.Retries < 0 {
+		return RetryPolicy{}, errors.New("Invalid retry policy retries: " + retries)
+	}
+
+	if policy.Interval, err = time.ParseDuration(interval); err != nil || policy.Interval <= 0 {
+		return RetryPolicy{}, errors.New("Invalid retry policy interval: " + interval)
+	}
+
+	return policy, nil
+}
+
+// RecurrenceRule returns the RRULE a frequency stands for, the rule itself for the CUSTOM one.
+func RecurrenceRule(frequency StandingOrderFrequency, rule string) (string, error) {
+	if frequency != StandingOrderCustom && rule != "" {
+		return "", errors.New("Only custom standing orders can have a rule")
+	}
+
+	switch frequency {
+	case StandingOrderDaily, StandingOrderWeekly, StandingOrderMonthly:
+		return "FREQ=" + string(frequency), nil
+	case StandingOrderCustom:
+		if rule == "" {
+			return "", errors.New("Custom standing orders need a rule")
+		}
+		return rule, nil
+	default:
+		return "", errors.New("Unknown standing order frequency " + string(frequency))
+	}
+}
+
+func (o StandingOrder) IsActive() bool {
+	return o.Status == StandingOrderActive
+}
+
+// IsDue reports whether the next transfer of the order should be attempted at the time.
+func (o StandingOrder) IsDue(at time.Time) bool {
+	return o.IsActive() && !o.NextAttemptAt.IsZero() && !at.Before(o.NextAttemptAt)
+}
+
+// NextDay returns the first day the order falls on after the given one, zero when it never does again.
+func (o StandingOrder) NextDay(after time.Time) time.Time {
+	next := o.Recurrence.Next(o.StartDate, after)
+	if next.IsZero() || (!o.EndDate.IsZero() && next.After(o.EndDate)) {
+		return time.Time{}
+	}
+	return next
+}
+
+// Advance moves the order on to its next day, completing it when there is none.
+func (o *StandingOrder) Advance() {
+	o.Attempts = 0
+	o.NextDueDate = o.NextDay(o.NextDueDate)
+	o.NextAttemptAt = o.NextDueDate
+
+	if o.NextDueDate.IsZero() {
+		o.Status = StandingOrderCompleted
+	}
+}
+
+// Retry schedules another attempt of the transfer of the day when the policy allows it, otherwise
+// it reports false and leaves the order as it is.
+func (o *StandingOrder) Retry(policy RetryPolicy, at time.Time) bool {
+	if o.Attempts+1 > policy.Retries {
+		return false
+	}
+
+	retryAt := at.Add(policy.Interval)
+	if next := o.NextDay(o.NextDueDate); !next.IsZero() && !retryAt.Before(next) {
+		return false
+	}
+
+	o.Attempts++
+	o.NextAttemptAt = retryAt
+	return true
+}
+
+// Cancel stops the order, it makes no more transfers.
+func (o *StandingOrder) Cancel(at time.Time) error {
+	if !o.IsActive() {
+		return errors.New("Standing order is already " + strings.ToLower(string(o.Status)))
+	}
+
+	o.Status = StandingOrderCancelled
+	o.CancelledAt = at
+	o.NextDueDate = time.Time{}
+	o.NextAttemptAt = time.Time{}
+	return nil
+}
+
+func NewStandingOrderExecution(order StandingOrder, at time.Time) StandingOrderExecution {
+	return StandingOrderExecution{
+		ID:              uuid.New(),
+		StandingOrderID: order.ID,
+		DueDate:         order.NextDueDate,
+		Attempt:         order.Attempts + 1,
+		TransactionID:   uuid.New(),
+		Status:          ExecutionPending,
+		CreatedAt:       at,
+	}
+}
+
+// Finish records how the attempt ended and, when it failed, why.
+func (e *StandingOrderExecution) Finish(status StandingOrderExecutionStatus, err error, at time.Time) {
+	e.Status = status
+	e.FinishedAt = at
+
+	if err != nil {
+		e.Error = err.Error()
+	}
+}
+
+/* ------------------------------------------------------------ */
+func (o StandingOrder) Validate() *ValidationErrors {
+	var errors []string
+
+	if o.ID == uuid.Nil || o.AccountID == uuid.Nil || o.ReceiverAccountID == uuid.Nil {
+		errors = append(errors, "Standing order, account and receiver account ID cannot be nil")
+	} else if o.AccountID == o.ReceiverAccountID {
+		errors = append(errors, "Standing order cannot pay into its own account")
+	}
+
+	if o.Amount.IsNegative() || o.Amount.IsZero() {
+		errors = append(errors, "Amount must be bigger than 0")
+	} else if o.Amount.Cmp(MoneyFromMajor(MAX_TRANSFER_AMOUNT, o.Amount.Currency)) > 0 {
+		errors = append(errors, "Amount must not be bigger than: "+strconv.Itoa(MAX_TRANSFER_AMOUNT))
+	}
+
+	if !slices.Contains(StandingOrderFrequencies, o.Frequency) {
+		errors = append(errors, "Unknown standing order frequency "+string(o.Frequency))
+	}
+
+	if o.StartDate.IsZero() {
+		errors = append(errors, "StartDate is required")
+	} else if !o.EndDate.IsZero() && o.EndDate.Before(o.StartDate) {
+		errors = append(errors, "EndDate cannot be before StartDate")
+	}
+
+	if o.CreatedAt.IsZero() {
+		errors = append(errors, "CreatedAt must be set")
+	}
+
+	if len(errors) > 0 {
+		return &ValidationErrors{Errors: errors}
+	}
+
+	return nil
+}
@@ -1,7 +1,12 @@
 package domain
 
 import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,59 +14,350 @@ import (
 
 const MAX_TRANSFER_AMOUNT = 10000;
 
+// ErrInsufficientFunds is why a transfer is rejected when the sender cannot cover it, standing
+// orders retry these transfers.
+var ErrInsufficientFunds = errors.New("Sender account doesnt have enough balance")
+
+type TransactionStatus string
+
+const (
+	TransactionPending  TransactionStatus = "PENDING"
+	TransactionPosted   TransactionStatus = "POSTED"
+	TransactionFailed   TransactionStatus = "FAILED"
+	TransactionReversed TransactionStatus = "REVERSED"
+)
+
+// TransactionType tells transfers between two accounts apart from money entering or leaving the bank.
+type TransactionType string
+
+const (
+	TransactionTransfer   TransactionType = "TRANSFER"
+	TransactionDeposit    TransactionType = "DEPOSIT"
+	TransactionWithdrawal TransactionType = "WITHDRAWAL"
+	TransactionInterest   TransactionType = "INTEREST" // Accrued interest posted at the end of the month
+)
+
+// TransactionChannel is the way the money of a deposit or withdrawal enters or leaves the bank.
+type TransactionChannel string
+
+const (
+	ChannelCash     TransactionChannel = "CASH"
+	ChannelCard     TransactionChannel = "CARD"
+	ChannelExternal TransactionChannel = "EXTERNAL"
+)
+
+var TransactionChannels = []TransactionChannel{ChannelCash, ChannelCard, ChannelExternal}
+
+// transactionTransitions lists the statuses a transaction can move to from each status.
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionPending: {TransactionPosted, TransactionFailed},
+	TransactionPosted:  {TransactionReversed},
+}
+
 type Transaction struct {
 	ID	uuid.UUID
-	SenderAccountID uuid.UUID
-	ReceiverAccountID uuid.UUID
-	Amount float64
+	Type TransactionType
+	Channel TransactionChannel // Empty for transfers and interest
+	SenderAccountID uuid.UUID // uuid.Nil for deposits and paid interest
+	ReceiverAccountID uuid.UUID // uuid.Nil for withdrawals and charged interest
+	Amount Money // Amount in the sender currency
 	CurrencyPair CurrencyPair
+	ExchangeRate *big.Rat // The rate the amount was converted with, nil for transactions made before rates were recorded
+	Fee Money // Conversion fee in the sender currency, included in the amount
+	QuoteID uuid.UUID // The FX quote the transaction was priced with, uuid.Nil when priced on the spot
+	Status TransactionStatus
+	FailureReason string // Why the transaction failed, empty otherwise
+	ReversalOf uuid.UUID // The transaction this one compensates, uuid.Nil for regular transfers
+	Reversals []uuid.UUID // The transactions compensating this one
+	Refunded Money // How much of the credited amount was already reversed, in the receiver currency
+	MandateID uuid.UUID // The mandate the creditor collected the transfer under, uuid.Nil for regular transfers
 	CreatedAt time.Time
 }
 
 type TransactionDTO struct {
 	ID	uuid.UUID
-	SenderAccountID uuid.UUID
-	ReceiverAccountID uuid.UUID
-	Amount float64
+	Type string
+	Channel string `json:",omitempty"`
+	SenderAccountID *uuid.UUID `json:",omitempty"`
+	ReceiverAccountID *uuid.UUID `json:",omitempty"`
+	Amount json.Number
 	CurrencyPair string
+	ExchangeRate json.Number `json:",omitempty"`
+	Fee json.Number
+	QuoteID *uuid.UUID `json:",omitempty"`
+	Status string
+	FailureReason string `json:",omitempty"`
+	ReversalOf *uuid.UUID `json:",omitempty"`
+	Reversals []uuid.UUID `json:",omitempty"`
+	Refunded json.Number `json:",omitempty"`
+	MandateID *uuid.UUID `json:",omitempty"`
 	CreatedAt time.Time
 }
 
 type CreateTransactionRequest struct {
 	SenderAccountID uuid.UUID
 	ReceiverAccountID uuid.UUID
-	Amount float64
+	Amount json.Number
 	Currency string // The sender preferred currency
+	QuoteID uuid.UUID // Optional FX quote locking the rate of the transfer
+	Code string // TOTP or recovery code, required for transfers above the step-up threshold
+	ID uuid.UUID `json:"-"` // Picked by standing orders, so an interrupted attempt can look up what became of the transfer
+	StandingOrderID uuid.UUID `json:"-"` // Set when a standing order makes the transfer, its second factor was checked when it was set up
+	MandateID uuid.UUID `json:"-"` // Set when a creditor collects the transfer, it is checked against the limits of the mandate
+}
+
+// CreateDepositRequest and CreateWithdrawalRequest move money in and out of an account in its own currency.
+type CreateDepositRequest struct {
+	Amount json.Number
+	Channel TransactionChannel
+}
+
+type CreateWithdrawalRequest struct {
+	Amount json.Number
+	Channel TransactionChannel
+	Code string // TOTP or recovery code, required for withdrawals above the step-up threshold
+}
+
+type CreateReversalRequest struct {
+	Amount json.Number // Amount in the receiver currency, the whole remaining amount when empty
+}
+
+func ParseTransactionStatus(status string) (TransactionStatus, error) {
+	parsed := TransactionStatus(strings.ToUpper(status))
+
+	switch parsed {
+	case TransactionPending, TransactionPosted, TransactionFailed, TransactionReversed:
+		return parsed, nil
+	}
+
+	return "", errors.New("Unknown transaction status: " + status)
+}
+
+func ParseTransactionChannel(channel string) (TransactionChannel, error) {
+	parsed := TransactionChannel(strings.ToUpper(strings.TrimSpace(channel)))
+
+	if slices.Contains(TransactionChannels, parsed) {
+		return parsed, nil
+	}
+
+	return "", errors.New("Unknown transaction channel: " + channel)
+}
+
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	return slices.Contains(transactionTransitions[s], next)
+}
+
+// TransitionTo moves the transaction to the next status, rejecting transitions the lifecycle doesnt allow.
+func (t *Transaction) TransitionTo(next TransactionStatus) error {
+	if !t.Status.CanTransitionTo(next) {
+		return errors.New("Transaction cannot go from " + string(t.Status) + " to " + string(next))
+	}
+
+	t.Status = next
+	return nil
+}
+
+// Rate returns the rate the amount was converted with, transactions made before
+// rates were recorded are only known to have one when no conversion took place.
+func (t Transaction) Rate() (*big.Rat, bool) {
+	if t.ExchangeRate != nil {
+		return t.ExchangeRate, true
+	}
+
+	return big.NewRat(1, 1), t.CurrencyPair.From == t.CurrencyPair.To
+}
+
+// Credited returns the amount the receiver got, in the receiver currency.
+func (t Transaction) Credited() Money {
+	rate, _ := t.Rate()
+	return t.Amount.Sub(t.Fee).Convert(t.CurrencyPair.To, rate)
+}
+
+// Refundable returns how much of the credited amount can still be reversed.
+func (t Transaction) Refundable() Money {
+	credited := t.Credited()
+	return credited.Sub(NewMoney(t.Refunded.Amount, credited.Currency))
+}
+
+func (t Transaction) IsReversal() bool {
+	return t.ReversalOf != uuid.Nil
+}
+
+// AccountIDs returns the accounts of the bank taking part in the transaction, one for deposits and withdrawals.
+func (t Transaction) AccountIDs() []uuid.UUID {
+	var ids []uuid.UUID
+
+	for _, id := range []uuid.UUID{t.SenderAccountID, t.ReceiverAccountID} {
+		if id != uuid.Nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// NewReversal creates the transaction sending the amount, in the receiver currency, back to the
+// sender. It is converted with the exact inverse of the rate of the original transaction.
+func NewReversal(original Transaction, amount Money, at time.Time) Transaction {
+	rate, _ := original.Rate()
+	inverse := new(big.Rat).Inv(rate)
+
+	return Transaction{
+		ID:                uuid.New(),
+		Type:              TransactionTransfer,
+		SenderAccountID:   original.ReceiverAccountID,
+		ReceiverAccountID: original.SenderAccountID,
+		Amount:            amount,
+		CurrencyPair:      NewCurrencyPair(original.CurrencyPair.To, original.CurrencyPair.From),
+		ExchangeRate:      inverse,
+		Fee:               NewMoney(0, amount.Currency),
+		Status:            TransactionPending,
+		ReversalOf:        original.ID,
+		Refunded:          NewMoney(0, original.CurrencyPair.From),
+		CreatedAt:         at,
+	}
+}
+
+// NewSweep creates the transaction moving the whole balance of an account that is being closed to the target account.
+func NewSweep(account, target Account, at time.Time) Transaction {
+	return Transaction{
+		ID:                uuid.New(),
+		Type:              TransactionTransfer,
+		SenderAccountID:   account.ID,
+		ReceiverAccountID: target.ID,
+		Amount:            account.Balance,
+		CurrencyPair:      NewCurrencyPair(account.Currency, target.Currency),
+		Fee:               NewMoney(0, account.Currency),
+		Status:            TransactionPending,
+		CreatedAt:         at,
+	}
+}
+
+// NewDeposit creates the transaction crediting money coming from outside the bank to the account.
+func NewDeposit(account Account, amount Money, channel TransactionChannel, at time.Time) Transaction {
+	return Transaction{
+		ID:                uuid.New(),
+		Type:              TransactionDeposit,
+		Channel:           channel,
+		ReceiverAccountID: account.ID,
+		Amount:            amount,
+		CurrencyPair:      NewCurrencyPair(account.Currency, account.Currency),
+		Fee:               NewMoney(0, account.Currency),
+		Status:            TransactionPending,
+		CreatedAt:         at,
+	}
+}
+
+// NewWithdrawal creates the transaction debiting money leaving the bank from the account.
+func NewWithdrawal(account Account, amount Money, channel TransactionChannel, at time.Time) Transaction {
+	return Transaction{
+		ID:              uuid.New(),
+		Type:            TransactionWithdrawal,
+		Channel:         channel,
+		SenderAccountID: account.ID,
+		Amount:          amount,
+		CurrencyPair:    NewCurrencyPair(account.Currency, account.Currency),
+		Fee:             NewMoney(0, account.Currency),
+		Status:          TransactionPending,
+		CreatedAt:       at,
+	}
+}
+
+// NewInterest creates the transaction posting accrued interest, positive interest is paid to the
+// account and negative interest is charged from it.
+func NewInterest(account Account, interest Money, at time.Time) Transaction {
+	transaction := Transaction{
+		ID:           uuid.New(),
+		Type:         TransactionInterest,
+		Amount:       interest,
+		CurrencyPair: NewCurrencyPair(account.Currency, account.Currency),
+		Fee:          NewMoney(0, account.Currency),
+		Status:       TransactionPending,
+		CreatedAt:    at,
+	}
+
+	if interest.IsNegative() {
+		transaction.SenderAccountID = account.ID
+		transaction.Amount = interest.Neg()
+	} else {
+		transaction.ReceiverAccountID = account.ID
+	}
+
+	return transaction
 }
 
 /* ------------------------------------------------------------ */
 func (t Transaction) Validate() *ValidationErrors {
+	return t.validate(true)
+}
+
+// ValidateSweep validates the sweep of a closing account or posted interest, they move the
+// whole balance or the interest of a month at once and so arent held to MAX_TRANSFER_AMOUNT.
+func (t Transaction) ValidateSweep() *ValidationErrors {
+	return t.validate(false)
+}
+
+func (t Transaction) validate(capped bool) *ValidationErrors {
 	var errors []string
 
 	if t.ID == uuid.Nil {
 		errors = append(errors, "ID cannot be nil")
 	}
 
-	if t.SenderAccountID == uuid.Nil || t.ReceiverAccountID == uuid.Nil {
-		errors = append(errors, "Both accounts ID's must be set")
-	} else if t.SenderAccountID == t.ReceiverAccountID {
-		errors = append(errors, "Sender and Receiver account cant have the same ID")
+	switch t.Type {
+	case TransactionTransfer:
+		if t.SenderAccountID == uuid.Nil || t.ReceiverAccountID == uuid.Nil {
+			errors = append(errors, "Both accounts ID's must be set")
+		} else if t.SenderAccountID == t.ReceiverAccountID {
+			errors = append(errors, "Sender and Receiver account cant have the same ID")
+		}
+
+		if t.Channel != "" {
+			errors = append(errors, "Transfers dont have a channel")
+		}
+	case TransactionDeposit, TransactionWithdrawal:
+		account, counterparty := t.ReceiverAccountID, t.SenderAccountID
+		if t.Type == TransactionWithdrawal {
+			account, counterparty = t.SenderAccountID, t.ReceiverAccountID
+		}
+
+		if account == uuid.Nil {
+			errors = append(errors, "Account ID must be set")
+		}
+
+		if counterparty != uuid.Nil {
+			errors = append(errors, "Deposits and withdrawals cant have a counterparty account")
+		}
+
+		if !slices.Contains(TransactionChannels, t.Channel) {
+			errors = append(errors, "Unknown channel "+string(t.Channel))
+		}
+	case TransactionInterest:
+		if (t.SenderAccountID == uuid.Nil) == (t.ReceiverAccountID == uuid.Nil) {
+			errors = append(errors, "Interest must be paid to or charged from a single account")
+		}
+
+		if t.Channel != "" {
+			errors = append(errors, "Interest doesnt have a channel")
+		}
+	default:
+		errors = append(errors, "Unknown transaction type "+string(t.Type))
 	}
 	
-	if t.Amount <= 0 {
+	if t.Amount.IsNegative() || t.Amount.IsZero() {
 		errors = append(errors, "Sending amount must be bigger than 0!")
 	}
 	
-	if t.Amount > MAX_TRANSFER_AMOUNT {
+	if capped && t.Amount.Cmp(MoneyFromMajor(MAX_TRANSFER_AMOUNT, t.Amount.Currency)) > 0 {
 		errors = append(errors, "Sending amount must not be bigger than: "+strconv.Itoa(MAX_TRANSFER_AMOUNT))
 	}
 	
-	if _, ok := CurrencyLookupMap[t.CurrencyPair.From]; !ok {
-		errors = append(errors, "This currency is not supported!")
+	if t.Amount.Currency != t.CurrencyPair.From {
+		errors = append(errors, "Amount must be in the sender currency")
 	}
 
-	if _, ok := CurrencyLookupMap[t.CurrencyPair.To]; !ok {
-		errors = append(errors, "This currency is not supported!")
+	if t.Fee.IsNegative() || (!t.Fee.IsZero() && t.Fee.Cmp(t.Amount) >= 0) {
+		errors = append(errors, "Fee must be smaller than the sending amount")
 	}
 
 	if t.CreatedAt.IsZero() {
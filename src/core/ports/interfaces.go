@@ -1,7 +1,16 @@
 package ports
 
-import "github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+import (
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
 
 type ISerializable interface {
 	ToDTO() domain.DTO
 }
+
+// IClock tells the services what time it is, so tests can move time instead of waiting for it.
+type IClock interface {
+	Now() time.Time
+}
@@ -1,7 +1,8 @@
 package ports
 
 import (
-	"database/sql"
+	"math/big"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
@@ -9,19 +10,39 @@ import (
 
 type IRepository interface {
 	DatabaseHas(table, column string, value any) bool
-	BeginTransaction() (*sql.Tx, error)
+	WithinTx(fn func(repositories IRepositories) error) error
 	ClearAllTables() error
 }
 
+// IRepositories groups the repositories handed out by a unit of work,
+// all of them running on the same database transaction.
+type IRepositories interface {
+	IAccountRepository
+	ICustomerRepository
+	ICustomerTokenRepository
+	IRefreshTokenRepository
+	ICredentialRepository
+	ITransactionRepository
+	ILedgerRepository
+	IAdjustmentRepository
+	IStandingOrderRepository
+	IMandateRepository
+	IFXQuoteRepository
+	IRateLimitRepository
+}
+
 type IAccountRepository interface {
 	GetAllAccounts(limit int, offset int) ([]domain.Account, error)
 	GetAllAccountsByCustomer(customerID uuid.UUID, limit int, offset int) ([]domain.Account, error)
-	GetAllSavingsAccounts() ([]domain.Account, error) 
+	GetAccountsToAccrue(through time.Time) ([]uuid.UUID, error) // Open accounts that werent accrued through the day yet
 	GetAccount(accountID uuid.UUID) (domain.Account, error)
 	GetAccountByOwner(customerID, accountID uuid.UUID) (domain.Account, error)
+	GetAccountForUpdate(accountID uuid.UUID) (domain.Account, error) // Locks the row until the transaction ends
 	CreateAccount(account domain.Account) (int64, error)
 	UpdateAccount(account domain.Account) (int64, error)
-	DeleteAccount(accountID uuid.UUID) (int64, error)	
+	UpdateAccountStatus(accountID uuid.UUID, status domain.AccountStatus) (int64, error)
+	UpdateAccruedInterest(accountID uuid.UUID, accrued *big.Rat, through time.Time) (int64, error)
+	MarkDormantAccounts(inactiveSince time.Time) (int64, error)
 }
 
 type ICustomerRepository interface {
@@ -29,13 +50,135 @@ type ICustomerRepository interface {
 	GetCustomer(customerID uuid.UUID) (domain.Customer, error)
 	CreateCustomer(customer domain.Customer) (int64, error)
 	UpdateCustomer(customer domain.Customer) (int64, error)
+	UpdateCustomerRole(customerID uuid.UUID, role domain.Role) (int64, error)
 	DeleteCustomer(customerID uuid.UUID) (int64, error)
-	AuthCustomer(customerID uuid.UUID, token string) (bool, error)
+}
+
+type ICustomerTokenRepository interface {
+	GetCustomerTokens(customerID uuid.UUID) ([]domain.CustomerToken, error)
+	GetActiveCustomerTokens(customerID uuid.UUID, at time.Time) ([]domain.CustomerToken, error)
+	CreateCustomerToken(token domain.CustomerToken) (int64, error)
+	TouchCustomerToken(tokenID uuid.UUID, at time.Time) (int64, error)
+	RevokeCustomerToken(customerID, tokenID uuid.UUID, at time.Time) (int64, error)
+}
+
+type IRefreshTokenRepository interface {
+	GetRefreshTokenForUpdate(tokenID uuid.UUID) (domain.RefreshToken, error) // Locks the row until the transaction ends
+	CreateRefreshToken(token domain.RefreshToken) (int64, error)
+	UseRefreshToken(tokenID uuid.UUID, at time.Time) (int64, error) // Returns 0 when the token was already used
+	RevokeRefreshTokenFamily(familyID uuid.UUID, at time.Time) (int64, error)
+	RevokeCustomerRefreshTokens(customerID uuid.UUID, at time.Time) (int64, error)
+}
+
+type IConsentRepository interface {
+	GetConsents(customerID uuid.UUID) ([]domain.Consent, error)
+	GetConsent(consentID uuid.UUID) (domain.Consent, error)
+	CreateConsent(consent domain.Consent) (int64, error)
+	TouchConsent(consentID uuid.UUID, at time.Time) (int64, error)
+	RevokeConsent(customerID, consentID uuid.UUID, at time.Time) (int64, error) // Returns 0 when there is no such active consent
+}
+
+type ICredentialRepository interface {
+	GetCredentials(customerID uuid.UUID) (domain.Credentials, error)
+	GetCredentialsForUpdate(customerID uuid.UUID) (domain.Credentials, error) // Locks the row until the transaction ends
+	SaveCredentials(credentials domain.Credentials) (int64, error)
+	GetUnusedRecoveryCodes(customerID uuid.UUID) ([]domain.RecoveryCode, error)
+	ReplaceRecoveryCodes(customerID uuid.UUID, codes []domain.RecoveryCode) (int64, error)
+	UseRecoveryCode(codeID uuid.UUID, at time.Time) (int64, error) // Returns 0 when the code was already used
+	CountSecondFactorFailures(customerID uuid.UUID, since time.Time) (int, error)
+	CreateSecondFactorFailure(customerID uuid.UUID, at time.Time) (int64, error)
+	DeleteSecondFactorFailures(customerID uuid.UUID) (int64, error)
 }
 
 type ITransactionRepository interface {
-	GetAllTransactions(limit, offset int) ([]domain.Transaction, error)
-	GetAllTransactionsFromAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Transaction, error)	
+	GetAllTransactions(status domain.TransactionStatus, limit, offset int) ([]domain.Transaction, error)
+	GetAllTransactionsFromAccount(accountID uuid.UUID, status domain.TransactionStatus, limit int, offset int) ([]domain.Transaction, error)	
 	GetTransaction(transactionID uuid.UUID) (domain.Transaction, error) 	
+	GetTransactionForUpdate(transactionID uuid.UUID) (domain.Transaction, error) // Locks the row until the transaction ends
 	CreateTransaction(transaction domain.Transaction) (int64, error)
-}
\ No newline at end of file
+	UpdateTransaction(transaction domain.Transaction) (int64, error)
+}
+
+type ILedgerRepository interface {
+	GetLedgerEntriesByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.LedgerEntry, error)
+	GetLedgerEntriesSince(accountID uuid.UUID, since time.Time) ([]domain.LedgerEntry, error)
+	GetLedgerBalance(accountID uuid.UUID, currency domain.Currency) (domain.Money, error)
+	CreateLedgerEntries(entries []domain.LedgerEntry) (int64, error)
+}
+
+type IJobRepository interface {
+	GetJobRuns(job string, limit int, offset int) ([]domain.JobRun, error)
+	CreateJobRun(run domain.JobRun) (int64, error)
+	FinishJobRun(run domain.JobRun) (int64, error)
+	InterruptJobRuns(job string, at time.Time) (int64, error)
+	LockJob(job string) (unlock func() error, locked bool, err error) // Only one instance can hold the lock of a job
+}
+
+type IStandingOrderRepository interface {
+	GetStandingOrdersByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.StandingOrder, error)
+	GetStandingOrder(standingOrderID uuid.UUID) (domain.StandingOrder, error)
+	GetStandingOrderForUpdate(standingOrderID uuid.UUID) (domain.StandingOrder, error) // Locks the row until the transaction ends
+	GetDueStandingOrders(at time.Time) ([]uuid.UUID, error)
+	CreateStandingOrder(order domain.StandingOrder) (int64, error)
+	UpdateStandingOrder(order domain.StandingOrder) (int64, error)
+	CancelStandingOrdersByAccount(accountID uuid.UUID, at time.Time) (int64, error)
+	GetStandingOrderExecutions(standingOrderID uuid.UUID, limit int, offset int) ([]domain.StandingOrderExecution, error)
+	GetStandingOrderExecution(standingOrderID uuid.UUID, dueDate time.Time, attempt int) (domain.StandingOrderExecution, error)
+	CreateStandingOrderExecution(execution domain.StandingOrderExecution) (int64, error) // Returns 0 when the attempt was already made
+	FinishStandingOrderExecution(execution domain.StandingOrderExecution) (int64, error)
+}
+
+type IMandateRepository interface {
+	GetMandatesByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Mandate, error) // Mandates the account pays or collects
+	GetMandate(mandateID uuid.UUID) (domain.Mandate, error)
+	GetMandateForUpdate(mandateID uuid.UUID) (domain.Mandate, error) // Locks the row until the transaction ends
+	CreateMandate(mandate domain.Mandate) (int64, error)
+	UpdateMandate(mandate domain.Mandate) (int64, error)
+	CancelMandatesByAccount(accountID uuid.UUID, at time.Time) (int64, error) // Mandates the account pays or collects
+	GetMandateCollections(mandateID uuid.UUID, limit int, offset int) ([]domain.Transaction, error)
+	CountMandateCollections(mandateID uuid.UUID, since time.Time) (int, error) // Failed collections arent counted
+}
+
+type IAdjustmentRepository interface {
+	GetAdjustmentsByAccount(accountID uuid.UUID, limit int, offset int) ([]domain.Adjustment, error)
+	CreateAdjustment(adjustment domain.Adjustment) (int64, error)
+}
+
+type IFXQuoteRepository interface {
+	GetFXQuoteForUpdate(quoteID uuid.UUID) (domain.FXQuote, error) // Locks the row until the transaction ends
+	CreateFXQuote(quote domain.FXQuote) (int64, error)
+	UseFXQuote(quoteID uuid.UUID, transactionID uuid.UUID) (int64, error) // Returns 0 when the quote was already used
+}
+
+type IRateLimitRepository interface {
+	GetRateLimitBucketForUpdate(key string) (domain.RateLimitBucket, error) // Locks the row until the transaction ends
+	SaveRateLimitBucket(bucket domain.RateLimitBucket) (int64, error)
+	DeleteFullRateLimitBuckets(at time.Time) (int64, error)
+}
+
+// IRateLimitStore keeps the token buckets of the rate limiter.
+type IRateLimitStore interface {
+	Take(key string, limit domain.RateLimit, at time.Time) (domain.RateLimitResult, error)
+}
+
+type IIdempotencyRepository interface {
+	GetIdempotencyKey(principal string, key string) (domain.IdempotencyKey, error)
+	CreateIdempotencyKey(key domain.IdempotencyKey) (int64, error)
+	UpdateIdempotencyKey(key domain.IdempotencyKey) (int64, error)
+	DeleteIdempotencyKey(principal string, key string) (int64, error)
+}
+
+// IExchangeRateProvider looks up the rate of a currency pair that was effective at the given time.
+type IExchangeRateProvider interface {
+	GetExchangeRate(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error)
+}
+
+type IExchangeRateRepository interface {
+	IExchangeRateProvider
+	CreateExchangeRate(rate domain.ExchangeRate) (int64, error)
+}
+
+type ICurrencyRepository interface {
+	GetEnabledCurrencies() ([]domain.Currency, error)
+	SetCurrencyEnabled(code domain.Currency, enabled bool) (int64, error)
+}
@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 )
@@ -10,9 +12,15 @@ type IAccountService interface {
 	Get(accountID uuid.UUID) (domain.Account, error)
 	Create(customerID uuid.UUID, body domain.CreateAccountRequest) (domain.Account, error)
 	Update(accountID uuid.UUID, body domain.UpdateAccountRequest) (int64, error)
-	Delete(accountID uuid.UUID) (int64, error)
+	Adjust(accountID, createdBy uuid.UUID, body domain.CreateAdjustmentRequest) (domain.Adjustment, error)
+	Adjustments(accountID uuid.UUID, limit int, offset int) ([]domain.Adjustment, error)
+	SetOverdraft(accountID uuid.UUID, body domain.SetOverdraftRequest) (domain.Account, error)
+	Freeze(accountID uuid.UUID) (domain.Account, error)
+	Unfreeze(accountID uuid.UUID) (domain.Account, error)
+	Reactivate(accountID uuid.UUID) (domain.Account, error)
+	Close(accountID uuid.UUID, body domain.CloseAccountRequest) (domain.Account, error)
 	IsOwner(customerID, accountID uuid.UUID) (bool, error)
-	UpdateBalanceDaily() error
+	MarkDormant(at time.Time) (int64, error)
 }
 
 type ICustomerService interface {
@@ -21,11 +29,115 @@ type ICustomerService interface {
 	Create(body domain.CreateCustomerRequest) (domain.Customer, error)
 	Update(customerID uuid.UUID, body domain.UpdateCustomerRequest) (int64, error)
 	Delete(customerID uuid.UUID) (int64, error)
+	SetRole(customerID uuid.UUID, body domain.UpdateRoleRequest) (domain.Customer, error)
 	Auth(customerID uuid.UUID, token string) (bool, error)
+	CreateToken(customerID uuid.UUID, body domain.CreateCustomerTokenRequest) (domain.CustomerToken, string, error)
+	Tokens(customerID uuid.UUID) ([]domain.CustomerToken, error)
+	RevokeToken(customerID, tokenID uuid.UUID) (int64, error)
+}
+
+type IConsentService interface {
+	Index(customerID uuid.UUID) ([]domain.Consent, error)
+	Create(customerID uuid.UUID, body domain.CreateConsentRequest) (domain.Consent, string, error)
+	Revoke(customerID, consentID uuid.UUID) (int64, error)
+	Authenticate(apiKey string) (domain.Consent, error)
+}
+
+type ICredentialService interface {
+	SetPassword(customerID uuid.UUID, body domain.SetPasswordRequest) error
+	EnrollTOTP(customerID uuid.UUID) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(customerID uuid.UUID, body domain.TwoFactorCodeRequest) ([]string, error)
+	DisableTOTP(customerID uuid.UUID, body domain.TwoFactorCodeRequest) error
+	Authenticate(body domain.PasswordLoginRequest) error
+	CheckSecondFactor(customerID uuid.UUID, code string) error
+	VerifySecondFactor(repositories IRepositories, customerID uuid.UUID, code string, at time.Time) error
+}
+
+type IAuthService interface {
+	Login(body domain.LoginRequest) (domain.TokenPair, error)
+	PasswordLogin(body domain.PasswordLoginRequest) (domain.TokenPair, error)
+	Refresh(body domain.RefreshRequest) (domain.TokenPair, error)
+	Logout(body domain.RefreshRequest) error
+	AccessToken(customerID uuid.UUID, role domain.Role, at time.Time) (string, time.Time, error)
+	Verify(accessToken string) (domain.Claims, error)
+	Keys() domain.JWKSet
 }
 
 type ITransactionService interface {
-	Index(accountID uuid.UUID, limit int, offset int) ([]domain.Transaction, error)
+	Index(accountID uuid.UUID, status domain.TransactionStatus, limit int, offset int) ([]domain.Transaction, error)
 	Get(transactionID uuid.UUID) (domain.Transaction, error)	
 	Create(body domain.CreateTransactionRequest) (domain.Transaction, error)
-}
\ No newline at end of file
+	Reverse(transactionID uuid.UUID, body domain.CreateReversalRequest) (domain.Transaction, error)
+	Refund(collectionID uuid.UUID) (domain.Transaction, error)
+	Deposit(accountID uuid.UUID, body domain.CreateDepositRequest) (domain.Transaction, error)
+	Withdraw(accountID uuid.UUID, body domain.CreateWithdrawalRequest) (domain.Transaction, error)
+	Sweep(repositories IRepositories, accountID, targetID uuid.UUID, at time.Time) (domain.Transaction, error)
+	PostInterest(repositories IRepositories, accountID uuid.UUID, interest domain.Money, at time.Time) (domain.Transaction, error)
+	RequiresStepUp(amount domain.Money) bool
+}
+
+type IStandingOrderService interface {
+	Index(accountID uuid.UUID, limit int, offset int) ([]domain.StandingOrder, error)
+	Get(accountID, standingOrderID uuid.UUID) (domain.StandingOrder, error)
+	Create(accountID uuid.UUID, body domain.CreateStandingOrderRequest) (domain.StandingOrder, error)
+	Cancel(accountID, standingOrderID uuid.UUID) (domain.StandingOrder, error)
+	Executions(accountID, standingOrderID uuid.UUID, limit int, offset int) ([]domain.StandingOrderExecution, error)
+	Execute(now time.Time) error
+}
+
+type IMandateService interface {
+	Index(accountID uuid.UUID, limit int, offset int) ([]domain.Mandate, error)
+	Get(accountID, mandateID uuid.UUID) (domain.Mandate, error)
+	Create(accountID uuid.UUID, body domain.CreateMandateRequest) (domain.Mandate, error)
+	Cancel(accountID, mandateID uuid.UUID) (domain.Mandate, error)
+	Collect(accountID, mandateID uuid.UUID, body domain.CreateCollectionRequest) (domain.Transaction, error)
+	Collections(accountID, mandateID uuid.UUID, limit int, offset int) ([]domain.Transaction, error)
+	Refund(accountID, mandateID, transactionID uuid.UUID) (domain.Transaction, error)
+}
+
+type IInterestService interface {
+	Accrue(now time.Time) error
+	AccrueAccount(accountID uuid.UUID, now time.Time) error
+	Settle(repositories IRepositories, accountID uuid.UUID, now time.Time) error
+}
+
+type IJobService interface {
+	Register(name, schedule string, run domain.JobFunc) error
+	Index() []domain.Job
+	Runs(name string, limit int, offset int) ([]domain.JobRun, error)
+	Trigger(name string, triggeredBy uuid.UUID) (domain.JobRun, error)
+	Start()
+}
+
+type ILedgerService interface {
+	Post(repositories IRepositories, journal domain.Journal) error
+	Entries(accountID uuid.UUID, limit int, offset int) ([]domain.LedgerEntry, error)
+	Verify(accountID uuid.UUID) (domain.LedgerBalance, error)
+}
+
+type IIdempotencyService interface {
+	Begin(principal string, key string, requestHash string) (domain.IdempotencyKey, bool, error)
+	Complete(key domain.IdempotencyKey) error
+	Release(key domain.IdempotencyKey) error
+}
+
+type IRateLimitService interface {
+	Allow(keys []string, write bool) (domain.RateLimitResult, error)
+}
+
+type IExchangeService interface {
+	Rate(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error)
+	Convert(amount domain.Money, to domain.Currency, at time.Time) (domain.Money, domain.ExchangeRate, error)
+}
+
+type ICurrencyService interface {
+	Index(enabledOnly bool) ([]domain.CurrencyInfo, error)
+	SetEnabled(code domain.Currency, enabled bool) (domain.CurrencyInfo, error)
+	CheckEnabled(codes ...domain.Currency) error
+}
+
+type IFXService interface {
+	Quote(customerID uuid.UUID, body domain.CreateFXQuoteRequest) (domain.FXQuote, error)
+	Price(amount domain.Money, to domain.Currency, at time.Time) (domain.FXQuote, error)
+	Redeem(repositories IRepositories, quoteID uuid.UUID, customerID uuid.UUID, amount domain.Money, to domain.Currency, transactionID uuid.UUID, at time.Time) (domain.FXQuote, error)
+}
@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,13 +16,25 @@ import (
 
 type AccountService struct {
 	AccountRepository ports.IAccountRepository
+	AdjustmentRepository ports.IAdjustmentRepository
 	GeneralRepository ports.IRepository
+	LedgerService     ports.ILedgerService
+	TransactionService ports.ITransactionService
+	InterestService ports.IInterestService
+	CurrencyService ports.ICurrencyService
+	Clock ports.IClock
 }
 
-func NewAccountService(accountRepository ports.IAccountRepository, generalRepository ports.IRepository) *AccountService {
+func NewAccountService(accountRepository ports.IAccountRepository, adjustmentRepository ports.IAdjustmentRepository, generalRepository ports.IRepository, ledgerService ports.ILedgerService, transactionService ports.ITransactionService, interestService ports.IInterestService, currencyService ports.ICurrencyService, clock ports.IClock) *AccountService {
 	return &AccountService{
 		AccountRepository: accountRepository,
+		AdjustmentRepository: adjustmentRepository,
 		GeneralRepository: generalRepository,
+		LedgerService:     ledgerService,
+		TransactionService: transactionService,
+		InterestService: interestService,
+		CurrencyService: currencyService,
+		Clock: clock,
 	}
 }
 
@@ -59,23 +74,50 @@ func (ac *AccountService) Get(accountID uuid.UUID) (domain.Account, error) {
 }
 
 func (ac *AccountService) Create(customerID uuid.UUID, body domain.CreateAccountRequest) (domain.Account, error) {
+	dayCount := domain.DayCountActual365
+	if body.DayCount != "" {
+		parsed, err := domain.ParseDayCount(body.DayCount)
+		if err != nil {
+			return domain.Account{}, domain.BadRequestError(err)
+		}
+		dayCount = parsed
+	}
+
+	tiers, err := domain.ParseInterestTiers(body.InterestTiers, body.Currency)
+	if err != nil {
+		return domain.Account{}, domain.BadRequestError(errors.New("Failed to parse interest tiers: "+err.Error()))
+	}
+
+	// Every account starts out empty, so the ledger explains every cent on it
 	account := domain.Account{
 		ID: uuid.New(),
 		CustomerID: customerID,
-		Balance: body.Balance,
+		Balance: domain.NewMoney(0, body.Currency),
+		OverdraftLimit: domain.NewMoney(0, body.Currency),
 		Type: body.Type,
 		Currency: body.Currency,
-		Status: true,
-		OpeningDate: time.Now(),
+		Status: domain.AccountActive,
+		OpeningDate: ac.Clock.Now(),
 		InterestRate: body.InterestRate,
-		CreatedAt: time.Now(),
+		InterestTiers: tiers,
+		DayCount: dayCount,
+		AccruedInterest: new(big.Rat),
+		CreatedAt: ac.Clock.Now(),
 	}
 
+	// Interest starts accruing on the day the account is opened
+	account.InterestAccruedThrough = domain.StartOfDay(account.OpeningDate).AddDate(0, 0, -1)
+
 	if err := account.Validate(); err != nil {
 		return domain.Account{}, domain.ValidationError(err)
 	}
 
-	_, err := ac.AccountRepository.CreateAccount(account)
+	// Accounts are only opened in the currencies the bank offers, disabling one keeps the existing accounts working
+	if err := ac.CurrencyService.CheckEnabled(account.Currency); err != nil {
+		return domain.Account{}, err
+	}
+
+	_, err = ac.AccountRepository.CreateAccount(account)
 	if err != nil {
 		return domain.Account{}, domain.InternalFailure(errors.New("Failed to create account: "+err.Error()))
 	}
@@ -83,45 +125,314 @@ func (ac *AccountService) Create(customerID uuid.UUID, body domain.CreateAccount
 	return account, nil
 }
 
+// Update changes the settings customers can change themselves, the balance only ever changes
+// through transfers and adjustments of the staff of the bank.
 func (ac *AccountService) Update(accountID uuid.UUID, body domain.UpdateAccountRequest) (int64, error) {
-	account := domain.Account{
-		ID: accountID,
-		Balance: body.Balance,
-		Type: body.Type,
-		Currency: body.Currency,
-		Status: body.Status,
-		LastTransactionDate: body.LastTransactionDate,
-		InterestRate: body.InterestRate,
+	var affectedRows int64
+
+	err := ac.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		account, err := repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Account not found"))
+			}
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
+		}
+
+		if account.Status == domain.AccountClosed {
+			return domain.ConflictError(errors.New("Account is closed"))
+		}
+
+		if body.Nickname != nil {
+			account.Nickname = strings.TrimSpace(*body.Nickname)
+			if len(account.Nickname) > domain.MAX_NICKNAME_LENGTH {
+				return domain.ValidationError(&domain.ValidationErrors{Errors: []string{"Nickname is too long"}})
+			}
+		}
+
+		if body.Status != "" && body.Status != account.Status {
+			if err := requestStatus(&account, body.Status); err != nil {
+				return err
+			}
+		}
+
+		affectedRows, err = repositories.UpdateAccount(account)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to update account: "+err.Error()))
+		}
+
+		if affectedRows == 0 {
+			return domain.InternalFailure(errors.New("No rows affected"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, domain.AsDomainError(err)
 	}
 
-	affectedRows, err := ac.AccountRepository.UpdateAccount(account)
+	return affectedRows, nil
+}
+
+// requestStatus lets customers freeze their own account, like when they lost their card, and
+// reactivate it when it is dormant. Unfreezing and closing is done elsewhere.
+func requestStatus(account *domain.Account, status domain.AccountStatus) error {
+	switch {
+	case status == domain.AccountFrozen:
+	case status == domain.AccountActive && account.Status == domain.AccountDormant:
+	case status == domain.AccountActive && account.Status == domain.AccountFrozen:
+		return domain.ForbiddenError(errors.New("Only the staff of the bank can unfreeze the account"))
+	case status == domain.AccountClosed:
+		return domain.BadRequestError(errors.New("Accounts are closed through the close endpoint"))
+	default:
+		return domain.BadRequestError(errors.New("Unknown account status "+string(status)))
+	}
+
+	if err := account.TransitionTo(status); err != nil {
+		return domain.ConflictError(err)
+	}
+
+	return nil
+}
+
+// Adjust changes the balance of the account outside of any transfer. Only the staff of the bank
+// make adjustments and they have to give a reason, the adjustment is booked in the ledger.
+func (ac *AccountService) Adjust(accountID, createdBy uuid.UUID, body domain.CreateAdjustmentRequest) (domain.Adjustment, error) {
+	var adjustment domain.Adjustment
+
+	err := ac.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		account, err := repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Account not found"))
+			}
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
+		}
+
+		if account.Status == domain.AccountClosed {
+			return domain.ConflictError(errors.New("Account is closed"))
+		}
+
+		amount, err := domain.ParseMoney(body.Amount.String(), account.Currency)
+		if err != nil {
+			return domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+		}
+
+		adjustment = domain.Adjustment{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Amount:    amount,
+			Reason:    body.Reason,
+			Note:      strings.TrimSpace(body.Note),
+			CreatedBy: createdBy,
+			CreatedAt: ac.Clock.Now(),
+		}
+
+		if err := adjustment.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		// Debits can use the arranged overdraft but not go past it
+		if adjustment.Amount.IsNegative() && !account.CanCover(adjustment.Amount.Neg()) {
+			return domain.BadRequestError(errors.New("Adjustment would overdraw the account"))
+		}
+		account.Balance = account.Balance.Add(adjustment.Amount)
+
+		_, err = repositories.UpdateAccount(account)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to update account: "+err.Error()))
+		}
+
+		_, err = repositories.CreateAdjustment(adjustment)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to create adjustment: "+err.Error()))
+		}
+
+		return ac.LedgerService.Post(repositories, domain.NewAdjustmentJournal(adjustment))
+	})
+	if err != nil {
+		return domain.Adjustment{}, domain.AsDomainError(err)
+	}
+
+	return adjustment, nil
+}
+
+func (ac *AccountService) Adjustments(accountID uuid.UUID, limit int, offset int) ([]domain.Adjustment, error) {
+	adjustments, err := ac.AdjustmentRepository.GetAdjustmentsByAccount(accountID, limit, offset)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, domain.NotFoundError(errors.New("Account not found"))
+			return nil, domain.NotFoundError(errors.New("Adjustments not found"))
 		}
-		return 0, domain.InternalFailure(errors.New("Failed to update account: "+err.Error()))
+		return nil, domain.InternalFailure(errors.New("Failed to get adjustments: "+err.Error()))
 	}
 
-	if affectedRows == 0 {
-		return 0, domain.InternalFailure(errors.New("No rows affected"))
+	return adjustments, nil
+}
+
+// SetOverdraft arranges an overdraft for the account, or cancels it with a zero limit. The limit
+// cannot be lowered below what the account already uses of it.
+func (ac *AccountService) SetOverdraft(accountID uuid.UUID, body domain.SetOverdraftRequest) (domain.Account, error) {
+	var account domain.Account
+
+	err := ac.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		account, err = repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Account not found"))
+			}
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
+		}
+
+		if account.Status == domain.AccountClosed {
+			return domain.ConflictError(errors.New("Account is closed"))
+		}
+
+		account.OverdraftLimit, err = domain.ParseMoney(body.Limit.String(), account.Currency)
+		if err != nil {
+			return domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+		}
+		account.OverdraftInterestRate = body.InterestRate
+
+		if err := account.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		_, err = repositories.UpdateAccount(account)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to update account: "+err.Error()))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Account{}, domain.AsDomainError(err)
 	}
 
-	return affectedRows, nil
+	return account, nil
 }
-func (ac *AccountService) Delete(accountID uuid.UUID) (int64, error) {
-	affectedRows, err := ac.AccountRepository.DeleteAccount(accountID)
+
+// Freeze blocks every transfer from and to the account until the staff of the bank unfreezes it.
+func (ac *AccountService) Freeze(accountID uuid.UUID) (domain.Account, error) {
+	return ac.transition(accountID, domain.AccountFrozen)
+}
+
+func (ac *AccountService) Unfreeze(accountID uuid.UUID) (domain.Account, error) {
+	return ac.transition(accountID, domain.AccountActive, domain.AccountFrozen)
+}
+
+// Reactivate lets money move through a dormant account again.
+func (ac *AccountService) Reactivate(accountID uuid.UUID) (domain.Account, error) {
+	return ac.transition(accountID, domain.AccountActive, domain.AccountDormant)
+}
+
+// Close closes the account for good. The interest accrued until today is posted first, an account
+// that then still holds money needs another active account of the customer to sweep the balance
+// to, the sweep is recorded as a regular transaction. Its standing orders and mandates are cancelled.
+func (ac *AccountService) Close(accountID uuid.UUID, body domain.CloseAccountRequest) (domain.Account, error) {
+	var account domain.Account
+
+	err := ac.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		account, err = repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Account not found"))
+			}
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
+		}
+
+		if !account.Status.CanTransitionTo(domain.AccountClosed) {
+			return domain.ConflictError(errors.New("A "+strings.ToLower(string(account.Status))+" account cannot be closed"))
+		}
+
+		now := ac.Clock.Now()
+
+		if err := ac.InterestService.Settle(repositories, accountID, now); err != nil {
+			return err
+		}
+
+		// The posted interest changed the balance
+		account, err = repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
+		}
+
+		if account.Balance.IsNegative() {
+			return domain.BadRequestError(errors.New("Account is overdrawn, the debt has to be paid before closing it"))
+		}
+
+		if !account.Balance.IsZero() {
+			if body.SweepAccountID == uuid.Nil {
+				return domain.BadRequestError(errors.New("Account still holds "+account.Balance.String()+" "+string(account.Currency)+", nominate an account to sweep it to"))
+			}
+
+			if _, err := ac.TransactionService.Sweep(repositories, accountID, body.SweepAccountID, now); err != nil {
+				return err
+			}
+
+			account.Balance = domain.NewMoney(0, account.Currency)
+		}
+
+		if _, err := repositories.CancelStandingOrdersByAccount(accountID, now); err != nil {
+			return domain.InternalFailure(errors.New("Failed to cancel standing orders: "+err.Error()))
+		}
+
+		if _, err := repositories.CancelMandatesByAccount(accountID, now); err != nil {
+			return domain.InternalFailure(errors.New("Failed to cancel mandates: "+err.Error()))
+		}
+
+		return updateStatus(repositories, &account, domain.AccountClosed)
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, domain.NotFoundError(errors.New("Account not found"))
+		return domain.Account{}, domain.AsDomainError(err)
+	}
+
+	return account, nil
+}
+
+// transition moves the account to the next status, only from the given statuses when there are any.
+func (ac *AccountService) transition(accountID uuid.UUID, next domain.AccountStatus, from ...domain.AccountStatus) (domain.Account, error) {
+	var account domain.Account
+
+	err := ac.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		account, err = repositories.GetAccountForUpdate(accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Account not found"))
+			}
+			return domain.InternalFailure(errors.New("Failed to get account: "+err.Error()))
 		}
-		return 0, domain.InternalFailure(errors.New("Failed to delete account: "+err.Error()))
+
+		if len(from) > 0 && !slices.Contains(from, account.Status) {
+			return domain.ConflictError(errors.New("Account is "+strings.ToLower(string(account.Status))))
+		}
+
+		return updateStatus(repositories, &account, next)
+	})
+	if err != nil {
+		return domain.Account{}, domain.AsDomainError(err)
 	}
 
-	if affectedRows == 0 {
-		return 0, domain.InternalFailure(errors.New("No rows affected"))
+	return account, nil
+}
+
+// updateStatus moves the locked account to the next status and persists it, invalid transitions are a conflict.
+func updateStatus(repositories ports.IRepositories, account *domain.Account, next domain.AccountStatus) error {
+	if err := account.TransitionTo(next); err != nil {
+		return domain.ConflictError(err)
 	}
 
-	return affectedRows, nil
+	_, err := repositories.UpdateAccountStatus(account.ID, account.Status)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to update account status: "+err.Error()))
+	}
+
+	return nil
 }
 
 func (ac *AccountService) IsOwner(customerID, accountID uuid.UUID) (bool, error) {
@@ -137,42 +448,14 @@ func (ac *AccountService) IsOwner(customerID, accountID uuid.UUID) (bool, error)
 	return true, nil
 }
 
-func (ac *AccountService) UpdateBalanceDaily() error {
-    ticker := time.NewTicker(24 * time.Hour)
-    defer ticker.Stop()
-
-    for {
-        select {
-		case <-ticker.C:
-			tx, err := ac.GeneralRepository.BeginTransaction()
-			if err != nil {
-				return errors.New("Failed to begin transaction: "+err.Error())
-			}
-			defer tx.Rollback()
-
-            accounts, err := ac.AccountRepository.GetAllSavingsAccounts()
-            if err != nil {
-                return errors.New("Failed to get accounts: "+err.Error())
-            }
-
-            for _, account := range accounts {
-                account.Balance += account.Balance * account.InterestRate / 365
-
-                affected, err := ac.AccountRepository.UpdateAccount(account)
-                if err != nil {
-                    return errors.New("Failed to update account: "+err.Error())
-                }
-
-                if affected == 0 {
-					return errors.New("Something went wrong: No rows affected")        
-                }
-            }
+// MarkDormant makes the active accounts without a transfer for DORMANCY_PERIOD before the given time dormant.
+func (ac *AccountService) MarkDormant(at time.Time) (int64, error) {
+	affected, err := ac.AccountRepository.MarkDormantAccounts(at.Add(-domain.DORMANCY_PERIOD))
+	if err != nil {
+		return 0, domain.InternalFailure(errors.New("Failed to mark dormant accounts: "+err.Error()))
+	}
 
-			if err := tx.Commit(); err != nil {
-				return errors.New("Failed to commit: "+err.Error())
-			}
+	log.Printf("[EVENT]\tMarked %v accounts as dormant", affected)
 
-			log.Printf("[EVENT] - Successfully updated the savings account balance!")
-        }
-    }
-}
\ No newline at end of file
+	return affected, nil
+}
@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+)
+
+const ISSUER = "GO_BankDemoApi"
+
+type AuthService struct {
+	CustomerService        ports.ICustomerService
+	CredentialService      ports.ICredentialService
+	RefreshTokenRepository ports.IRefreshTokenRepository
+	GeneralRepository      ports.IRepository
+	KeySet                 *KeySet
+	Issuer                 string
+	Clock                  ports.IClock
+}
+
+func NewAuthService(customerService ports.ICustomerService, credentialService ports.ICredentialService, refreshTokenRepository ports.IRefreshTokenRepository, generalRepository ports.IRepository, keySet *KeySet, clock ports.IClock) *AuthService {
+	return &AuthService{
+		CustomerService:        customerService,
+		CredentialService:      credentialService,
+		RefreshTokenRepository: refreshTokenRepository,
+		GeneralRepository:      generalRepository,
+		KeySet:                 keySet,
+		Issuer:                 ISSUER,
+		Clock:                  clock,
+	}
+}
+
+// Login exchanges an API token of the customer and, when enabled, a second factor for an access
+// token and a new refresh token family.
+func (as *AuthService) Login(body domain.LoginRequest) (domain.TokenPair, error) {
+	authorized, err := as.CustomerService.Auth(body.CustomerID, body.Token)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	if !authorized {
+		return domain.TokenPair{}, domain.UnauthorizedError(errors.New("Bad credentials"))
+	}
+
+	if err := as.CredentialService.CheckSecondFactor(body.CustomerID, body.Code); err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	owner, err := as.CustomerService.Get(body.CustomerID)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	return as.issue(as.RefreshTokenRepository, owner, uuid.Nil, as.Clock.Now())
+}
+
+// PasswordLogin exchanges the password and, when enabled, a second factor for an access token and a new refresh token family.
+func (as *AuthService) PasswordLogin(body domain.PasswordLoginRequest) (domain.TokenPair, error) {
+	if err := as.CredentialService.Authenticate(body); err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	owner, err := as.CustomerService.Get(body.CustomerID)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	return as.issue(as.RefreshTokenRepository, owner, uuid.Nil, as.Clock.Now())
+}
+
+// Refresh exchanges a refresh token for a new pair, the refresh token cannot be used again.
+// Using it again anyway means it leaked, so the whole family gets revoked.
+func (as *AuthService) Refresh(body domain.RefreshRequest) (domain.TokenPair, error) {
+	tokenID, secret, err := splitRefreshToken(body.RefreshToken)
+	if err != nil {
+		return domain.TokenPair{}, domain.UnauthorizedError(err)
+	}
+
+	now := as.Clock.Now()
+
+	var pair domain.TokenPair
+	var reused bool
+
+	err = as.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		reused = false
+
+		stored, err := lockRefreshToken(repositories, tokenID, secret)
+		if err != nil {
+			return err
+		}
+
+		if stored.IsRevoked() || stored.IsExpired(now) {
+			return domain.UnauthorizedError(errors.New("Refresh token expired or was revoked"))
+		}
+
+		// The revocation has to be committed, so the error is only returned after the transaction
+		if stored.IsUsed() {
+			reused = true
+			_, err := repositories.RevokeRefreshTokenFamily(stored.FamilyID, now)
+			if err != nil {
+				return domain.InternalFailure(fmt.Errorf("Failed to revoke refresh tokens: %w", err))
+			}
+			return nil
+		}
+
+		affectedRows, err := repositories.UseRefreshToken(stored.ID, now)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to use refresh token: %w", err))
+		}
+		if affectedRows == 0 {
+			return domain.UnauthorizedError(errors.New("Refresh token was already used"))
+		}
+
+		// The role is read again, so a changed role applies from the next refresh on
+		owner, err := repositories.GetCustomer(stored.CustomerID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.UnauthorizedError(errors.New("Customer not found"))
+			}
+			return domain.InternalFailure(fmt.Errorf("Failed to get customer: %w", err))
+		}
+
+		pair, err = as.issue(repositories, owner, stored.FamilyID, now)
+		return err
+	})
+	if err != nil {
+		return domain.TokenPair{}, domain.AsDomainError(err)
+	}
+
+	if reused {
+		return domain.TokenPair{}, domain.UnauthorizedError(errors.New("Refresh token was already used, log in again"))
+	}
+
+	return pair, nil
+}
+
+// Logout revokes the refresh token together with the rest of its family.
+func (as *AuthService) Logout(body domain.RefreshRequest) error {
+	tokenID, secret, err := splitRefreshToken(body.RefreshToken)
+	if err != nil {
+		return domain.UnauthorizedError(err)
+	}
+
+	err = as.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		stored, err := lockRefreshToken(repositories, tokenID, secret)
+		if err != nil {
+			return err
+		}
+
+		_, err = repositories.RevokeRefreshTokenFamily(stored.FamilyID, as.Clock.Now())
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to revoke refresh tokens: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.AsDomainError(err)
+	}
+
+	return nil
+}
+
+// AccessToken signs a short-lived access token of the customer with the active key.
+func (as *AuthService) AccessToken(customerID uuid.UUID, role domain.Role, at time.Time) (string, time.Time, error) {
+	expiresAt := at.Add(domain.ACCESS_TOKEN_TTL)
+
+	claims := domain.Claims{
+		Issuer:    as.Issuer,
+		Subject:   customerID.String(),
+		IssuedAt:  at.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		ID:        uuid.NewString(),
+		Role:      role,
+	}
+
+	token, err := Sign(claims, as.KeySet.Active())
+	if err != nil {
+		return "", time.Time{}, domain.InternalFailure(errors.New("Failed to sign access token: " + err.Error()))
+	}
+
+	return token, expiresAt, nil
+}
+
+// Verify checks the access token without touching the database.
+func (as *AuthService) Verify(accessToken string) (domain.Claims, error) {
+	claims, err := Parse(accessToken, as.KeySet)
+	if err != nil {
+		return domain.Claims{}, domain.UnauthorizedError(err)
+	}
+
+	if err := claims.Validate(as.Issuer, as.Clock.Now()); err != nil {
+		return domain.Claims{}, domain.UnauthorizedError(err)
+	}
+
+	return claims, nil
+}
+
+func (as *AuthService) Keys() domain.JWKSet {
+	return as.KeySet.JWKS()
+}
+
+// issue creates an access token and a refresh token, which starts a new family when none is given.
+func (as *AuthService) issue(repository ports.IRefreshTokenRepository, owner domain.Customer, familyID uuid.UUID, at time.Time) (domain.TokenPair, error) {
+	accessToken, accessExpiresAt, err := as.AccessToken(owner.ID, owner.Role, at)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	salt, err := customer.GenerateTokenSalt()
+	if err != nil {
+		return domain.TokenPair{}, domain.InternalFailure(errors.New("Failed to generate token salt: " + err.Error()))
+	}
+
+	secret := customer.GenerateToken()
+
+	refreshToken := domain.RefreshToken{
+		ID:         uuid.New(),
+		CustomerID: owner.ID,
+		FamilyID:   familyID,
+		Salt:       salt,
+		Hash:       customer.HashToken(secret, salt),
+		CreatedAt:  at,
+		ExpiresAt:  at.Add(domain.REFRESH_TOKEN_TTL),
+	}
+
+	if refreshToken.FamilyID == uuid.Nil {
+		refreshToken.FamilyID = refreshToken.ID
+	}
+
+	if err := refreshToken.Validate(); err != nil {
+		return domain.TokenPair{}, domain.ValidationError(err)
+	}
+
+	_, err = repository.CreateRefreshToken(refreshToken)
+	if err != nil {
+		return domain.TokenPair{}, domain.InternalFailure(errors.New("Failed to create refresh token: " + err.Error()))
+	}
+
+	return domain.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken.ID.String() + "." + secret,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+		IssuedAt:         at,
+	}, nil
+}
+
+// lockRefreshToken gets the refresh token and checks the secret matches it.
+func lockRefreshToken(repositories ports.IRepositories, tokenID uuid.UUID, secret string) (domain.RefreshToken, error) {
+	stored, err := repositories.GetRefreshTokenForUpdate(tokenID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.RefreshToken{}, domain.UnauthorizedError(errors.New("Invalid refresh token"))
+		}
+		return domain.RefreshToken{}, domain.InternalFailure(fmt.Errorf("Failed to get refresh token: %w", err))
+	}
+
+	if !customer.VerifyToken(secret, stored.Salt, stored.Hash) {
+		return domain.RefreshToken{}, domain.UnauthorizedError(errors.New("Invalid refresh token"))
+	}
+
+	return stored, nil
+}
+
+// splitRefreshToken splits a refresh token into the ID it is stored under and its secret.
+func splitRefreshToken(token string) (uuid.UUID, string, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return uuid.Nil, "", errors.New("Invalid refresh token")
+	}
+
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", errors.New("Invalid refresh token")
+	}
+
+	return tokenID, secret, nil
+}
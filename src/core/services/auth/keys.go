@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// Shorter HMAC secrets could be brute forced from a single token.
+const MIN_HS256_SECRET_LENGTH = 32
+
+// SigningKey signs and verifies access tokens. HS256 keys are secret and only
+// verify tokens inside this server, EdDSA keys can be published so others can verify them too.
+type SigningKey struct {
+	ID         string
+	Algorithm  Algorithm
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+func NewHS256Key(id string, secret []byte) (SigningKey, error) {
+	if id == "" {
+		return SigningKey{}, errors.New("key ID is required")
+	}
+
+	if len(secret) < MIN_HS256_SECRET_LENGTH {
+		return SigningKey{}, errors.New("HS256 secret must be at least 32 bytes long")
+	}
+
+	return SigningKey{ID: id, Algorithm: HS256, secret: secret}, nil
+}
+
+func NewEdDSAKey(id string, seed []byte) (SigningKey, error) {
+	if id == "" {
+		return SigningKey{}, errors.New("key ID is required")
+	}
+
+	if len(seed) != ed25519.SeedSize {
+		return SigningKey{}, errors.New("EdDSA seed must be 32 bytes long")
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+
+	return SigningKey{ID: id, Algorithm: EdDSA, privateKey: privateKey, publicKey: privateKey.Public().(ed25519.PublicKey)}, nil
+}
+
+// GenerateKey creates a random key of the algorithm.
+func GenerateKey(id string, algorithm Algorithm) (SigningKey, error) {
+	material := make([]byte, 32)
+	if _, err := rand.Read(material); err != nil {
+		return SigningKey{}, err
+	}
+
+	switch algorithm {
+	case HS256:
+		return NewHS256Key(id, material)
+	case EdDSA:
+		return NewEdDSAKey(id, material)
+	}
+
+	return SigningKey{}, errors.New("unsupported algorithm: " + string(algorithm))
+}
+
+// ParseKey parses a key written as "id:algorithm:base64", where the base64 part
+// is the secret of HS256 keys and the private key seed of EdDSA keys.
+func ParseKey(value string) (SigningKey, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 3)
+	if len(parts) != 3 {
+		return SigningKey{}, errors.New("key must be written as id:algorithm:base64")
+	}
+
+	material, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return SigningKey{}, errors.New("key material is not valid base64")
+	}
+
+	switch Algorithm(parts[1]) {
+	case HS256:
+		return NewHS256Key(parts[0], material)
+	case EdDSA:
+		return NewEdDSAKey(parts[0], material)
+	}
+
+	return SigningKey{}, errors.New("unsupported algorithm: " + parts[1])
+}
+
+func (k SigningKey) JWK() (domain.JWK, bool) {
+	if k.Algorithm != EdDSA {
+		return domain.JWK{}, false
+	}
+
+	return domain.JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		KeyID:     k.ID,
+		Algorithm: string(EdDSA),
+		Use:       "sig",
+		X:         base64.RawURLEncoding.EncodeToString(k.publicKey),
+	}, true
+}
+
+// KeySet holds the keys access tokens are verified with. New tokens are signed with the
+// active key, the other keys are kept so tokens signed before a rotation stay valid.
+type KeySet struct {
+	mu     sync.RWMutex
+	keys   map[string]SigningKey
+	active string
+}
+
+// NewKeySet creates a key set signing with the first key.
+func NewKeySet(active SigningKey, others ...SigningKey) *KeySet {
+	ks := &KeySet{keys: map[string]SigningKey{}}
+
+	for _, key := range others {
+		ks.keys[key.ID] = key
+	}
+	ks.keys[active.ID] = active
+	ks.active = active.ID
+
+	return ks
+}
+
+func (ks *KeySet) Active() SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.keys[ks.active]
+}
+
+func (ks *KeySet) Lookup(id string) (SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[id]
+	return key, ok
+}
+
+// Rotate starts signing with the key, the previous keys still verify the tokens they signed.
+func (ks *KeySet) Rotate(key SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys[key.ID] = key
+	ks.active = key.ID
+}
+
+// Retire removes a key once all tokens it signed expired, the active key cannot be retired.
+func (ks *KeySet) Retire(id string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if id == ks.active {
+		return false
+	}
+
+	if _, ok := ks.keys[id]; !ok {
+		return false
+	}
+
+	delete(ks.keys, id)
+	return true
+}
+
+// JWKS returns the public keys of the set sorted by their ID, secret HS256 keys are left out.
+func (ks *KeySet) JWKS() domain.JWKSet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := domain.JWKSet{Keys: []domain.JWK{}}
+
+	for _, key := range ks.keys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
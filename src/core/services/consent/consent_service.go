@@ -0,0 +1,169 @@
+package consent
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+)
+
+type ConsentService struct {
+	ConsentRepository ports.IConsentRepository
+	AccountRepository ports.IAccountRepository
+	Clock             ports.IClock
+}
+
+func NewConsentService(consentRepository ports.IConsentRepository, accountRepository ports.IAccountRepository, clock ports.IClock) *ConsentService {
+	return &ConsentService{
+		ConsentRepository: consentRepository,
+		AccountRepository: accountRepository,
+		Clock:             clock,
+	}
+}
+
+func (cs *ConsentService) Index(customerID uuid.UUID) ([]domain.Consent, error) {
+	consents, err := cs.ConsentRepository.GetConsents(customerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Consents not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get consents: " + err.Error()))
+	}
+
+	return consents, nil
+}
+
+// Create lets a partner app use the accounts of the customer within the scopes, the API key
+// of the consent is returned in plaintext only this once.
+func (cs *ConsentService) Create(customerID uuid.UUID, body domain.CreateConsentRequest) (domain.Consent, string, error) {
+	now := cs.Clock.Now()
+
+	salt, err := customer.GenerateTokenSalt()
+	if err != nil {
+		return domain.Consent{}, "", domain.InternalFailure(errors.New("Failed to generate API key salt: " + err.Error()))
+	}
+
+	secret := customer.GenerateToken()
+
+	consent := domain.Consent{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		ClientName: strings.TrimSpace(body.ClientName),
+		AccountIDs: unique(body.AccountIDs),
+		Scopes:     unique(body.Scopes),
+		Salt:       salt,
+		Hash:       customer.HashToken(secret, salt),
+		CreatedAt:  now,
+		ExpiresAt:  body.ExpiresAt,
+	}
+
+	if consent.ExpiresAt.IsZero() {
+		consent.ExpiresAt = now.Add(domain.MAX_CONSENT_TTL)
+	}
+
+	if err := consent.Validate(); err != nil {
+		return domain.Consent{}, "", domain.ValidationError(err)
+	}
+
+	// Customers can only consent to the use of their own accounts
+	for _, accountID := range consent.AccountIDs {
+		_, err := cs.AccountRepository.GetAccountByOwner(customerID, accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.Consent{}, "", domain.BadRequestError(fmt.Errorf("Account %s not found", accountID))
+			}
+			return domain.Consent{}, "", domain.InternalFailure(errors.New("Failed to get account: " + err.Error()))
+		}
+	}
+
+	_, err = cs.ConsentRepository.CreateConsent(consent)
+	if err != nil {
+		return domain.Consent{}, "", domain.InternalFailure(errors.New("Failed to create consent: " + err.Error()))
+	}
+
+	return consent, domain.API_KEY_PREFIX + consent.ID.String() + "." + secret, nil
+}
+
+func (cs *ConsentService) Revoke(customerID, consentID uuid.UUID) (int64, error) {
+	affectedRows, err := cs.ConsentRepository.RevokeConsent(customerID, consentID, cs.Clock.Now())
+	if err != nil {
+		return 0, domain.InternalFailure(errors.New("Failed to revoke consent: " + err.Error()))
+	}
+
+	if affectedRows == 0 {
+		return 0, domain.NotFoundError(errors.New("Consent not found or already revoked"))
+	}
+
+	return affectedRows, nil
+}
+
+// Authenticate returns the active consent the API key belongs to and records its use.
+func (cs *ConsentService) Authenticate(apiKey string) (domain.Consent, error) {
+	consentID, secret, err := splitAPIKey(apiKey)
+	if err != nil {
+		return domain.Consent{}, domain.UnauthorizedError(err)
+	}
+
+	consent, err := cs.ConsentRepository.GetConsent(consentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Consent{}, domain.UnauthorizedError(errors.New("Invalid API key"))
+		}
+		return domain.Consent{}, domain.InternalFailure(errors.New("Failed to get consent: " + err.Error()))
+	}
+
+	if !customer.VerifyToken(secret, consent.Salt, consent.Hash) {
+		return domain.Consent{}, domain.UnauthorizedError(errors.New("Invalid API key"))
+	}
+
+	now := cs.Clock.Now()
+
+	if !consent.IsActive(now) {
+		return domain.Consent{}, domain.UnauthorizedError(errors.New("Consent expired or was revoked"))
+	}
+
+	_, err = cs.ConsentRepository.TouchConsent(consent.ID, now)
+	if err != nil {
+		return domain.Consent{}, domain.InternalFailure(errors.New("Failed to update consent: " + err.Error()))
+	}
+
+	return consent, nil
+}
+
+// splitAPIKey splits an API key into the ID of its consent and its secret.
+func splitAPIKey(apiKey string) (uuid.UUID, string, error) {
+	key, ok := strings.CutPrefix(apiKey, domain.API_KEY_PREFIX)
+	if !ok {
+		return uuid.Nil, "", errors.New("Invalid API key")
+	}
+
+	id, secret, ok := strings.Cut(key, ".")
+	if !ok || secret == "" {
+		return uuid.Nil, "", errors.New("Invalid API key")
+	}
+
+	consentID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", errors.New("Invalid API key")
+	}
+
+	return consentID, secret, nil
+}
+
+func unique[T comparable](values []T) []T {
+	var result []T
+
+	for _, value := range values {
+		if !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,403 @@
+package credential
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+)
+
+// The name authenticator apps show next to the codes.
+const TOTP_ISSUER = "GO Bank"
+
+type CredentialService struct {
+	CredentialRepository ports.ICredentialRepository
+	CustomerRepository   ports.ICustomerRepository
+	GeneralRepository    ports.IRepository
+	Clock                ports.IClock
+}
+
+func NewCredentialService(credentialRepository ports.ICredentialRepository, customerRepository ports.ICustomerRepository, generalRepository ports.IRepository, clock ports.IClock) *CredentialService {
+	return &CredentialService{
+		CredentialRepository: credentialRepository,
+		CustomerRepository:   customerRepository,
+		GeneralRepository:    generalRepository,
+		Clock:                clock,
+	}
+}
+
+// SetPassword sets the password of the customer, changing an existing one needs the current password.
+func (cs *CredentialService) SetPassword(customerID uuid.UUID, body domain.SetPasswordRequest) error {
+	if err := domain.ValidatePassword(body.NewPassword); err != nil {
+		return domain.ValidationError(err)
+	}
+
+	hash, err := HashPassword(body.NewPassword)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to hash password: " + err.Error()))
+	}
+
+	err = cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		credentials, err := cs.lockCredentials(repositories, customerID)
+		if err != nil {
+			return err
+		}
+
+		if credentials.HasPassword() {
+			matches, err := VerifyPassword(body.CurrentPassword, credentials.PasswordHash)
+			if err != nil {
+				return domain.InternalFailure(fmt.Errorf("Failed to verify password: %w", err))
+			}
+			if !matches {
+				return domain.UnauthorizedError(errors.New("Current password is wrong"))
+			}
+
+			// Whoever knew the old password shouldnt keep a session started with it
+			_, err = repositories.RevokeCustomerRefreshTokens(customerID, cs.Clock.Now())
+			if err != nil {
+				return domain.InternalFailure(fmt.Errorf("Failed to revoke refresh tokens: %w", err))
+			}
+		}
+
+		credentials.PasswordHash = hash
+		return cs.saveCredentials(repositories, credentials)
+	})
+	if err != nil {
+		return domain.AsDomainError(err)
+	}
+
+	return nil
+}
+
+// EnrollTOTP starts the two-factor enrolment with a new secret, it is enabled once a code is confirmed.
+func (cs *CredentialService) EnrollTOTP(customerID uuid.UUID) (domain.TOTPEnrollment, error) {
+	owner, err := cs.CustomerRepository.GetCustomer(customerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.TOTPEnrollment{}, domain.NotFoundError(errors.New("Customer not found"))
+		}
+		return domain.TOTPEnrollment{}, domain.InternalFailure(errors.New("Failed to get customer: " + err.Error()))
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, domain.InternalFailure(errors.New("Failed to generate secret: " + err.Error()))
+	}
+
+	err = cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		credentials, err := cs.lockCredentials(repositories, customerID)
+		if err != nil {
+			return err
+		}
+
+		if credentials.HasTwoFactor() {
+			return domain.ConflictError(errors.New("Two-factor authentication is already enabled"))
+		}
+
+		credentials.TOTPSecret = secret
+		credentials.TOTPLastStep = 0
+		return cs.saveCredentials(repositories, credentials)
+	})
+	if err != nil {
+		return domain.TOTPEnrollment{}, domain.AsDomainError(err)
+	}
+
+	return domain.TOTPEnrollment{
+		Secret: EncodeTOTPSecret(secret),
+		URI:    TOTPURI(secret, TOTP_ISSUER, owner.Email),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication and returns the recovery codes, they are shown only this once.
+func (cs *CredentialService) ConfirmTOTP(customerID uuid.UUID, body domain.TwoFactorCodeRequest) ([]string, error) {
+	var plaintexts []string
+
+	err := cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		credentials, err := cs.lockCredentials(repositories, customerID)
+		if err != nil {
+			return err
+		}
+
+		if credentials.HasTwoFactor() {
+			return domain.ConflictError(errors.New("Two-factor authentication is already enabled"))
+		}
+
+		if len(credentials.TOTPSecret) == 0 {
+			return domain.BadRequestError(errors.New("Two-factor enrolment was not started"))
+		}
+
+		now := cs.Clock.Now()
+
+		if err := checkAttempts(repositories, customerID, now); err != nil {
+			return err
+		}
+
+		step, ok := VerifyTOTP(credentials.TOTPSecret, body.Code, now, credentials.TOTPLastStep)
+		if !ok {
+			return cs.reject(customerID, now)
+		}
+
+		if err := clearAttempts(repositories, customerID); err != nil {
+			return err
+		}
+
+		credentials.TOTPEnabled = true
+		credentials.TOTPLastStep = step
+		if err := cs.saveCredentials(repositories, credentials); err != nil {
+			return err
+		}
+
+		var codes []domain.RecoveryCode
+		codes, plaintexts, err = newRecoveryCodes(customerID, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = repositories.ReplaceRecoveryCodes(customerID, codes)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to create recovery codes: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, domain.AsDomainError(err)
+	}
+
+	return plaintexts, nil
+}
+
+// DisableTOTP turns two-factor authentication off, which needs one last valid code.
+func (cs *CredentialService) DisableTOTP(customerID uuid.UUID, body domain.TwoFactorCodeRequest) error {
+	err := cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		if err := cs.VerifySecondFactor(repositories, customerID, body.Code, cs.Clock.Now()); err != nil {
+			return err
+		}
+
+		credentials, err := cs.lockCredentials(repositories, customerID)
+		if err != nil {
+			return err
+		}
+
+		credentials.TOTPSecret = nil
+		credentials.TOTPEnabled = false
+		credentials.TOTPLastStep = 0
+		if err := cs.saveCredentials(repositories, credentials); err != nil {
+			return err
+		}
+
+		_, err = repositories.ReplaceRecoveryCodes(customerID, nil)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to delete recovery codes: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.AsDomainError(err)
+	}
+
+	return nil
+}
+
+// Authenticate checks the password and, when the customer enabled it, the second factor.
+func (cs *CredentialService) Authenticate(body domain.PasswordLoginRequest) error {
+	credentials, err := cs.CredentialRepository.GetCredentials(body.CustomerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.UnauthorizedError(errors.New("Bad credentials"))
+		}
+		return domain.InternalFailure(errors.New("Failed to get credentials: " + err.Error()))
+	}
+
+	if !credentials.HasPassword() {
+		return domain.UnauthorizedError(errors.New("Bad credentials"))
+	}
+
+	matches, err := VerifyPassword(body.Password, credentials.PasswordHash)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to verify password: " + err.Error()))
+	}
+	if !matches {
+		return domain.UnauthorizedError(errors.New("Bad credentials"))
+	}
+
+	return cs.CheckSecondFactor(body.CustomerID, body.Code)
+}
+
+// CheckSecondFactor verifies the code of a login when the customer enabled two-factor
+// authentication, customers without it need no code.
+func (cs *CredentialService) CheckSecondFactor(customerID uuid.UUID, code string) error {
+	credentials, err := cs.CredentialRepository.GetCredentials(customerID)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.InternalFailure(errors.New("Failed to get credentials: " + err.Error()))
+	}
+
+	if !credentials.HasTwoFactor() {
+		return nil
+	}
+
+	err = cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		return cs.VerifySecondFactor(repositories, customerID, code, cs.Clock.Now())
+	})
+	if err != nil {
+		return domain.AsDomainError(err)
+	}
+
+	return nil
+}
+
+// VerifySecondFactor checks a TOTP or recovery code of the customer inside the database
+// transaction of the caller and uses it up, customers without two-factor authentication are forbidden.
+// After MAX_SECOND_FACTOR_ATTEMPTS invalid codes no code is accepted for a while.
+func (cs *CredentialService) VerifySecondFactor(repositories ports.IRepositories, customerID uuid.UUID, code string, at time.Time) error {
+	credentials, err := repositories.GetCredentialsForUpdate(customerID)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.InternalFailure(fmt.Errorf("Failed to get credentials: %w", err))
+	}
+
+	if !credentials.HasTwoFactor() {
+		return domain.ForbiddenError(errors.New("Two-factor authentication is not enabled"))
+	}
+
+	if strings.TrimSpace(code) == "" {
+		return domain.UnauthorizedError(errors.New("Two-factor code is required"))
+	}
+
+	if err := checkAttempts(repositories, customerID, at); err != nil {
+		return err
+	}
+
+	if step, ok := VerifyTOTP(credentials.TOTPSecret, code, at, credentials.TOTPLastStep); ok {
+		credentials.TOTPLastStep = step
+		if err := cs.saveCredentials(repositories, credentials); err != nil {
+			return err
+		}
+		return clearAttempts(repositories, customerID)
+	}
+
+	codes, err := repositories.GetUnusedRecoveryCodes(customerID)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to get recovery codes: %w", err))
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	for _, recovery := range codes {
+		if !customer.VerifyToken(normalized, recovery.Salt, recovery.Hash) {
+			continue
+		}
+
+		affectedRows, err := repositories.UseRecoveryCode(recovery.ID, at)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to use recovery code: %w", err))
+		}
+		if affectedRows == 0 {
+			break
+		}
+
+		return clearAttempts(repositories, customerID)
+	}
+
+	return cs.reject(customerID, at)
+}
+
+// reject counts the invalid code outside the database transaction of the caller, which is rolled
+// back on the error, and returns the error.
+func (cs *CredentialService) reject(customerID uuid.UUID, at time.Time) error {
+	if _, err := cs.CredentialRepository.CreateSecondFactorFailure(customerID, at); err != nil {
+		log.Printf("[ERROR]\tFailed to record invalid two-factor code of customer %s: %v", customerID.String(), err)
+	}
+
+	return domain.UnauthorizedError(errors.New("Invalid two-factor code"))
+}
+
+// checkAttempts rejects every code once the customer entered too many invalid ones recently.
+func checkAttempts(repositories ports.IRepositories, customerID uuid.UUID, at time.Time) error {
+	failures, err := repositories.CountSecondFactorFailures(customerID, at.Add(-domain.SECOND_FACTOR_LOCKOUT))
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to count invalid two-factor codes: %w", err))
+	}
+
+	if failures >= domain.MAX_SECOND_FACTOR_ATTEMPTS {
+		return domain.UnauthorizedError(errors.New("Too many invalid two-factor codes, try again later"))
+	}
+
+	return nil
+}
+
+// clearAttempts forgets the invalid codes after a valid one.
+func clearAttempts(repositories ports.IRepositories, customerID uuid.UUID) error {
+	_, err := repositories.DeleteSecondFactorFailures(customerID)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to delete invalid two-factor codes: %w", err))
+	}
+
+	return nil
+}
+
+// lockCredentials returns the credentials of the customer, or new ones when they have none yet.
+func (cs *CredentialService) lockCredentials(repositories ports.IRepositories, customerID uuid.UUID) (domain.Credentials, error) {
+	credentials, err := repositories.GetCredentialsForUpdate(customerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Credentials{CustomerID: customerID, CreatedAt: cs.Clock.Now()}, nil
+		}
+		return domain.Credentials{}, domain.InternalFailure(fmt.Errorf("Failed to get credentials: %w", err))
+	}
+
+	return credentials, nil
+}
+
+func (cs *CredentialService) saveCredentials(repositories ports.IRepositories, credentials domain.Credentials) error {
+	credentials.UpdatedAt = cs.Clock.Now()
+
+	_, err := repositories.SaveCredentials(credentials)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to save credentials: %w", err))
+	}
+
+	return nil
+}
+
+// newRecoveryCodes creates the hashed recovery codes and their plaintexts, like abcd-efgh-ijkl-mnop.
+func newRecoveryCodes(customerID uuid.UUID, at time.Time) ([]domain.RecoveryCode, []string, error) {
+	codes := make([]domain.RecoveryCode, 0, domain.RECOVERY_CODE_COUNT)
+	plaintexts := make([]string, 0, domain.RECOVERY_CODE_COUNT)
+
+	for i := 0; i < domain.RECOVERY_CODE_COUNT; i++ {
+		random := make([]byte, 10)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, domain.InternalFailure(errors.New("Failed to generate recovery code: " + err.Error()))
+		}
+
+		salt, err := customer.GenerateTokenSalt()
+		if err != nil {
+			return nil, nil, domain.InternalFailure(errors.New("Failed to generate recovery code: " + err.Error()))
+		}
+
+		code := strings.ToLower(base32NoPadding.EncodeToString(random))
+
+		codes = append(codes, domain.RecoveryCode{
+			ID:         uuid.New(),
+			CustomerID: customerID,
+			Salt:       salt,
+			Hash:       customer.HashToken(code, salt),
+			CreatedAt:  at,
+		})
+		plaintexts = append(plaintexts, code[0:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:16])
+	}
+
+	return codes, plaintexts, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
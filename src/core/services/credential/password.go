@@ -0,0 +1,72 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters recommended by RFC 9106 for memory constrained servers.
+const (
+	ARGON2_TIME        = 3
+	ARGON2_MEMORY      = 64 * 1024 // KiB
+	ARGON2_THREADS     = 4
+	ARGON2_KEY_LENGTH  = 32
+	ARGON2_SALT_LENGTH = 16
+)
+
+// HashPassword hashes the password with argon2id and encodes it together with its
+// parameters, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, ARGON2_SALT_LENGTH)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, ARGON2_TIME, ARGON2_MEMORY, ARGON2_THREADS, ARGON2_KEY_LENGTH)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, ARGON2_MEMORY, ARGON2_TIME, ARGON2_THREADS,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// VerifyPassword checks the password against the encoded hash, using the parameters
+// stored with it so hashes made with older parameters keep working.
+func VerifyPassword(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errors.New("unsupported password hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errors.New("unsupported argon2 version")
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, errors.New("invalid argon2 parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errors.New("invalid password salt")
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, errors.New("invalid password hash")
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(hash)))
+
+	return subtle.ConstantTimeCompare(computed, hash) == 1, nil
+}
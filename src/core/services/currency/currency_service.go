@@ -0,0 +1,86 @@
+package currency
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// CurrencyService tells which currencies the bank offers. They are read from the database on every
+// call, so enabling or disabling one applies to every instance of the server right away.
+type CurrencyService struct {
+	CurrencyRepository ports.ICurrencyRepository
+	Registry           *domain.CurrencyRegistry
+}
+
+func NewCurrencyService(currencyRepository ports.ICurrencyRepository, registry *domain.CurrencyRegistry) *CurrencyService {
+	return &CurrencyService{
+		CurrencyRepository: currencyRepository,
+		Registry:           registry,
+	}
+}
+
+func (cs *CurrencyService) Index(enabledOnly bool) ([]domain.CurrencyInfo, error) {
+	enabled, err := cs.enabled()
+	if err != nil {
+		return nil, err
+	}
+
+	var currencies []domain.CurrencyInfo
+	for _, currency := range cs.Registry.All() {
+		currency.Enabled = slices.Contains(enabled, currency.Code)
+
+		if currency.Enabled || !enabledOnly {
+			currencies = append(currencies, currency)
+		}
+	}
+
+	return currencies, nil
+}
+
+func (cs *CurrencyService) SetEnabled(code domain.Currency, enabled bool) (domain.CurrencyInfo, error) {
+	code = domain.Currency(strings.ToUpper(string(code)))
+
+	currency, ok := cs.Registry.Lookup(code)
+	if !ok {
+		return domain.CurrencyInfo{}, domain.NotFoundError(errors.New("Currency " + string(code) + " is not an ISO 4217 currency"))
+	}
+
+	_, err := cs.CurrencyRepository.SetCurrencyEnabled(code, enabled)
+	if err != nil {
+		return domain.CurrencyInfo{}, domain.InternalFailure(errors.New("Failed to update currency: " + err.Error()))
+	}
+
+	currency.Enabled = enabled
+	return currency, nil
+}
+
+// CheckEnabled rejects new business in currencies the bank doesnt offer, like opening an account or
+// making a transfer. Money already held in a disabled currency can still be swept, reversed,
+// refunded and earn interest.
+func (cs *CurrencyService) CheckEnabled(codes ...domain.Currency) error {
+	enabled, err := cs.enabled()
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if !slices.Contains(enabled, code) {
+			return domain.ValidationError(&domain.ValidationErrors{Errors: []string{"This currency is not supported!"}})
+		}
+	}
+
+	return nil
+}
+
+func (cs *CurrencyService) enabled() ([]domain.Currency, error) {
+	enabled, err := cs.CurrencyRepository.GetEnabledCurrencies()
+	if err != nil {
+		return nil, domain.InternalFailure(errors.New("Failed to get currencies: " + err.Error()))
+	}
+
+	return enabled, nil
+}
@@ -3,6 +3,7 @@ package customer
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,12 +14,18 @@ import (
 const TOKEN_LENGTH = 64
 
 type CustomerService struct {
-	CustomerRepository ports.ICustomerRepository
+	CustomerRepository      ports.ICustomerRepository
+	CustomerTokenRepository ports.ICustomerTokenRepository
+	GeneralRepository       ports.IRepository
+	Clock                   ports.IClock
 }
 
-func NewCustomerService(customerRepository ports.ICustomerRepository) *CustomerService {
+func NewCustomerService(customerRepository ports.ICustomerRepository, customerTokenRepository ports.ICustomerTokenRepository, generalRepository ports.IRepository, clock ports.IClock) *CustomerService {
 	return &CustomerService{
-		CustomerRepository: customerRepository,
+		CustomerRepository:      customerRepository,
+		CustomerTokenRepository: customerTokenRepository,
+		GeneralRepository:       generalRepository,
+		Clock:                   clock,
 	}
 }
 
@@ -56,17 +63,36 @@ func (cs *CustomerService) Create(body domain.CreateCustomerRequest) (domain.Cus
 		Phone:     body.Phone,
 		State:     body.State,
 		Address:   body.Address,
-		CreatedAt: time.Now(),
+		CreatedAt: cs.Clock.Now(),
+		Role:      domain.ROLE_CUSTOMER,
 		Token:     GenerateToken(),
 	}
 
-	if err := customer.Validate(); err != nil {
+	if err := customer.Validate(cs.Clock.Now()); err != nil {
 		return domain.Customer{}, domain.ValidationError(err)
 	}
 
-	_, err := cs.CustomerRepository.CreateCustomer(customer)
+	// The token the customer gets is stored as their first token
+	token, err := cs.newCustomerToken(customer.ID, "default", customer.Token, time.Time{})
 	if err != nil {
-		return domain.Customer{}, domain.InternalFailure(errors.New("Failed to create customer: "+err.Error()))
+		return domain.Customer{}, err
+	}
+
+	err = cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		_, err := repositories.CreateCustomer(customer)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to create customer: %w", err))
+		}
+
+		_, err = repositories.CreateCustomerToken(token)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to create token: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Customer{}, domain.AsDomainError(err)
 	}
 
 	return customer, nil
@@ -84,7 +110,7 @@ func (cs *CustomerService) Update(customerID uuid.UUID, body domain.UpdateCustom
 		Address:   body.Address,
 	}
 
-	if err := customer.Validate(); err != nil {
+	if err := customer.Validate(cs.Clock.Now()); err != nil {
 		return 0, domain.ValidationError(err)
 	}
 
@@ -119,11 +145,130 @@ func (cs *CustomerService) Delete(customerID uuid.UUID) (int64, error) {
 	return affectedRows, nil
 }
 
+// SetRole grants the customer a role, it takes effect with the next access token they get.
+func (cs *CustomerService) SetRole(customerID uuid.UUID, body domain.UpdateRoleRequest) (domain.Customer, error) {
+	if !body.Role.IsValid() {
+		return domain.Customer{}, domain.ValidationError(&domain.ValidationErrors{Errors: []string{"role is not valid"}})
+	}
+
+	affectedRows, err := cs.CustomerRepository.UpdateCustomerRole(customerID, body.Role)
+	if err != nil {
+		return domain.Customer{}, domain.InternalFailure(errors.New("Failed to update role: "+err.Error()))
+	}
+
+	if affectedRows == 0 {
+		return domain.Customer{}, domain.NotFoundError(errors.New("Customer not found"))
+	}
+
+	return cs.Get(customerID)
+}
+
+// Auth checks the token against the active tokens of the customer and records its use.
 func (cs *CustomerService) Auth(customerID uuid.UUID, token string) (bool, error) {
-	authenticated, err := cs.CustomerRepository.AuthCustomer(customerID, token)
+	now := cs.Clock.Now()
+
+	tokens, err := cs.CustomerTokenRepository.GetActiveCustomerTokens(customerID, now)
+	if err != nil {
+		return false, domain.InternalFailure(errors.New("Failed to get tokens: " + err.Error()))
+	}
+
+	for _, stored := range tokens {
+		if !VerifyToken(token, stored.Salt, stored.Hash) {
+			continue
+		}
+
+		_, err := cs.CustomerTokenRepository.TouchCustomerToken(stored.ID, now)
+		if err != nil {
+			return false, domain.InternalFailure(errors.New("Failed to update token: " + err.Error()))
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// CreateToken creates a new named token, it is returned in plaintext only this once.
+func (cs *CustomerService) CreateToken(customerID uuid.UUID, body domain.CreateCustomerTokenRequest) (domain.CustomerToken, string, error) {
+	plaintext := GenerateToken()
+
+	token, err := cs.newCustomerToken(customerID, body.Name, plaintext, body.ExpiresAt)
 	if err != nil {
-		return false, err
+		return domain.CustomerToken{}, "", err
+	}
+
+	_, err = cs.CustomerTokenRepository.CreateCustomerToken(token)
+	if err != nil {
+		return domain.CustomerToken{}, "", domain.InternalFailure(errors.New("Failed to create token: " + err.Error()))
+	}
+
+	return token, plaintext, nil
+}
+
+func (cs *CustomerService) Tokens(customerID uuid.UUID) ([]domain.CustomerToken, error) {
+	tokens, err := cs.CustomerTokenRepository.GetCustomerTokens(customerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Tokens not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get tokens: " + err.Error()))
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken revokes the token together with every refresh token family of the customer. The
+// families dont remember which token they were started with, and a leaked token must not live on
+// as a session.
+func (cs *CustomerService) RevokeToken(customerID, tokenID uuid.UUID) (int64, error) {
+	var affectedRows int64
+	now := cs.Clock.Now()
+
+	err := cs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		affectedRows, err = repositories.RevokeCustomerToken(customerID, tokenID, now)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to revoke token: " + err.Error()))
+		}
+
+		if affectedRows == 0 {
+			return domain.NotFoundError(errors.New("Token not found or already revoked"))
+		}
+
+		_, err = repositories.RevokeCustomerRefreshTokens(customerID, now)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to revoke refresh tokens: " + err.Error()))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, domain.AsDomainError(err)
+	}
+
+	return affectedRows, nil
+}
+
+func (cs *CustomerService) newCustomerToken(customerID uuid.UUID, name string, plaintext string, expiresAt time.Time) (domain.CustomerToken, error) {
+	salt, err := GenerateTokenSalt()
+	if err != nil {
+		return domain.CustomerToken{}, domain.InternalFailure(errors.New("Failed to generate token salt: " + err.Error()))
+	}
+
+	token := domain.CustomerToken{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Name:       name,
+		Salt:       salt,
+		Hash:       HashToken(plaintext, salt),
+		CreatedAt:  cs.Clock.Now(),
+		ExpiresAt:  expiresAt,
+	}
+
+	if err := token.Validate(); err != nil {
+		return domain.CustomerToken{}, domain.ValidationError(err)
 	}
 
-	return authenticated, nil
+	return token, nil
 }
@@ -2,11 +2,15 @@ package customer
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
 	"strings"
 )
 
+const TOKEN_SALT_LENGTH = 16
+
 func GetTokenFromHeader(header string) (string, error) {
 	slicedHeader := strings.Split(header, " ")
 
@@ -32,4 +36,27 @@ func GenerateToken() string {
 
 	token := hex.EncodeToString(tokenBytes)
 	return token
-}
\ No newline at end of file
+}
+
+func GenerateTokenSalt() ([]byte, error) {
+	salt := make([]byte, TOKEN_SALT_LENGTH)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// HashToken hashes the salted token. Tokens are long random strings, so a
+// single round of SHA-256 is enough to keep them safe if the database leaks.
+func HashToken(token string, salt []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, salt...), token...))
+	return hash[:]
+}
+
+// VerifyToken compares the token with the stored hash in constant time.
+func VerifyToken(token string, salt, hash []byte) bool {
+	return subtle.ConstantTimeCompare(HashToken(token, salt), hash) == 1
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type ExchangeService struct {
+	ExchangeRateProvider ports.IExchangeRateProvider
+	BaseCurrency         domain.Currency // Pairs without a rate of their own are crossed through this currency
+}
+
+func NewExchangeService(exchangeRateProvider ports.IExchangeRateProvider, baseCurrency domain.Currency) *ExchangeService {
+	return &ExchangeService{
+		ExchangeRateProvider: exchangeRateProvider,
+		BaseCurrency:         baseCurrency,
+	}
+}
+
+// Rate returns the rate of the pair effective at the given time, falling back
+// to the inverse of the opposite pair and then to a cross rate through the base currency.
+func (es *ExchangeService) Rate(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	if pair.From == pair.To {
+		return domain.IdentityRate(pair.From, at), nil
+	}
+
+	rate, err := es.direct(pair, at)
+	if err == nil {
+		return rate, nil
+	}
+	if err != sql.ErrNoRows {
+		return domain.ExchangeRate{}, domain.InternalFailure(errors.New("Failed to get exchange rate: " + err.Error()))
+	}
+
+	if es.BaseCurrency != "" && pair.From != es.BaseCurrency && pair.To != es.BaseCurrency {
+		rate, err := es.cross(pair, at)
+		if err == nil {
+			return rate, nil
+		}
+		if err != sql.ErrNoRows {
+			return domain.ExchangeRate{}, domain.InternalFailure(errors.New("Failed to get exchange rate: " + err.Error()))
+		}
+	}
+
+	return domain.ExchangeRate{}, domain.BadRequestError(errors.New("No exchange rate available for " + string(pair.From) + "-" + string(pair.To)))
+}
+
+// Convert converts the money into the target currency with the rate effective at the given time.
+func (es *ExchangeService) Convert(amount domain.Money, to domain.Currency, at time.Time) (domain.Money, domain.ExchangeRate, error) {
+	rate, err := es.Rate(domain.NewCurrencyPair(amount.Currency, to), at)
+	if err != nil {
+		return domain.Money{}, domain.ExchangeRate{}, err
+	}
+
+	return rate.Convert(amount), rate, nil
+}
+
+// direct looks up the pair itself or the inverse of the opposite pair, returning sql.ErrNoRows when neither is known.
+func (es *ExchangeService) direct(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	rate, err := es.lookup(pair, at)
+	if err != sql.ErrNoRows {
+		return rate, err
+	}
+
+	inverse, err := es.lookup(domain.NewCurrencyPair(pair.To, pair.From), at)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	return inverse.Inverse(), nil
+}
+
+// cross combines the rates of both currencies against the base currency.
+func (es *ExchangeService) cross(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	toBase, err := es.direct(domain.NewCurrencyPair(pair.From, es.BaseCurrency), at)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	fromBase, err := es.direct(domain.NewCurrencyPair(es.BaseCurrency, pair.To), at)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	return toBase.Cross(fromBase), nil
+}
+
+func (es *ExchangeService) lookup(pair domain.CurrencyPair, at time.Time) (domain.ExchangeRate, error) {
+	return es.ExchangeRateProvider.GetExchangeRate(pair, at)
+}
@@ -0,0 +1,110 @@
+package fx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type FXService struct {
+	FXQuoteRepository ports.IFXQuoteRepository
+	ExchangeService   ports.IExchangeService
+	CurrencyService   ports.ICurrencyService
+	Pricing           domain.FXPricing
+	Clock             ports.IClock
+}
+
+func NewFXService(fxQuoteRepository ports.IFXQuoteRepository, exchangeService ports.IExchangeService, currencyService ports.ICurrencyService, pricing domain.FXPricing, clock ports.IClock) *FXService {
+	return &FXService{
+		FXQuoteRepository: fxQuoteRepository,
+		ExchangeService:   exchangeService,
+		CurrencyService:   currencyService,
+		Pricing:           pricing,
+		Clock:             clock,
+	}
+}
+
+// Quote prices the conversion for the customer and stores it, so a transfer from one of their
+// accounts can use it until it expires.
+func (fs *FXService) Quote(customerID uuid.UUID, body domain.CreateFXQuoteRequest) (domain.FXQuote, error) {
+	from := domain.Currency(strings.ToUpper(body.From))
+	to := domain.Currency(strings.ToUpper(body.To))
+
+	amount, err := domain.ParseMoney(body.Amount.String(), from)
+	if err != nil {
+		return domain.FXQuote{}, domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+	}
+
+	if err := fs.CurrencyService.CheckEnabled(from, to); err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	quote, err := fs.Price(amount, to, fs.Clock.Now())
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+	quote.CustomerID = customerID
+
+	if err := quote.Validate(); err != nil {
+		return domain.FXQuote{}, domain.ValidationError(err)
+	}
+
+	_, err = fs.FXQuoteRepository.CreateFXQuote(quote)
+	if err != nil {
+		return domain.FXQuote{}, domain.InternalFailure(errors.New("Failed to create quote: " + err.Error()))
+	}
+
+	return quote, nil
+}
+
+// Price prices the conversion with the rate effective at the given time without storing it.
+func (fs *FXService) Price(amount domain.Money, to domain.Currency, at time.Time) (domain.FXQuote, error) {
+	rate, err := fs.ExchangeService.Rate(domain.NewCurrencyPair(amount.Currency, to), at)
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	return domain.NewFXQuote(amount, rate, fs.Pricing, at), nil
+}
+
+// Redeem marks the quote as used by the transaction of the customer. It fails when the quote has
+// expired, was already used or was made for a different conversion. Quotes of other customers aren't found.
+func (fs *FXService) Redeem(repositories ports.IRepositories, quoteID uuid.UUID, customerID uuid.UUID, amount domain.Money, to domain.Currency, transactionID uuid.UUID, at time.Time) (domain.FXQuote, error) {
+	quote, err := repositories.GetFXQuoteForUpdate(quoteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.FXQuote{}, domain.NotFoundError(errors.New("Quote not found"))
+		}
+		return domain.FXQuote{}, domain.InternalFailure(fmt.Errorf("Failed to get quote: %w", err))
+	}
+
+	if quote.CustomerID != customerID {
+		return domain.FXQuote{}, domain.NotFoundError(errors.New("Quote not found"))
+	}
+
+	if quote.IsUsed() {
+		return domain.FXQuote{}, domain.ConflictError(errors.New("Quote was already used"))
+	}
+
+	if quote.IsExpired(at) {
+		return domain.FXQuote{}, domain.UnprocessableError(errors.New("Quote has expired"))
+	}
+
+	if !quote.Matches(amount, to) {
+		return domain.FXQuote{}, domain.BadRequestError(errors.New("Quote doesnt match the transfer, it was made for " + quote.Amount.String() + " " + quote.Pair.String()))
+	}
+
+	_, err = repositories.UseFXQuote(quote.ID, transactionID)
+	if err != nil {
+		return domain.FXQuote{}, domain.InternalFailure(fmt.Errorf("Failed to use quote: %w", err))
+	}
+	quote.TransactionID = transactionID
+
+	return quote, nil
+}
@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type IdempotencyService struct {
+	IdempotencyRepository ports.IIdempotencyRepository
+	Clock                 ports.IClock
+}
+
+func NewIdempotencyService(idempotencyRepository ports.IIdempotencyRepository, clock ports.IClock) *IdempotencyService {
+	return &IdempotencyService{
+		IdempotencyRepository: idempotencyRepository,
+		Clock:                 clock,
+	}
+}
+
+// Begin reserves the key of the principal for a request. When the same request was already completed
+// the stored key is returned with replay set to true, so its response can be sent again.
+func (is *IdempotencyService) Begin(principal string, key string, requestHash string) (domain.IdempotencyKey, bool, error) {
+	if len(key) > domain.MAX_IDEMPOTENCY_KEY_LENGTH {
+		return domain.IdempotencyKey{}, false, domain.BadRequestError(errors.New("Idempotency-Key must not be longer than " + strconv.Itoa(domain.MAX_IDEMPOTENCY_KEY_LENGTH) + " characters"))
+	}
+
+	existing, err := is.IdempotencyRepository.GetIdempotencyKey(principal, key)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.IdempotencyKey{}, false, domain.InternalFailure(errors.New("Failed to get idempotency key: " + err.Error()))
+	}
+
+	if err == nil {
+		if existing.IsExpired(is.Clock.Now()) {
+			if _, err := is.IdempotencyRepository.DeleteIdempotencyKey(principal, key); err != nil {
+				return domain.IdempotencyKey{}, false, domain.InternalFailure(errors.New("Failed to delete idempotency key: " + err.Error()))
+			}
+		} else {
+			return existing, true, checkReplay(existing, requestHash)
+		}
+	}
+
+	idempotencyKey := domain.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   is.Clock.Now(),
+		ExpiresAt:   is.Clock.Now().Add(domain.IDEMPOTENCY_KEY_TTL),
+		Principal:   principal,
+	}
+
+	affectedRows, err := is.IdempotencyRepository.CreateIdempotencyKey(idempotencyKey)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, domain.InternalFailure(errors.New("Failed to create idempotency key: " + err.Error()))
+	}
+
+	// Another request with the same key got in first
+	if affectedRows == 0 {
+		return domain.IdempotencyKey{}, false, domain.ConflictError(errors.New("A request with this Idempotency-Key is still being processed"))
+	}
+
+	return idempotencyKey, false, nil
+}
+
+// Complete stores the response so repeated requests get the same answer.
+func (is *IdempotencyService) Complete(key domain.IdempotencyKey) error {
+	_, err := is.IdempotencyRepository.UpdateIdempotencyKey(key)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to update idempotency key: " + err.Error()))
+	}
+
+	return nil
+}
+
+// Release forgets the key, so a request that didnt succeed can be retried with it.
+func (is *IdempotencyService) Release(key domain.IdempotencyKey) error {
+	_, err := is.IdempotencyRepository.DeleteIdempotencyKey(key.Principal, key.Key)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to delete idempotency key: " + err.Error()))
+	}
+
+	return nil
+}
+
+func checkReplay(existing domain.IdempotencyKey, requestHash string) error {
+	if existing.RequestHash != requestHash {
+		return domain.UnprocessableError(errors.New("Idempotency-Key was already used with a different request"))
+	}
+
+	if !existing.IsCompleted() {
+		return domain.ConflictError(errors.New("A request with this Idempotency-Key is still being processed"))
+	}
+
+	return nil
+}
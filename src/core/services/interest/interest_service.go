@@ -0,0 +1,201 @@
+package interest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// InterestService accrues the interest of every day into the accounts and posts it at the end of
+// the month. Each account remembers the last day it was accrued through, so days missed while the
+// server was down are caught up and running it twice never accrues a day twice. It runs as a job
+// of the scheduler.
+type InterestService struct {
+	AccountRepository  ports.IAccountRepository
+	GeneralRepository  ports.IRepository
+	TransactionService ports.ITransactionService
+}
+
+func NewInterestService(accountRepository ports.IAccountRepository, generalRepository ports.IRepository, transactionService ports.ITransactionService) *InterestService {
+	return &InterestService{
+		AccountRepository:  accountRepository,
+		GeneralRepository:  generalRepository,
+		TransactionService: transactionService,
+	}
+}
+
+// Accrue accrues every account through the last day finished before now, in UTC. Every account
+// is accrued in a transaction of its own so a failing one doesnt hold up the others.
+func (is *InterestService) Accrue(now time.Time) error {
+	through := domain.StartOfDay(now).AddDate(0, 0, -1)
+
+	accountIDs, err := is.AccountRepository.GetAccountsToAccrue(through)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return domain.InternalFailure(errors.New("Failed to get accounts: " + err.Error()))
+	}
+
+	failed := 0
+	for _, accountID := range accountIDs {
+		if err := is.AccrueAccount(accountID, now); err != nil {
+			log.Printf("[ERROR]\tFailed to accrue interest of account %s: %v", accountID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return domain.InternalFailure(fmt.Errorf("Failed to accrue interest of %d out of %d accounts", failed, len(accountIDs)))
+	}
+
+	log.Printf("[EVENT]\tAccrued interest of %d accounts through %s", len(accountIDs), through.Format(time.DateOnly))
+	return nil
+}
+
+// AccrueAccount accrues the account for every day after the last accrued one that finished
+// before now.
+func (is *InterestService) AccrueAccount(accountID uuid.UUID, now time.Time) error {
+	through := domain.StartOfDay(now).AddDate(0, 0, -1)
+
+	return is.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		account, err := getAccount(repositories, accountID)
+		if err != nil {
+			return err
+		}
+
+		// Another run got here first
+		if !account.InterestAccruedThrough.Before(through) || account.Status == domain.AccountClosed {
+			return nil
+		}
+
+		accrued, err := is.accrue(repositories, account, through, now)
+		if err != nil {
+			return err
+		}
+
+		return updateAccrued(repositories, account.ID, accrued, through)
+	})
+}
+
+// Settle accrues the account through the day of now and posts all of the interest accrued since
+// the last posting, running inside the transaction closing the account. The remainder below a
+// minor unit is dropped.
+func (is *InterestService) Settle(repositories ports.IRepositories, accountID uuid.UUID, now time.Time) error {
+	through := domain.StartOfDay(now)
+
+	account, err := getAccount(repositories, accountID)
+	if err != nil {
+		return err
+	}
+
+	accrued := account.Accrued()
+	if account.InterestAccruedThrough.Before(through) {
+		accrued, err = is.accrue(repositories, account, through, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	posting, err := domain.MoneyFromRat(accrued, account.Currency)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to round interest: " + err.Error()))
+	}
+
+	if !posting.IsZero() {
+		if _, err := is.TransactionService.PostInterest(repositories, account.ID, posting, now); err != nil {
+			return err
+		}
+	}
+
+	return updateAccrued(repositories, account.ID, new(big.Rat), through)
+}
+
+// accrue accrues the locked account for every day after the last accrued one through the given
+// day and returns the interest left accrued. The balance each day ended with is rebuilt from the
+// ledger, and the interest accrued by the end of every month is posted with the remainder below
+// a minor unit carried on.
+func (is *InterestService) accrue(repositories ports.IRepositories, account domain.Account, through, now time.Time) (*big.Rat, error) {
+	accrued := account.Accrued()
+
+	// Nothing to accrue, only move on to the day
+	if !account.EarnsInterest() && accrued.Sign() == 0 {
+		return accrued, nil
+	}
+
+	first := account.InterestAccruedThrough.AddDate(0, 0, 1)
+
+	entries, err := repositories.GetLedgerEntriesSince(account.ID, first)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, domain.InternalFailure(errors.New("Failed to get ledger entries: " + err.Error()))
+	}
+
+	// Undo everything posted since, leaving the balance the last accrued day ended with
+	balance := account.Balance
+	for _, entry := range entries {
+		balance = balance.Sub(entry.Signed())
+	}
+
+	var postings []domain.Money
+
+	for day := first; !day.After(through); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		for len(entries) > 0 && entries[0].CreatedAt.Before(next) {
+			balance = balance.Add(entries[0].Signed())
+			entries = entries[1:]
+		}
+
+		accrued = domain.RoundInterest(accrued.Add(accrued, account.DailyInterest(balance, day)))
+
+		if !domain.IsMonthEnd(day) {
+			continue
+		}
+
+		posting, err := domain.MoneyFromRat(accrued, account.Currency)
+		if err != nil {
+			return nil, domain.InternalFailure(errors.New("Failed to round interest: " + err.Error()))
+		}
+
+		if !posting.IsZero() {
+			postings = append(postings, posting)
+			accrued.Sub(accrued, posting.Rat())
+			balance = balance.Add(posting)
+		}
+	}
+
+	for _, posting := range postings {
+		if _, err := is.TransactionService.PostInterest(repositories, account.ID, posting, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return accrued, nil
+}
+
+func getAccount(repositories ports.IRepositories, accountID uuid.UUID) (domain.Account, error) {
+	account, err := repositories.GetAccountForUpdate(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Account{}, domain.NotFoundError(errors.New("Account not found"))
+		}
+		return domain.Account{}, domain.InternalFailure(errors.New("Failed to get account: " + err.Error()))
+	}
+
+	return account, nil
+}
+
+func updateAccrued(repositories ports.IRepositories, accountID uuid.UUID, accrued *big.Rat, through time.Time) error {
+	_, err := repositories.UpdateAccruedInterest(accountID, accrued, through)
+	if err != nil {
+		return domain.InternalFailure(errors.New("Failed to update accrued interest: " + err.Error()))
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// JobService runs the background jobs on their schedules. Every run is recorded, and the advisory
+// lock of a job makes sure only one instance of the server runs it at a time. Failing or
+// panicking jobs are recorded as failed and never take the server down with them.
+type JobService struct {
+	JobRepository ports.IJobRepository
+	Clock         ports.IClock
+	jobs          []domain.Job
+}
+
+func NewJobService(jobRepository ports.IJobRepository, clock ports.IClock) *JobService {
+	return &JobService{
+		JobRepository: jobRepository,
+		Clock:         clock,
+	}
+}
+
+// Register adds a job running on the cron schedule, jobs have to be registered before Start.
+func (js *JobService) Register(name, schedule string, run domain.JobFunc) error {
+	parsed, err := domain.ParseSchedule(schedule)
+	if err != nil {
+		return domain.BadRequestError(err)
+	}
+
+	job := domain.Job{Name: name, Schedule: parsed, Run: run}
+
+	if err := job.Validate(); err != nil {
+		return domain.ValidationError(err)
+	}
+
+	if _, ok := js.job(name); ok {
+		return domain.ConflictError(errors.New("Job " + name + " is already registered"))
+	}
+
+	js.jobs = append(js.jobs, job)
+	return nil
+}
+
+func (js *JobService) Index() []domain.Job {
+	now := js.Clock.Now()
+
+	jobs := make([]domain.Job, len(js.jobs))
+	for i, job := range js.jobs {
+		job.NextRunAt = job.Schedule.Next(now)
+		jobs[i] = job
+	}
+
+	return jobs
+}
+
+func (js *JobService) Runs(name string, limit int, offset int) ([]domain.JobRun, error) {
+	if _, ok := js.job(name); !ok {
+		return nil, domain.NotFoundError(errors.New("Job not found"))
+	}
+
+	runs, err := js.JobRepository.GetJobRuns(name, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Job runs not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get job runs: " + err.Error()))
+	}
+
+	return runs, nil
+}
+
+// Trigger starts a run of the job right away, outside of its schedule. The run goes on in the
+// background and is returned while still running.
+func (js *JobService) Trigger(name string, triggeredBy uuid.UUID) (domain.JobRun, error) {
+	job, ok := js.job(name)
+	if !ok {
+		return domain.JobRun{}, domain.NotFoundError(errors.New("Job not found"))
+	}
+
+	run := domain.NewJobRun(job.Name, domain.JobTriggerManual, triggeredBy, time.Time{}, js.Clock.Now())
+
+	unlock, err := js.begin(run)
+	if err != nil {
+		return domain.JobRun{}, err
+	}
+
+	go js.execute(job, run, unlock)
+
+	return run, nil
+}
+
+// Start runs every job each time its schedule is due, it never returns. It looks at the clock
+// every JOB_POLL_INTERVAL, so jobs follow a fake clock as soon as it is moved.
+func (js *JobService) Start() {
+	next := make(map[string]time.Time)
+	for _, job := range js.jobs {
+		next[job.Name] = job.Schedule.Next(js.Clock.Now())
+	}
+
+	ticker := time.NewTicker(domain.JOB_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := js.Clock.Now()
+
+		for _, job := range js.jobs {
+			if next[job.Name].IsZero() || now.Before(next[job.Name]) {
+				continue
+			}
+
+			go js.fire(job, next[job.Name])
+			next[job.Name] = job.Schedule.Next(now)
+		}
+	}
+}
+
+// fire runs the job for a time of its schedule, unless another instance already runs it or ran it.
+func (js *JobService) fire(job domain.Job, scheduledFor time.Time) {
+	run := domain.NewJobRun(job.Name, domain.JobTriggerSchedule, uuid.Nil, scheduledFor, js.Clock.Now())
+
+	unlock, err := js.begin(run)
+	if err != nil {
+		if !errors.Is(err, domain.ErrConflict) {
+			log.Printf("[ERROR]\tFailed to start job %s: %v", job.Name, err)
+		}
+		return
+	}
+
+	js.execute(job, run, unlock)
+}
+
+// begin takes the lock of the job and records the start of the run, the returned func releases the lock.
+func (js *JobService) begin(run domain.JobRun) (func() error, error) {
+	unlock, locked, err := js.JobRepository.LockJob(run.Job)
+	if err != nil {
+		return nil, domain.InternalFailure(errors.New("Failed to lock job: " + err.Error()))
+	}
+
+	if !locked {
+		return nil, domain.ConflictError(errors.New("Job " + run.Job + " is already running"))
+	}
+
+	// Nobody else holds the lock, so runs still marked as running were cut off by an instance going down
+	if _, err := js.JobRepository.InterruptJobRuns(run.Job, run.StartedAt); err != nil {
+		unlock()
+		return nil, domain.InternalFailure(errors.New("Failed to interrupt job runs: " + err.Error()))
+	}
+
+	created, err := js.JobRepository.CreateJobRun(run)
+	if err != nil {
+		unlock()
+		return nil, domain.InternalFailure(errors.New("Failed to create job run: " + err.Error()))
+	}
+
+	if created == 0 {
+		unlock()
+		return nil, domain.ConflictError(errors.New("Job " + run.Job + " already ran at " + run.ScheduledFor.String()))
+	}
+
+	return unlock, nil
+}
+
+// execute runs the job and records how it ended, releasing its lock afterwards.
+func (js *JobService) execute(job domain.Job, run domain.JobRun, unlock func() error) {
+	defer func() {
+		if err := unlock(); err != nil {
+			log.Printf("[ERROR]\tFailed to unlock job %s: %v", job.Name, err)
+		}
+	}()
+
+	err := safely(job.Run, run.StartedAt)
+	run.Finish(err, js.Clock.Now())
+
+	if err != nil {
+		log.Printf("[ERROR]\tJob %s failed: %v", job.Name, err)
+	} else {
+		log.Printf("[EVENT]\tJob %s finished in %v", job.Name, run.FinishedAt.Sub(run.StartedAt))
+	}
+
+	if _, err := js.JobRepository.FinishJobRun(run); err != nil {
+		log.Printf("[ERROR]\tFailed to record the run of job %s: %v", job.Name, err)
+	}
+}
+
+// safely turns a panic of the job into an error.
+func safely(run domain.JobFunc, at time.Time) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Job panicked: %v", recovered)
+		}
+	}()
+
+	return run(at)
+}
+
+func (js *JobService) job(name string) (domain.Job, bool) {
+	for _, job := range js.jobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+
+	return domain.Job{}, false
+}
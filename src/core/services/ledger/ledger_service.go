@@ -0,0 +1,77 @@
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type LedgerService struct {
+	LedgerRepository  ports.ILedgerRepository
+	AccountRepository ports.IAccountRepository
+}
+
+func NewLedgerService(ledgerRepository ports.ILedgerRepository, accountRepository ports.IAccountRepository) *LedgerService {
+	return &LedgerService{
+		LedgerRepository:  ledgerRepository,
+		AccountRepository: accountRepository,
+	}
+}
+
+// Post validates that the journal balances and writes its entries. It has to be called
+// with the repositories of the unit of work that also changes the account balances.
+func (ls *LedgerService) Post(repositories ports.IRepositories, journal domain.Journal) error {
+	// A journal whose amounts were all zero has nothing to record
+	if len(journal.Entries) == 0 {
+		return nil
+	}
+
+	if err := journal.Validate(); err != nil {
+		return domain.InternalFailure(errors.New("Failed to post journal: " + err.Error()))
+	}
+
+	_, err := repositories.CreateLedgerEntries(journal.Entries)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to post journal: %w", err))
+	}
+
+	return nil
+}
+
+func (ls *LedgerService) Entries(accountID uuid.UUID, limit int, offset int) ([]domain.LedgerEntry, error) {
+	entries, err := ls.LedgerRepository.GetLedgerEntriesByAccount(accountID, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Ledger entries not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get ledger entries: " + err.Error()))
+	}
+
+	return entries, nil
+}
+
+// Verify compares the stored balance of the account with the one derived from its ledger entries.
+func (ls *LedgerService) Verify(accountID uuid.UUID) (domain.LedgerBalance, error) {
+	account, err := ls.AccountRepository.GetAccount(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.LedgerBalance{}, domain.NotFoundError(errors.New("Account not found"))
+		}
+		return domain.LedgerBalance{}, domain.InternalFailure(errors.New("Failed to get account: " + err.Error()))
+	}
+
+	derived, err := ls.LedgerRepository.GetLedgerBalance(account.ID, account.Currency)
+	if err != nil {
+		return domain.LedgerBalance{}, domain.InternalFailure(errors.New("Failed to get ledger balance: " + err.Error()))
+	}
+
+	return domain.LedgerBalance{
+		AccountID: account.ID,
+		Stored:    account.Balance,
+		Derived:   derived,
+	}, nil
+}
@@ -0,0 +1,252 @@
+package mandates
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// MandateService keeps the direct debit mandates payers give creditor accounts. The creditor
+// collects through the TransactionService, which checks every collection against the mandate.
+type MandateService struct {
+	MandateRepository  ports.IMandateRepository
+	AccountRepository  ports.IAccountRepository
+	GeneralRepository  ports.IRepository
+	TransactionService ports.ITransactionService
+	CredentialService  ports.ICredentialService
+	RefundDays         int // How many days after a collection the payer can have it refunded
+	Clock              ports.IClock
+}
+
+func NewMandateService(mandateRepository ports.IMandateRepository, accountRepository ports.IAccountRepository, generalRepository ports.IRepository, transactionService ports.ITransactionService, credentialService ports.ICredentialService, refundDays int, clock ports.IClock) *MandateService {
+	return &MandateService{
+		MandateRepository:  mandateRepository,
+		AccountRepository:  accountRepository,
+		GeneralRepository:  generalRepository,
+		TransactionService: transactionService,
+		CredentialService:  credentialService,
+		RefundDays:         refundDays,
+		Clock:              clock,
+	}
+}
+
+// Index returns the mandates the account pays and the ones it collects.
+func (ms *MandateService) Index(accountID uuid.UUID, limit int, offset int) ([]domain.Mandate, error) {
+	mandates, err := ms.MandateRepository.GetMandatesByAccount(accountID, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Mandates not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get mandates: " + err.Error()))
+	}
+
+	return mandates, nil
+}
+
+// Get returns the mandate, only the payer and the creditor account find it.
+func (ms *MandateService) Get(accountID, mandateID uuid.UUID) (domain.Mandate, error) {
+	mandate, err := ms.MandateRepository.GetMandate(mandateID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Mandate{}, domain.NotFoundError(errors.New("Mandate not found"))
+		}
+		return domain.Mandate{}, domain.InternalFailure(errors.New("Failed to get mandate: " + err.Error()))
+	}
+
+	if !mandate.IsParty(accountID) {
+		return domain.Mandate{}, domain.NotFoundError(errors.New("Mandate not found"))
+	}
+
+	return mandate, nil
+}
+
+// Create authorises the creditor account to collect from the account. Max amounts above the
+// step-up threshold need a second factor of the payer now, the collections are then made without one.
+func (ms *MandateService) Create(accountID uuid.UUID, body domain.CreateMandateRequest) (domain.Mandate, error) {
+	now := ms.Clock.Now()
+
+	account, err := ms.getAccount(accountID, "Account not found")
+	if err != nil {
+		return domain.Mandate{}, err
+	}
+
+	if !account.IsActive() {
+		return domain.Mandate{}, domain.ConflictError(errors.New("Account is " + strings.ToLower(string(account.Status))))
+	}
+
+	if _, err := ms.getAccount(body.CreditorAccountID, "Creditor account not found"); err != nil {
+		return domain.Mandate{}, err
+	}
+
+	maxAmount, err := domain.ParseMoney(body.MaxAmount.String(), account.Currency)
+	if err != nil {
+		return domain.Mandate{}, domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+	}
+
+	mandate := domain.Mandate{
+		ID:                uuid.New(),
+		PayerAccountID:    account.ID,
+		CreditorAccountID: body.CreditorAccountID,
+		Reference:         strings.TrimSpace(body.Reference),
+		MaxAmount:         maxAmount,
+		Frequency:         body.Frequency,
+		Status:            domain.MandateActive,
+		CreatedAt:         now,
+	}
+
+	if err := mandate.Validate(); err != nil {
+		return domain.Mandate{}, domain.ValidationError(err)
+	}
+
+	err = ms.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		if ms.TransactionService.RequiresStepUp(mandate.MaxAmount) {
+			if err := ms.CredentialService.VerifySecondFactor(repositories, account.CustomerID, body.Code, now); err != nil {
+				return err
+			}
+		}
+
+		_, err := repositories.CreateMandate(mandate)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to create mandate: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Mandate{}, domain.AsDomainError(err)
+	}
+
+	return mandate, nil
+}
+
+// Cancel stops the mandate, only the payer can cancel it. Collections already made can still be refunded.
+func (ms *MandateService) Cancel(accountID, mandateID uuid.UUID) (domain.Mandate, error) {
+	var mandate domain.Mandate
+
+	err := ms.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		mandate, err = repositories.GetMandateForUpdate(mandateID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return domain.NotFoundError(errors.New("Mandate not found"))
+			}
+			return domain.InternalFailure(fmt.Errorf("Failed to get mandate: %w", err))
+		}
+
+		if err := checkPayer(mandate, accountID, "Only the payer can cancel the mandate"); err != nil {
+			return err
+		}
+
+		if err := mandate.Cancel(ms.Clock.Now()); err != nil {
+			return domain.ConflictError(err)
+		}
+
+		_, err = repositories.UpdateMandate(mandate)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to update mandate: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Mandate{}, domain.AsDomainError(err)
+	}
+
+	return mandate, nil
+}
+
+// Collect pulls the amount from the payer to the creditor account under the mandate, only the
+// creditor can collect. The transfer fails when it is beyond the limits of the mandate.
+func (ms *MandateService) Collect(accountID, mandateID uuid.UUID, body domain.CreateCollectionRequest) (domain.Transaction, error) {
+	mandate, err := ms.Get(accountID, mandateID)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	if mandate.CreditorAccountID != accountID {
+		return domain.Transaction{}, domain.ForbiddenError(errors.New("Only the creditor can collect under the mandate"))
+	}
+
+	return ms.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   mandate.PayerAccountID,
+		ReceiverAccountID: mandate.CreditorAccountID,
+		Amount:            body.Amount,
+		MandateID:         mandate.ID,
+	})
+}
+
+func (ms *MandateService) Collections(accountID, mandateID uuid.UUID, limit int, offset int) ([]domain.Transaction, error) {
+	if _, err := ms.Get(accountID, mandateID); err != nil {
+		return nil, err
+	}
+
+	collections, err := ms.MandateRepository.GetMandateCollections(mandateID, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Collections not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get collections: " + err.Error()))
+	}
+
+	return collections, nil
+}
+
+// Refund sends what is left of a collection back to the payer, who can ask for it without giving a
+// reason within RefundDays of the collection.
+func (ms *MandateService) Refund(accountID, mandateID, transactionID uuid.UUID) (domain.Transaction, error) {
+	mandate, err := ms.Get(accountID, mandateID)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	if err := checkPayer(mandate, accountID, "Only the payer can have collections refunded"); err != nil {
+		return domain.Transaction{}, err
+	}
+
+	collection, err := ms.TransactionService.Get(transactionID)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	if collection.MandateID != mandate.ID {
+		return domain.Transaction{}, domain.NotFoundError(errors.New("Collection not found"))
+	}
+
+	if ms.Clock.Now().After(collection.CreatedAt.AddDate(0, 0, ms.RefundDays)) {
+		return domain.Transaction{}, domain.BadRequestError(errors.New("Collections can only be refunded within " + strconv.Itoa(ms.RefundDays) + " days"))
+	}
+
+	return ms.TransactionService.Refund(collection.ID)
+}
+
+func (ms *MandateService) getAccount(accountID uuid.UUID, notFound string) (domain.Account, error) {
+	account, err := ms.AccountRepository.GetAccount(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Account{}, domain.NotFoundError(errors.New(notFound))
+		}
+		return domain.Account{}, domain.InternalFailure(errors.New("Failed to get account: " + err.Error()))
+	}
+
+	return account, nil
+}
+
+// checkPayer only lets the payer of the mandate through, other accounts dont find it at all.
+func checkPayer(mandate domain.Mandate, accountID uuid.UUID, forbidden string) error {
+	if !mandate.IsParty(accountID) {
+		return domain.NotFoundError(errors.New("Mandate not found"))
+	}
+
+	if mandate.PayerAccountID != accountID {
+		return domain.ForbiddenError(errors.New(forbidden))
+	}
+
+	return nil
+}
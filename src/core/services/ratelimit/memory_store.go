@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+// MemoryStore keeps the token buckets in the memory of the process, every instance of the
+// server limits requests on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]domain.RateLimitBucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]domain.RateLimitBucket),
+	}
+}
+
+func (ms *MemoryStore) Take(key string, limit domain.RateLimit, at time.Time) (domain.RateLimitResult, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	bucket, result := limit.Take(ms.buckets[key], at)
+	bucket.Key = key
+	ms.buckets[key] = bucket
+
+	if at.Sub(ms.lastSweep) >= domain.RATE_LIMIT_SWEEP_INTERVAL {
+		ms.sweep(at)
+	}
+
+	return result, nil
+}
+
+// Len returns the number of buckets kept.
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.buckets)
+}
+
+// sweep forgets the buckets that filled up again, so clients that went away dont pile up.
+func (ms *MemoryStore) sweep(at time.Time) {
+	for key, bucket := range ms.buckets {
+		if !bucket.FullAt.After(at) {
+			delete(ms.buckets, key)
+		}
+	}
+
+	ms.lastSweep = at
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"errors"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+type RateLimitService struct {
+	RateLimitStore ports.IRateLimitStore
+	Policy         domain.RateLimitPolicy
+	Clock          ports.IClock
+}
+
+func NewRateLimitService(rateLimitStore ports.IRateLimitStore, policy domain.RateLimitPolicy, clock ports.IClock) *RateLimitService {
+	return &RateLimitService{
+		RateLimitStore: rateLimitStore,
+		Policy:         policy,
+		Clock:          clock,
+	}
+}
+
+// Allow takes a token from the bucket of every key, reads and writes having buckets of their own.
+// The result is the one of the strictest bucket, so a request goes through only when every key
+// still has room for it. Without a limit every request is allowed and the result has no Limit.
+func (rs *RateLimitService) Allow(keys []string, write bool) (domain.RateLimitResult, error) {
+	limit, class := rs.Policy.Read, "read"
+	if write {
+		limit, class = rs.Policy.Write, "write"
+	}
+
+	if limit.IsZero() {
+		return domain.RateLimitResult{Allowed: true}, nil
+	}
+
+	now := rs.Clock.Now()
+	result := domain.RateLimitResult{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}
+
+	for _, key := range keys {
+		taken, err := rs.RateLimitStore.Take(class+":"+key, limit, now)
+		if err != nil {
+			return domain.RateLimitResult{}, domain.InternalFailure(errors.New("Failed to take a rate limit token: " + err.Error()))
+		}
+
+		if taken.Stricter(result) {
+			result = taken
+		}
+	}
+
+	return result, nil
+}
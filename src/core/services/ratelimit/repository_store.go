@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// RepositoryStore keeps the token buckets in the database, so every instance of the server
+// draws from the same buckets.
+type RepositoryStore struct {
+	GeneralRepository ports.IRepository
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRepositoryStore(generalRepository ports.IRepository) *RepositoryStore {
+	return &RepositoryStore{
+		GeneralRepository: generalRepository,
+	}
+}
+
+// Take locks the bucket of the key while spending its token. Two instances can both start a
+// bucket for a brand new key, which at worst lets a single extra request through.
+func (rs *RepositoryStore) Take(key string, limit domain.RateLimit, at time.Time) (domain.RateLimitResult, error) {
+	var result domain.RateLimitResult
+
+	err := rs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		bucket, err := repositories.GetRateLimitBucketForUpdate(key)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		bucket, result = limit.Take(bucket, at)
+		bucket.Key = key
+
+		_, err = repositories.SaveRateLimitBucket(bucket)
+		return err
+	})
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	rs.sweep(at)
+
+	return result, nil
+}
+
+// sweep forgets the buckets that filled up again, at most once every RATE_LIMIT_SWEEP_INTERVAL.
+func (rs *RepositoryStore) sweep(at time.Time) {
+	rs.mu.Lock()
+	if at.Sub(rs.lastSweep) < domain.RATE_LIMIT_SWEEP_INTERVAL {
+		rs.mu.Unlock()
+		return
+	}
+	rs.lastSweep = at
+	rs.mu.Unlock()
+
+	err := rs.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		_, err := repositories.DeleteFullRateLimitBuckets(at)
+		return err
+	})
+	if err != nil {
+		log.Println("[WARNING]\tFailed to delete full rate limit buckets: " + err.Error())
+	}
+}
@@ -0,0 +1,396 @@
+package standingorders
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+// StandingOrderService keeps the standing orders of the accounts and makes their transfers through
+// the TransactionService, it runs as a job of the scheduler. Every attempt is recorded before the
+// transfer is made, so an attempt cut off by the server going down is never made twice.
+type StandingOrderService struct {
+	StandingOrderRepository ports.IStandingOrderRepository
+	AccountRepository       ports.IAccountRepository
+	GeneralRepository       ports.IRepository
+	TransactionService      ports.ITransactionService
+	CredentialService       ports.ICredentialService
+	RetryPolicy             domain.RetryPolicy
+	Clock                   ports.IClock
+}
+
+func NewStandingOrderService(standingOrderRepository ports.IStandingOrderRepository, accountRepository ports.IAccountRepository, generalRepository ports.IRepository, transactionService ports.ITransactionService, credentialService ports.ICredentialService, retryPolicy domain.RetryPolicy, clock ports.IClock) *StandingOrderService {
+	return &StandingOrderService{
+		StandingOrderRepository: standingOrderRepository,
+		AccountRepository:       accountRepository,
+		GeneralRepository:       generalRepository,
+		TransactionService:      transactionService,
+		CredentialService:       credentialService,
+		RetryPolicy:             retryPolicy,
+		Clock:                   clock,
+	}
+}
+
+func (ss *StandingOrderService) Index(accountID uuid.UUID, limit int, offset int) ([]domain.StandingOrder, error) {
+	orders, err := ss.StandingOrderRepository.GetStandingOrdersByAccount(accountID, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Standing orders not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get standing orders: " + err.Error()))
+	}
+
+	return orders, nil
+}
+
+// Get returns the standing order, orders of other accounts are not found.
+func (ss *StandingOrderService) Get(accountID, standingOrderID uuid.UUID) (domain.StandingOrder, error) {
+	order, err := ss.StandingOrderRepository.GetStandingOrder(standingOrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.StandingOrder{}, domain.NotFoundError(errors.New("Standing order not found"))
+		}
+		return domain.StandingOrder{}, domain.InternalFailure(errors.New("Failed to get standing order: " + err.Error()))
+	}
+
+	if order.AccountID != accountID {
+		return domain.StandingOrder{}, domain.NotFoundError(errors.New("Standing order not found"))
+	}
+
+	return order, nil
+}
+
+// Create sets up a standing order of the account. Amounts above the step-up threshold need a second
+// factor of the customer now, the transfers of the order are then made without one.
+func (ss *StandingOrderService) Create(accountID uuid.UUID, body domain.CreateStandingOrderRequest) (domain.StandingOrder, error) {
+	now := ss.Clock.Now()
+
+	account, err := ss.getAccount(accountID, "Account not found")
+	if err != nil {
+		return domain.StandingOrder{}, err
+	}
+
+	if !account.IsActive() {
+		return domain.StandingOrder{}, domain.ConflictError(errors.New("Account is " + strings.ToLower(string(account.Status))))
+	}
+
+	if _, err := ss.getAccount(body.ReceiverAccountID, "Receiver account not found"); err != nil {
+		return domain.StandingOrder{}, err
+	}
+
+	amount, err := domain.ParseMoney(body.Amount.String(), account.Currency)
+	if err != nil {
+		return domain.StandingOrder{}, domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+	}
+
+	rule, err := domain.RecurrenceRule(body.Frequency, body.Rule)
+	if err != nil {
+		return domain.StandingOrder{}, domain.BadRequestError(err)
+	}
+
+	recurrence, err := domain.ParseRecurrence(rule)
+	if err != nil {
+		return domain.StandingOrder{}, domain.BadRequestError(err)
+	}
+
+	order := domain.StandingOrder{
+		ID:                uuid.New(),
+		AccountID:         account.ID,
+		ReceiverAccountID: body.ReceiverAccountID,
+		Amount:            amount,
+		Frequency:         body.Frequency,
+		Recurrence:        recurrence,
+		StartDate:         domain.StartOfDay(body.StartDate),
+		EndDate:           domain.StartOfDay(body.EndDate),
+		Status:            domain.StandingOrderActive,
+		CreatedAt:         now,
+	}
+
+	if err := order.Validate(); err != nil {
+		return domain.StandingOrder{}, domain.ValidationError(err)
+	}
+
+	if order.StartDate.Before(domain.StartOfDay(now)) {
+		return domain.StandingOrder{}, domain.BadRequestError(errors.New("StartDate cannot be in the past"))
+	}
+
+	order.NextDueDate = order.NextDay(order.StartDate.AddDate(0, 0, -1))
+	order.NextAttemptAt = order.NextDueDate
+
+	if order.NextDueDate.IsZero() {
+		return domain.StandingOrder{}, domain.BadRequestError(errors.New("Standing order never comes due"))
+	}
+
+	err = ss.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		if ss.TransactionService.RequiresStepUp(order.Amount) {
+			if err := ss.CredentialService.VerifySecondFactor(repositories, account.CustomerID, body.Code, now); err != nil {
+				return err
+			}
+		}
+
+		_, err := repositories.CreateStandingOrder(order)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to create standing order: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.StandingOrder{}, domain.AsDomainError(err)
+	}
+
+	return order, nil
+}
+
+// Cancel stops the standing order, an attempt already being made still goes through.
+func (ss *StandingOrderService) Cancel(accountID, standingOrderID uuid.UUID) (domain.StandingOrder, error) {
+	var order domain.StandingOrder
+
+	err := ss.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+
+		order, err = lockStandingOrder(repositories, standingOrderID)
+		if err != nil {
+			return err
+		}
+
+		if order.AccountID != accountID {
+			return domain.NotFoundError(errors.New("Standing order not found"))
+		}
+
+		if err := order.Cancel(ss.Clock.Now()); err != nil {
+			return domain.ConflictError(err)
+		}
+
+		return updateStandingOrder(repositories, order)
+	})
+	if err != nil {
+		return domain.StandingOrder{}, domain.AsDomainError(err)
+	}
+
+	return order, nil
+}
+
+func (ss *StandingOrderService) Executions(accountID, standingOrderID uuid.UUID, limit int, offset int) ([]domain.StandingOrderExecution, error) {
+	if _, err := ss.Get(accountID, standingOrderID); err != nil {
+		return nil, err
+	}
+
+	executions, err := ss.StandingOrderRepository.GetStandingOrderExecutions(standingOrderID, limit, offset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NotFoundError(errors.New("Standing order executions not found"))
+		}
+		return nil, domain.InternalFailure(errors.New("Failed to get standing order executions: " + err.Error()))
+	}
+
+	return executions, nil
+}
+
+// Execute makes the transfers of every standing order due by now, days missed while the server
+// was down are caught up. A failing order doesnt hold up the others.
+func (ss *StandingOrderService) Execute(now time.Time) error {
+	orderIDs, err := ss.StandingOrderRepository.GetDueStandingOrders(now)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return domain.InternalFailure(errors.New("Failed to get standing orders: " + err.Error()))
+	}
+
+	failed := 0
+	for _, orderID := range orderIDs {
+		if err := ss.execute(orderID, now); err != nil {
+			log.Printf("[ERROR]\tFailed to execute standing order %s: %v", orderID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return domain.InternalFailure(fmt.Errorf("Failed to execute %d out of %d standing orders", failed, len(orderIDs)))
+	}
+
+	log.Printf("[EVENT]\tExecuted %d standing orders", len(orderIDs))
+	return nil
+}
+
+// execute attempts the transfers of the order until it isnt due anymore.
+func (ss *StandingOrderService) execute(orderID uuid.UUID, now time.Time) error {
+	for {
+		attempted, err := ss.attempt(orderID, now)
+		if err != nil || !attempted {
+			return err
+		}
+	}
+}
+
+// attempt makes the next transfer of the order when it is due and records how it went. Transfers
+// the account cannot cover are retried as the policy allows, other rejections move on to the next
+// day. An internal failure leaves the attempt pending, so the next run picks it up again.
+func (ss *StandingOrderService) attempt(orderID uuid.UUID, now time.Time) (bool, error) {
+	order, execution, resumed, err := ss.begin(orderID, now)
+	if err != nil || execution.ID == uuid.Nil {
+		return false, err
+	}
+
+	transferErr := ss.transfer(order, execution, resumed)
+	if errors.Is(transferErr, domain.ErrInternalFailure) {
+		return false, transferErr
+	}
+
+	err = ss.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		order, err := lockStandingOrder(repositories, orderID)
+		if err != nil {
+			return err
+		}
+
+		// Cancelled while the transfer was being made
+		scheduled := order.IsActive() && order.NextDueDate.Equal(execution.DueDate)
+
+		status := domain.ExecutionSucceeded
+		switch {
+		case transferErr == nil:
+			if scheduled {
+				order.Advance()
+			}
+		case errors.Is(transferErr, domain.ErrInsufficientFunds) && scheduled && order.Retry(ss.RetryPolicy, now):
+			status = domain.ExecutionRetrying
+		default:
+			status = domain.ExecutionFailed
+			if scheduled {
+				order.Advance()
+			}
+		}
+
+		execution.Finish(status, transferErr, now)
+
+		if _, err := repositories.FinishStandingOrderExecution(execution); err != nil {
+			return domain.InternalFailure(errors.New("Failed to finish standing order execution: " + err.Error()))
+		}
+
+		return updateStandingOrder(repositories, order)
+	})
+	if err != nil {
+		return false, domain.AsDomainError(err)
+	}
+
+	return true, nil
+}
+
+// begin records the start of the next attempt of the order, when it is due. An attempt that was
+// already started but never finished is returned instead, resumed.
+func (ss *StandingOrderService) begin(orderID uuid.UUID, now time.Time) (domain.StandingOrder, domain.StandingOrderExecution, bool, error) {
+	var order domain.StandingOrder
+	var execution domain.StandingOrderExecution
+	var resumed bool
+
+	err := ss.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		var err error
+		execution, resumed = domain.StandingOrderExecution{}, false
+
+		order, err = lockStandingOrder(repositories, orderID)
+		if err != nil {
+			return err
+		}
+
+		// Another run got here first
+		if !order.IsDue(now) {
+			return nil
+		}
+
+		execution = domain.NewStandingOrderExecution(order, now)
+
+		created, err := repositories.CreateStandingOrderExecution(execution)
+		if err != nil {
+			return domain.InternalFailure(errors.New("Failed to create standing order execution: " + err.Error()))
+		}
+
+		if created == 0 {
+			resumed = true
+
+			execution, err = repositories.GetStandingOrderExecution(order.ID, order.NextDueDate, execution.Attempt)
+			if err != nil {
+				return domain.InternalFailure(errors.New("Failed to get standing order execution: " + err.Error()))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.StandingOrder{}, domain.StandingOrderExecution{}, false, domain.AsDomainError(err)
+	}
+
+	return order, execution, resumed, nil
+}
+
+// transfer makes the transfer of the attempt. A resumed attempt first looks up whether the transfer
+// was already made before the run was cut off, and only makes it when it wasnt.
+func (ss *StandingOrderService) transfer(order domain.StandingOrder, execution domain.StandingOrderExecution, resumed bool) error {
+	if resumed {
+		transaction, err := ss.TransactionService.Get(execution.TransactionID)
+		if err == nil {
+			if transaction.Status != domain.TransactionFailed {
+				return nil
+			}
+			if strings.HasSuffix(transaction.FailureReason, domain.ErrInsufficientFunds.Error()) {
+				return domain.BadRequestError(domain.ErrInsufficientFunds)
+			}
+			return domain.BadRequestError(errors.New(transaction.FailureReason))
+		}
+
+		if !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+	}
+
+	_, err := ss.TransactionService.Create(domain.CreateTransactionRequest{
+		ID:                execution.TransactionID,
+		StandingOrderID:   order.ID,
+		SenderAccountID:   order.AccountID,
+		ReceiverAccountID: order.ReceiverAccountID,
+		Amount:            order.Amount.Number(),
+	})
+
+	return err
+}
+
+func (ss *StandingOrderService) getAccount(accountID uuid.UUID, notFound string) (domain.Account, error) {
+	account, err := ss.AccountRepository.GetAccount(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Account{}, domain.NotFoundError(errors.New(notFound))
+		}
+		return domain.Account{}, domain.InternalFailure(errors.New("Failed to get account: " + err.Error()))
+	}
+
+	return account, nil
+}
+
+// lockStandingOrder locks the standing order for the rest of the database transaction.
+func lockStandingOrder(repositories ports.IRepositories, standingOrderID uuid.UUID) (domain.StandingOrder, error) {
+	order, err := repositories.GetStandingOrderForUpdate(standingOrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.StandingOrder{}, domain.NotFoundError(errors.New("Standing order not found"))
+		}
+		return domain.StandingOrder{}, domain.InternalFailure(fmt.Errorf("Failed to get standing order: %w", err))
+	}
+
+	return order, nil
+}
+
+func updateStandingOrder(repositories ports.IRepositories, order domain.StandingOrder) error {
+	_, err := repositories.UpdateStandingOrder(order)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to update standing order: %w", err))
+	}
+
+	return nil
+}
@@ -2,7 +2,11 @@ package transactions
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,17 +18,29 @@ type TransactionService struct {
 	TransactionRepository 	ports.ITransactionRepository
 	AccountRepository		ports.IAccountRepository
 	GeneralRepository		ports.IRepository
+	LedgerService			ports.ILedgerService
+	FXService				ports.IFXService
+	CredentialService		ports.ICredentialService
+	CurrencyService			ports.ICurrencyService
+	StepUpThreshold			int64 // Transfers above this amount in the sender currency need a second factor, 0 disables it
+	Clock					ports.IClock
 }
 
-func NewTransactionService(transactionRepository ports.ITransactionRepository, accountRepository ports.IAccountRepository, generalRepository ports.IRepository) *TransactionService {
+func NewTransactionService(transactionRepository ports.ITransactionRepository, accountRepository ports.IAccountRepository, generalRepository ports.IRepository, ledgerService ports.ILedgerService, fxService ports.IFXService, credentialService ports.ICredentialService, currencyService ports.ICurrencyService, stepUpThreshold int64, clock ports.IClock) *TransactionService {
 	return &TransactionService{
 		TransactionRepository: transactionRepository,
 		AccountRepository: accountRepository,
 		GeneralRepository: generalRepository,
+		LedgerService: ledgerService,
+		FXService: fxService,
+		CredentialService: credentialService,
+		CurrencyService: currencyService,
+		StepUpThreshold: stepUpThreshold,
+		Clock: clock,
 	}
 }
 
-func (ts *TransactionService) Index(accountID uuid.UUID, limit int, offset int) ([]domain.Transaction, error) {
+func (ts *TransactionService) Index(accountID uuid.UUID, status domain.TransactionStatus, limit int, offset int) ([]domain.Transaction, error) {
 	// Declare variables for transactions and error, because
 	// we can then access them in if/else scope
 	var transactions []domain.Transaction
@@ -33,9 +49,9 @@ func (ts *TransactionService) Index(accountID uuid.UUID, limit int, offset int)
 	// If the id is provided by the handler
 	// fetch the transactions filtered by the account
 	if accountID != uuid.Nil {
-		transactions, err = ts.TransactionRepository.GetAllTransactionsFromAccount(accountID, limit, offset)
+		transactions, err = ts.TransactionRepository.GetAllTransactionsFromAccount(accountID, status, limit, offset)
 	} else {
-		transactions, err = ts.TransactionRepository.GetAllTransactions(limit, offset)
+		transactions, err = ts.TransactionRepository.GetAllTransactions(status, limit, offset)
 	}
 
 	// Handle error for both options
@@ -63,80 +79,581 @@ func (ts *TransactionService) Get(transactionID uuid.UUID) (domain.Transaction,
 
 func (ts *TransactionService) Create(body domain.CreateTransactionRequest) (domain.Transaction, error) {
 	transaction := domain.Transaction{
-		ID: uuid.New(),
+		ID: body.ID,
+		Type: domain.TransactionTransfer,
 		SenderAccountID: body.SenderAccountID,
 		ReceiverAccountID: body.ReceiverAccountID,
-		Amount: body.Amount,
-		CreatedAt: time.Now(),
+		QuoteID: body.QuoteID,
+		MandateID: body.MandateID,
+		Status: domain.TransactionPending,
+		CreatedAt: ts.Clock.Now(),
 	}
 
-	// Get the sender account
-	sender, err := ts.AccountRepository.GetAccount(transaction.SenderAccountID)
+	if transaction.ID == uuid.Nil {
+		transaction.ID = uuid.New()
+	}
+
+	// Set when a valid transaction gets rejected, it is then recorded as failed
+	var rejection error
+
+	// Everything runs on locked rows inside one database transaction, so concurrent
+	// transfers from the same account cannot both pass the balance check
+	err := ts.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		// Start over from a pending transaction when the database transaction is retried
+		rejection = nil
+		transaction.Status = domain.TransactionPending
+
+		sender, receiver, err := lockAccounts(repositories, transaction.SenderAccountID, transaction.ReceiverAccountID)
+		if err != nil {
+			return err
+		}
+
+		// Create the currency-pair for the transaction
+		transaction.CurrencyPair = domain.NewCurrencyPair(sender.Currency, receiver.Currency)
+
+		// The amount is always held in the sender currency
+		transaction.Amount, err = domain.ParseMoney(body.Amount.String(), sender.Currency)
+		if err != nil {
+			return domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+		}
+		transaction.Fee = domain.NewMoney(0, sender.Currency)
+
+		// Validate the transaction
+		if err := transaction.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		// New transfers only move between currencies the bank still offers, unlike sweeps, interest, reversals and refunds
+		if err := ts.CurrencyService.CheckEnabled(sender.Currency, receiver.Currency); err != nil {
+			return err
+		}
+
+		// Large transfers need a fresh second factor of the sender, standing orders and mandates had theirs checked when set up
+		if ts.RequiresStepUp(transaction.Amount) && body.StandingOrderID == uuid.Nil && body.MandateID == uuid.Nil {
+			if err := ts.CredentialService.VerifySecondFactor(repositories, sender.CustomerID, body.Code, ts.Clock.Now()); err != nil {
+				return err
+			}
+		}
+
+		// Money only moves between active accounts
+		if err := checkActive(sender, receiver); err != nil {
+			rejection = err
+			return rejection
+		}
+
+		// Collections have to stay within the mandate the sender gave the receiver
+		if body.MandateID != uuid.Nil {
+			mandate, err := lockMandate(repositories, body.MandateID)
+			if err != nil {
+				return err
+			}
+
+			if mandate.PayerAccountID != sender.ID || mandate.CreditorAccountID != receiver.ID {
+				return domain.BadRequestError(errors.New("Mandate wasnt given for these accounts"))
+			}
+
+			if !mandate.IsActive() {
+				rejection = domain.ConflictError(errors.New("Mandate is " + strings.ToLower(string(mandate.Status))))
+				return rejection
+			}
+
+			collected, err := repositories.CountMandateCollections(mandate.ID, mandate.PeriodStart(transaction.CreatedAt))
+			if err != nil {
+				return domain.InternalFailure(fmt.Errorf("Failed to count mandate collections: %w", err))
+			}
+
+			if err := mandate.Allows(transaction.Amount, collected); err != nil {
+				rejection = domain.BadRequestError(err)
+				return rejection
+			}
+		}
+
+		// Validate that the sender can send the money, the arranged overdraft included
+		if !sender.CanCover(transaction.Amount) {
+			rejection = domain.BadRequestError(domain.ErrInsufficientFunds)
+			return rejection
+		}
+
+		// Price the conversion with the locked quote, or with the rate effective right now
+		quote, err := ts.price(repositories, transaction, sender.CustomerID, receiver.Currency)
+		if err != nil {
+			if !errors.Is(err, domain.ErrInternalFailure) {
+				rejection = err
+			}
+			return err
+		}
+		transaction.ExchangeRate = quote.Rate
+		transaction.Fee = quote.Fee
+
+		return ts.settle(repositories, &transaction, sender, receiver, quote.Converted)
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return domain.Transaction{}, domain.NotFoundError(errors.New("Account not found"))
+		if rejection != nil {
+			ts.fail(transaction, rejection)
 		}
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to get sender: "+err.Error()))
+		return domain.Transaction{}, domain.AsDomainError(err)
 	}
 
-	// Get the receiver account
-	receiver, err := ts.AccountRepository.GetAccount(transaction.ReceiverAccountID)
+	return transaction, nil
+}
+
+// Reverse sends the amount, or the whole remaining amount when none is given, back from the
+// receiver to the sender with a linked transaction, converted with the rate of the original one.
+// The original transaction becomes reversed once all of it was sent back.
+func (ts *TransactionService) Reverse(transactionID uuid.UUID, body domain.CreateReversalRequest) (domain.Transaction, error) {
+	var reversal domain.Transaction
+
+	// Set when a valid reversal gets rejected, it is then recorded as failed
+	var rejection error
+
+	err := ts.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		rejection = nil
+
+		original, err := lockTransaction(repositories, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if original.IsReversal() {
+			return domain.BadRequestError(errors.New("A reversal cannot be reversed"))
+		}
+
+		if original.Type != domain.TransactionTransfer {
+			return domain.BadRequestError(errors.New("Only transfers can be reversed"))
+		}
+
+		if original.Status != domain.TransactionPosted {
+			return domain.ConflictError(errors.New("Only posted transactions can be reversed, this one is " + string(original.Status)))
+		}
+
+		if _, ok := original.Rate(); !ok {
+			return domain.BadRequestError(errors.New("Transaction has no recorded exchange rate"))
+		}
+
+		// The amount is held in the currency the receiver got
+		refundable := original.Refundable()
+		amount := refundable
+		if body.Amount != "" {
+			amount, err = domain.ParseMoney(body.Amount.String(), refundable.Currency)
+			if err != nil {
+				return domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+			}
+		}
+
+		if amount.Cmp(refundable) > 0 {
+			return domain.BadRequestError(errors.New("Only " + refundable.String() + " " + string(refundable.Currency) + " of the transaction can still be reversed"))
+		}
+
+		reversal = domain.NewReversal(original, amount, ts.Clock.Now())
+
+		if err := reversal.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		sender, receiver, err := lockAccounts(repositories, reversal.SenderAccountID, reversal.ReceiverAccountID)
+		if err != nil {
+			return err
+		}
+
+		if sender.Currency != reversal.CurrencyPair.From || receiver.Currency != reversal.CurrencyPair.To {
+			return domain.BadRequestError(errors.New("Accounts changed their currency since the transaction was made"))
+		}
+
+		if err := checkActive(sender, receiver); err != nil {
+			rejection = err
+			return rejection
+		}
+
+		// Validate that the receiver of the original transaction can send the money back
+		if !sender.CanCover(reversal.Amount) {
+			rejection = domain.BadRequestError(errors.New("Receiver account doesnt have enough balance to reverse the transaction"))
+			return rejection
+		}
+
+		if err := ts.settle(repositories, &reversal, sender, receiver, reversal.Credited()); err != nil {
+			return err
+		}
+
+		return link(repositories, &original, reversal)
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return domain.Transaction{}, domain.NotFoundError(errors.New("Account not found"))
+		if rejection != nil {
+			ts.fail(reversal, rejection)
+		}
+		return domain.Transaction{}, domain.AsDomainError(err)
+	}
+
+	return reversal, nil
+}
+
+// Refund sends a mandate collection back to the payer in full. The payer has a right to it, so
+// unlike a reversal it is booked even when the creditor already spent the money, taking it past
+// its overdraft, or when the creditor is frozen or closed by now.
+func (ts *TransactionService) Refund(collectionID uuid.UUID) (domain.Transaction, error) {
+	var refund domain.Transaction
+
+	err := ts.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		collection, err := lockTransaction(repositories, collectionID)
+		if err != nil {
+			return err
 		}
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to get receiver: "+err.Error()))
-	}	
 
-	// Create the currency-pair for the transaction
-	transaction.CurrencyPair = domain.NewCurrencyPair(sender.Currency, receiver.Currency)
+		if collection.MandateID == uuid.Nil {
+			return domain.BadRequestError(errors.New("Only collections of a mandate can be refunded"))
+		}
+
+		if collection.Status != domain.TransactionPosted {
+			return domain.ConflictError(errors.New("Only posted collections can be refunded, this one is " + string(collection.Status)))
+		}
+
+		if _, ok := collection.Rate(); !ok {
+			return domain.BadRequestError(errors.New("Transaction has no recorded exchange rate"))
+		}
+
+		refund = domain.NewReversal(collection, collection.Refundable(), ts.Clock.Now())
+
+		if err := refund.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		creditor, payer, err := lockAccounts(repositories, refund.SenderAccountID, refund.ReceiverAccountID)
+		if err != nil {
+			return err
+		}
+
+		if creditor.Currency != refund.CurrencyPair.From || payer.Currency != refund.CurrencyPair.To {
+			return domain.BadRequestError(errors.New("Accounts changed their currency since the transaction was made"))
+		}
+
+		if !payer.IsActive() {
+			return domain.ConflictError(errors.New("Payer account is " + strings.ToLower(string(payer.Status))))
+		}
 
-	// Validate the transaction
-	if err := transaction.Validate(); err != nil {
+		if err := ts.settle(repositories, &refund, creditor, payer, refund.Credited()); err != nil {
+			return err
+		}
+
+		return link(repositories, &collection, refund)
+	})
+	if err != nil {
+		return domain.Transaction{}, domain.AsDomainError(err)
+	}
+
+	return refund, nil
+}
+
+// Sweep moves the whole balance of a closing account to the target account within the database
+// transaction of the caller. The account may be dormant, but the target has to be active.
+func (ts *TransactionService) Sweep(repositories ports.IRepositories, accountID, targetID uuid.UUID, at time.Time) (domain.Transaction, error) {
+	account, target, err := lockAccounts(repositories, accountID, targetID)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	// Sweeps skip the step-up and transfer limits, so the money must stay with the customer
+	if target.CustomerID != account.CustomerID {
+		return domain.Transaction{}, domain.BadRequestError(errors.New("Balance can only be swept to another account of the same customer"))
+	}
+
+	if !target.IsActive() {
+		return domain.Transaction{}, domain.ConflictError(errors.New("Sweep account is " + strings.ToLower(string(target.Status))))
+	}
+
+	sweep := domain.NewSweep(account, target, at)
+
+	if err := sweep.ValidateSweep(); err != nil {
 		return domain.Transaction{}, domain.ValidationError(err)
 	}
 
-	// Validate that the sender can send the money
-	if (sender.Balance - transaction.Amount) < 0 {
-		return domain.Transaction{}, domain.BadRequestError(errors.New("Sender account doesnt have enough balance"))
+	quote, err := ts.FXService.Price(sweep.Amount, target.Currency, at)
+	if err != nil {
+		return domain.Transaction{}, err
 	}
+	sweep.ExchangeRate = quote.Rate
+	sweep.Fee = quote.Fee
 
-	// Calculate the correct amount to add to the receiver account (With the currency conversion)
-	receiver.Balance += transaction.CurrencyPair.Calculate(transaction.Amount)
-	sender.Balance -= transaction.Amount
+	if err := ts.settle(repositories, &sweep, account, target, quote.Converted); err != nil {
+		return domain.Transaction{}, err
+	}
 
-	tx, err := ts.GeneralRepository.BeginTransaction()
+	return sweep, nil
+}
+
+// PostInterest pays the interest to the account or charges it when negative, running inside the
+// transaction of the interest engine. Charged interest can take an account past its overdraft limit.
+func (ts *TransactionService) PostInterest(repositories ports.IRepositories, accountID uuid.UUID, interest domain.Money, at time.Time) (domain.Transaction, error) {
+	account, err := lockAccount(repositories, accountID)
 	if err != nil {
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to start a transaction: "+err.Error()))
-	}	
-	defer tx.Rollback()
+		return domain.Transaction{}, err
+	}
+
+	transaction := domain.NewInterest(account, interest, at)
+
+	if err := transaction.ValidateSweep(); err != nil {
+		return domain.Transaction{}, domain.ValidationError(err)
+	}
+
+	sender, receiver := domain.Account{}, account
+	if transaction.SenderAccountID != uuid.Nil {
+		sender, receiver = account, domain.Account{}
+	}
 
-	// Update all the accounts
-	_, err = ts.AccountRepository.UpdateAccount(sender)
+	if err := ts.settle(repositories, &transaction, sender, receiver, transaction.Amount); err != nil {
+		return domain.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// Deposit credits money coming from outside the bank through the channel to the account.
+func (ts *TransactionService) Deposit(accountID uuid.UUID, body domain.CreateDepositRequest) (domain.Transaction, error) {
+	return ts.book(accountID, domain.TransactionDeposit, body.Amount, body.Channel, "")
+}
+
+// Withdraw debits money leaving the bank through the channel from the account, large withdrawals
+// need a second factor of the owner just like transfers.
+func (ts *TransactionService) Withdraw(accountID uuid.UUID, body domain.CreateWithdrawalRequest) (domain.Transaction, error) {
+	return ts.book(accountID, domain.TransactionWithdrawal, body.Amount, body.Channel, body.Code)
+}
+
+// book records a deposit or withdrawal of the account, rejected ones are recorded as failed.
+func (ts *TransactionService) book(accountID uuid.UUID, kind domain.TransactionType, value json.Number, channelName domain.TransactionChannel, code string) (domain.Transaction, error) {
+	var transaction domain.Transaction
+
+	// Set when a valid transaction gets rejected, it is then recorded as failed
+	var rejection error
+
+	channel, err := domain.ParseTransactionChannel(string(channelName))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return domain.Transaction{}, domain.NotFoundError(errors.New("Account not found"))
+		return domain.Transaction{}, domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+	}
+
+	err = ts.GeneralRepository.WithinTx(func(repositories ports.IRepositories) error {
+		rejection = nil
+
+		account, err := lockAccount(repositories, accountID)
+		if err != nil {
+			return err
+		}
+
+		amount, err := domain.ParseMoney(value.String(), account.Currency)
+		if err != nil {
+			return domain.ValidationError(&domain.ValidationErrors{Errors: []string{err.Error()}})
+		}
+
+		sender, receiver := domain.Account{}, account
+		transaction = domain.NewDeposit(account, amount, channel, ts.Clock.Now())
+		if kind == domain.TransactionWithdrawal {
+			sender, receiver = account, domain.Account{}
+			transaction = domain.NewWithdrawal(account, amount, channel, ts.Clock.Now())
+		}
+
+		if err := transaction.Validate(); err != nil {
+			return domain.ValidationError(err)
+		}
+
+		if kind == domain.TransactionWithdrawal && ts.RequiresStepUp(transaction.Amount) {
+			if err := ts.CredentialService.VerifySecondFactor(repositories, account.CustomerID, code, ts.Clock.Now()); err != nil {
+				return err
+			}
+		}
+
+		if !account.IsActive() {
+			rejection = domain.ConflictError(errors.New("Account is " + strings.ToLower(string(account.Status))))
+			return rejection
+		}
+
+		if kind == domain.TransactionWithdrawal && !account.CanCover(transaction.Amount) {
+			rejection = domain.BadRequestError(errors.New("Account doesnt have enough balance"))
+			return rejection
+		}
+
+		return ts.settle(repositories, &transaction, sender, receiver, transaction.Amount)
+	})
+	if err != nil {
+		if rejection != nil {
+			ts.fail(transaction, rejection)
+		}
+		return domain.Transaction{}, domain.AsDomainError(err)
+	}
+
+	return transaction, nil
+}
+
+// settle moves the money between the locked accounts, records the transaction as pending and
+// posts it once the ledger has it. Deposits come without a sender and withdrawals without a receiver.
+func (ts *TransactionService) settle(repositories ports.IRepositories, transaction *domain.Transaction, sender, receiver domain.Account, credited domain.Money) error {
+	if sender.ID != uuid.Nil {
+		sender.Balance = sender.Balance.Sub(transaction.Amount)
+
+		// Transfers keep the accounts from becoming dormant, interest doesnt
+		if transaction.Type != domain.TransactionInterest {
+			sender.LastTransactionDate = transaction.CreatedAt
+		}
+
+		_, err := repositories.UpdateAccount(sender)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to update sender: %w", err))
 		}
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to update sender: "+err.Error()))
 	}
-	
-	_, err = ts.AccountRepository.UpdateAccount(receiver)
+
+	if receiver.ID != uuid.Nil {
+		receiver.Balance = receiver.Balance.Add(credited)
+		if transaction.Type != domain.TransactionInterest {
+			receiver.LastTransactionDate = transaction.CreatedAt
+		}
+
+		_, err := repositories.UpdateAccount(receiver)
+		if err != nil {
+			return domain.InternalFailure(fmt.Errorf("Failed to update receiver: %w", err))
+		}
+	}
+
+	_, err := repositories.CreateTransaction(*transaction)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to create transaction: %w", err))
+	}
+
+	// Record the movement in the ledger
+	if err := ts.LedgerService.Post(repositories, domain.NewTransferJournal(*transaction, credited)); err != nil {
+		return err
+	}
+
+	return transition(repositories, transaction, domain.TransactionPosted, "")
+}
+
+// checkActive rejects transfers from or to accounts that are frozen, dormant or closed.
+func checkActive(sender, receiver domain.Account) error {
+	if !sender.IsActive() {
+		return domain.ConflictError(errors.New("Sender account is " + strings.ToLower(string(sender.Status))))
+	}
+
+	if !receiver.IsActive() {
+		return domain.ConflictError(errors.New("Receiver account is " + strings.ToLower(string(receiver.Status))))
+	}
+
+	return nil
+}
+
+// fail records a transaction that was rejected, so the customer can see why it didnt go through.
+func (ts *TransactionService) fail(transaction domain.Transaction, reason error) {
+	transaction.Status = domain.TransactionPending
+	transaction.ExchangeRate = nil
+
+	if err := transaction.TransitionTo(domain.TransactionFailed); err != nil {
+		return
+	}
+	transaction.FailureReason = reason.Error()
+
+	if _, err := ts.TransactionRepository.CreateTransaction(transaction); err != nil {
+		log.Printf("[ERROR]\tFailed to record failed transaction %s: %v", transaction.ID.String(), err)
+	}
+}
+
+// transition moves the transaction to the next status and persists it, invalid transitions are a conflict.
+func transition(repositories ports.IRepositories, transaction *domain.Transaction, next domain.TransactionStatus, reason string) error {
+	if err := transaction.TransitionTo(next); err != nil {
+		return domain.ConflictError(err)
+	}
+	transaction.FailureReason = reason
+
+	_, err := repositories.UpdateTransaction(*transaction)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to update transaction status: %w", err))
+	}
+
+	return nil
+}
+
+// link records the reversal on the original transaction, which is reversed once nothing of it is left.
+func link(repositories ports.IRepositories, original *domain.Transaction, reversal domain.Transaction) error {
+	original.Refunded = original.Refunded.Add(reversal.Amount)
+	original.Reversals = append(original.Reversals, reversal.ID)
+
+	if original.Refundable().IsZero() {
+		return transition(repositories, original, domain.TransactionReversed, "")
+	}
+
+	_, err := repositories.UpdateTransaction(*original)
+	if err != nil {
+		return domain.InternalFailure(fmt.Errorf("Failed to update transaction: %w", err))
+	}
+
+	return nil
+}
+
+// RequiresStepUp reports whether a transfer of the amount needs a second factor of the sender.
+func (ts *TransactionService) RequiresStepUp(amount domain.Money) bool {
+	return ts.StepUpThreshold > 0 && amount.Cmp(domain.MoneyFromMajor(ts.StepUpThreshold, amount.Currency)) > 0
+}
+
+func (ts *TransactionService) price(repositories ports.IRepositories, transaction domain.Transaction, customerID uuid.UUID, to domain.Currency) (domain.FXQuote, error) {
+	if transaction.QuoteID != uuid.Nil {
+		return ts.FXService.Redeem(repositories, transaction.QuoteID, customerID, transaction.Amount, to, transaction.ID, transaction.CreatedAt)
+	}
+
+	return ts.FXService.Price(transaction.Amount, to, transaction.CreatedAt)
+}
+
+// lockAccounts locks both accounts for the rest of the database transaction. The rows
+// are always locked in the same order so two opposite transfers cannot deadlock.
+func lockAccounts(repositories ports.IRepositories, senderID, receiverID uuid.UUID) (domain.Account, domain.Account, error) {
+	accounts := make(map[uuid.UUID]domain.Account, 2)
+
+	ids := []uuid.UUID{senderID, receiverID}
+	if senderID.String() > receiverID.String() {
+		ids = []uuid.UUID{receiverID, senderID}
+	}
+
+	for _, id := range ids {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+
+		account, err := lockAccount(repositories, id)
+		if err != nil {
+			return domain.Account{}, domain.Account{}, err
+		}
+
+		accounts[id] = account
+	}
+
+	return accounts[senderID], accounts[receiverID], nil
+}
+
+func lockTransaction(repositories ports.IRepositories, transactionID uuid.UUID) (domain.Transaction, error) {
+	transaction, err := repositories.GetTransactionForUpdate(transactionID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return domain.Transaction{}, domain.NotFoundError(errors.New("Account not found"))
+			return domain.Transaction{}, domain.NotFoundError(errors.New("Transaction not found"))
 		}
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to update receiver: "+err.Error()))
+		return domain.Transaction{}, domain.InternalFailure(fmt.Errorf("Failed to get transaction: %w", err))
 	}
 
-	// Create the transaction
-	_, err = ts.TransactionRepository.CreateTransaction(transaction)
+	return transaction, nil
+}
+
+func lockAccount(repositories ports.IRepositories, accountID uuid.UUID) (domain.Account, error) {
+	account, err := repositories.GetAccountForUpdate(accountID)
 	if err != nil {
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to create transaction: "+err.Error())) 
+		if err == sql.ErrNoRows {
+			return domain.Account{}, domain.NotFoundError(errors.New("Account not found"))
+		}
+		return domain.Account{}, domain.InternalFailure(fmt.Errorf("Failed to get account: %w", err))
 	}
 
-	if err := tx.Commit(); err != nil {
-		return domain.Transaction{}, domain.InternalFailure(errors.New("Failed to commit: "+err.Error()))
+	return account, nil
+}
+
+// lockMandate locks the mandate, so collections under it are checked against its limits one at a time.
+func lockMandate(repositories ports.IRepositories, mandateID uuid.UUID) (domain.Mandate, error) {
+	mandate, err := repositories.GetMandateForUpdate(mandateID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Mandate{}, domain.NotFoundError(errors.New("Mandate not found"))
+		}
+		return domain.Mandate{}, domain.InternalFailure(fmt.Errorf("Failed to get mandate: %w", err))
 	}
 
-	return transaction, nil
-}
\ No newline at end of file
+	return mandate, nil
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 )
@@ -25,6 +27,7 @@ func Test_Account_Create_Works(t *testing.T) {
 	db.CreateCustomer(customer)
 	db.CreateAccount(account)
 
+	// Accounts always start out empty, the balance is ignored
 	body := fmt.Sprintf(`
 	{
 		"Balance": 1000.00,
@@ -39,10 +42,11 @@ func Test_Account_Create_Works(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer.ID))
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Post("/api/customer/{customer_id}/account", handlers.NewAccountHandler(server.AccountService).Create)
+	router.With(server.TokenAuth).Post("/api/customer/{customer_id}/account", handlers.NewAccountHandler(server.AccountService).Create)
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusCreated, recorder.Code)
@@ -51,6 +55,12 @@ func Test_Account_Create_Works(t *testing.T) {
 	id := recorder.Header().Get("Location")[idStartIndex+len(url+"/"):]
 
 	assertDatabaseHas(t, "accounts", "id", id, db)
+
+	created, err := server.AccountService.Get(uuid.MustParse(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, true, created.Balance.IsZero())
 }
 
 func Test_Account_Create_ValidationWorks(t *testing.T) {
@@ -60,7 +70,6 @@ func Test_Account_Create_ValidationWorks(t *testing.T) {
 	customer := NewTestCustomer()
 
 	body := `{
-		"Balance": -1000.00,
 		"Type": 134,
 		"Currency": "USD"
 	}`
@@ -71,10 +80,11 @@ func Test_Account_Create_ValidationWorks(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer.ID))
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Post("/api/customer/{customer_id}/account", handlers.NewAccountHandler(server.AccountService).Create)
+	router.With(server.TokenAuth).Post("/api/customer/{customer_id}/account", handlers.NewAccountHandler(server.AccountService).Create)
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusBadRequest, recorder.Code)
@@ -88,7 +98,6 @@ func Test_Account_Create_ValidationWorks(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	assertEqual(t, true, slices.Contains(rBody.Errors, "Balance cannot be negative"))
 	assertEqual(t, true, slices.Contains(rBody.Errors, "Invalid account type"))
 }
 
@@ -186,8 +195,6 @@ func Test_Account_GetAll_FilterByCustomerWorks(t *testing.T) {
 func Test_Account_Update_Works(t *testing.T) {
 	customer := NewTestCustomer()
 	account := NewTestAccount(customer.ID)
-	account.Status = true
-	account.InterestRate = 1
 
 	db := NewTestDatabase()
 	server := NewTestServer(db)
@@ -196,17 +203,14 @@ func Test_Account_Update_Works(t *testing.T) {
 	db.CreateCustomer(customer)
 	db.CreateAccount(account)
 
-	assertDatabaseMissing(t, "accounts", "status", false, db)
-	assertDatabaseMissing(t, "accounts", "interest_rate", 0.025, db)
+	assertDatabaseMissing(t, "accounts", "nickname", "Holiday savings", db)
 
+	// Balances cannot be set by the customer anymore, the field is ignored
 	body := `
 	{
-		"Balance": 1000.00,
-		"Type": 3,
-		"Currency": "USD",
-		"Status": false,
-		"LastTransactionDate": "2024-04-19T12:00:00Z",
-		"InterestRate": 0.025
+		"Nickname": "Holiday savings",
+		"Status": "FROZEN",
+		"Balance": 1000000.00
 	}`
 
 	url := fmt.Sprintf("/api/customer/%s/account/%s", account.CustomerID.String(), account.ID.String())
@@ -224,35 +228,228 @@ func Test_Account_Update_Works(t *testing.T) {
 
 	assertEqual(t, http.StatusOK, recorder.Code)
 
-	assertDatabaseHas(t, "accounts", "status", false, db)
-	assertDatabaseHas(t, "accounts", "interest_rate", 0.025, db)
+	assertDatabaseHas(t, "accounts", "nickname", "Holiday savings", db)
+	assertDatabaseHas(t, "accounts", "status", string(domain.AccountFrozen), db)
+
+	updated, err := server.AccountService.Get(account.ID)
+	assertEqual(t, nil, err)
+	assertEqual(t, true, updated.Balance.IsZero())
+
+	// Only the staff of the bank can unfreeze the account
+	_, err = server.AccountService.Update(account.ID, domain.UpdateAccountRequest{Status: domain.AccountActive})
+	assertEqual(t, true, errors.Is(err, domain.ErrForbidden))
 }
 
-func Test_Account_Delete_Works(t *testing.T) {
+func Test_Account_Update_KeepsNicknameWhenMissing(t *testing.T) {
 	customer := NewTestCustomer()
 	account := NewTestAccount(customer.ID)
+	account.Nickname = "Holiday savings"
 
 	db := NewTestDatabase()
 	server := NewTestServer(db)
+	defer db.ClearAllTables()
 
-	db.ClearAllTables()
 	db.CreateCustomer(customer)
 	db.CreateAccount(account)
 
-	assertDatabaseHas(t, "accounts", "id", account.ID.String(), db)
-
 	url := fmt.Sprintf("/api/customer/%s/account/%s", account.CustomerID.String(), account.ID.String())
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequest("PUT", url, strings.NewReader(`{"Status": "FROZEN"}`))
 	if err != nil {
 		t.Fatal(err)
 	}
+
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Delete("/api/customer/{customer_id}/account/{account_id}", handlers.NewAccountHandler(server.AccountService).Delete)
+	router.Put("/api/customer/{customer_id}/account/{account_id}", handlers.NewAccountHandler(server.AccountService).Update)
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusOK, recorder.Code)
-	assertDatabaseMissing(t, "accounts", "id", account.ID.String(), db)
+
+	updated, err := server.AccountService.Get(account.ID)
+	assertEqual(t, nil, err)
+	assertEqual(t, "Holiday savings", updated.Nickname)
+	assertEqual(t, domain.AccountFrozen, updated.Status)
+
+	// An empty nickname removes it
+	empty := ""
+	_, err = server.AccountService.Update(account.ID, domain.UpdateAccountRequest{Nickname: &empty})
+	assertEqual(t, nil, err)
+
+	updated, err = server.AccountService.Get(account.ID)
+	assertEqual(t, nil, err)
+	assertEqual(t, "", updated.Nickname)
+}
+
+func Test_Account_Adjust_IsBookedInTheLedger(t *testing.T) {
+	customer := NewTestCustomer()
+	account := NewTestAccount(customer.ID)
+	account.Balance = domain.NewMoney(10_00, "USD")
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(account)
+
+	staff := uuid.New()
+
+	_, err := server.AccountService.Adjust(account.ID, staff, domain.CreateAdjustmentRequest{Amount: "5", Reason: "BECAUSE", Note: ""})
+	assertEqual(t, []string{"Unknown adjustment reason BECAUSE", "Note is required"}, domain.ExtractValidationErrorsToList(err))
+
+	_, err = server.AccountService.Adjust(account.ID, staff, domain.CreateAdjustmentRequest{Amount: "-20", Reason: domain.AdjustmentCorrection, Note: "Double booked deposit"})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+
+	adjustment, err := server.AccountService.Adjust(account.ID, staff, domain.CreateAdjustmentRequest{Amount: "-2.50", Reason: domain.AdjustmentCorrection, Note: "Double booked deposit"})
+	assertEqual(t, nil, err)
+	assertEqual(t, staff, adjustment.CreatedBy)
+
+	balance, err := server.LedgerService.Verify(account.ID)
+	assertEqual(t, nil, err)
+	assertEqual(t, "7.50", balance.Stored.String())
+	assertEqual(t, true, balance.IsBalanced())
+
+	adjustments, err := server.AccountService.Adjustments(account.ID, 10, 0)
+	assertEqual(t, nil, err)
+	assertEqual(t, adjustment.ID, adjustments[0].ID)
+	assertEqual(t, domain.AdjustmentCorrection, adjustments[0].Reason)
+}
+
+func Test_Account_Status_TransitionsAreEnforced(t *testing.T) {
+	account := NewTestAccount(uuid.New())
+
+	assertEqual(t, nil, account.TransitionTo(domain.AccountFrozen))
+	assertNotEqual(t, nil, account.TransitionTo(domain.AccountClosed))
+	assertEqual(t, nil, account.TransitionTo(domain.AccountActive))
+	assertEqual(t, nil, account.TransitionTo(domain.AccountDormant))
+	assertEqual(t, false, account.IsActive())
+	assertEqual(t, nil, account.TransitionTo(domain.AccountClosed))
+	assertNotEqual(t, nil, account.TransitionTo(domain.AccountActive))
+}
+
+func Test_Account_Close_SweepsTheRemainingBalance(t *testing.T) {
+	customer := NewTestCustomer()
+	account := NewTestAccount(customer.ID)
+	account.Balance = domain.NewMoney(250_00, "USD")
+	target := NewTestAccount(customer.ID)
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(account)
+	db.CreateAccount(target)
+
+	// An account holding money needs somewhere to sweep it to
+	_, err := server.AccountService.Close(account.ID, domain.CloseAccountRequest{})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+
+	// The balance cannot be swept to an account of someone else
+	stranger := NewTestCustomer()
+	strangerAccount := NewTestAccount(stranger.ID)
+	db.CreateCustomer(stranger)
+	db.CreateAccount(strangerAccount)
+
+	_, err = server.AccountService.Close(account.ID, domain.CloseAccountRequest{SweepAccountID: strangerAccount.ID})
+	assertEqual(t, "Error bad request: Balance can only be swept to another account of the same customer", err.Error())
+
+	// Frozen accounts cannot receive the sweep
+	_, err = server.AccountService.Freeze(target.ID)
+	assertEqual(t, nil, err)
+
+	_, err = server.AccountService.Close(account.ID, domain.CloseAccountRequest{SweepAccountID: target.ID})
+	assertEqual(t, true, errors.Is(err, domain.ErrConflict))
+
+	_, err = server.AccountService.Unfreeze(target.ID)
+	assertEqual(t, nil, err)
+
+	closed, err := server.AccountService.Close(account.ID, domain.CloseAccountRequest{SweepAccountID: target.ID})
+	assertEqual(t, nil, err)
+	assertEqual(t, domain.AccountClosed, closed.Status)
+	assertEqual(t, true, closed.Balance.IsZero())
+
+	swept, err := server.AccountService.Get(target.ID)
+	assertEqual(t, nil, err)
+	assertEqual(t, "250.00", swept.Balance.String())
+
+	// Closed accounts stay closed
+	_, err = server.AccountService.Reactivate(account.ID)
+	assertEqual(t, true, errors.Is(err, domain.ErrConflict))
+}
+
+func Test_Account_Overdraft_LimitsWhatCanBeSent(t *testing.T) {
+	account := NewTestAccount(uuid.New())
+	account.Balance = domain.MoneyFromMajor(100, "USD")
+	account.OverdraftLimit = domain.MoneyFromMajor(500, "USD")
+
+	assertEqual(t, true, account.CanCover(domain.MoneyFromMajor(600, "USD")))
+	assertEqual(t, false, account.CanCover(domain.MoneyFromMajor(601, "USD")))
+
+	account.Balance = domain.MoneyFromMajor(-500, "USD")
+	assertEqual(t, (*domain.ValidationErrors)(nil), account.Validate())
+
+	account.Balance = domain.MoneyFromMajor(-501, "USD")
+	assertEqual(t, []string{"Balance cannot be below the overdraft limit"}, account.Validate().Errors)
+
+	account.Type = 3
+	account.Balance = domain.MoneyFromMajor(0, "USD")
+	assertEqual(t, []string{"Savings account cannot have an overdraft"}, account.Validate().Errors)
+}
+
+func Test_Account_Overdraft_AllowsTransfersDownToTheLimit(t *testing.T) {
+	customer := NewTestCustomer()
+	sender := NewTestAccount(customer.ID)
+	receiver := NewTestAccount(customer.ID)
+	sender.Balance = domain.MoneyFromMajor(100, "USD")
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	setOverdraft := func(body string) int {
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/admin/account/%s/overdraft", sender.ID), strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	transfer := func(amount string) error {
+		_, err := server.TransactionService.Create(domain.CreateTransactionRequest{
+			SenderAccountID:   sender.ID,
+			ReceiverAccountID: receiver.ID,
+			Amount:            json.Number(amount),
+		})
+		return err
+	}
+
+	assertEqual(t, true, errors.Is(transfer("150"), domain.ErrBadRequest))
+
+	assertEqual(t, http.StatusOK, setOverdraft(`{"Limit": 500, "InterestRate": 0.12}`))
+	assertEqual(t, nil, transfer("550"))
+	assertEqual(t, true, errors.Is(transfer("51"), domain.ErrBadRequest))
+
+	// The limit cant be lowered below what is already used of it
+	assertEqual(t, http.StatusBadRequest, setOverdraft(`{"Limit": 100, "InterestRate": 0.12}`))
+
+	updated, err := server.AccountService.Get(sender.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "-450.00", updated.Balance.String())
+	assertEqual(t, "500.00", updated.OverdraftLimit.String())
+	assertEqual(t, "50.00", updated.Available().String())
+
+	// Overdrawn accounts have to be paid off before closing them
+	_, err = server.AccountService.Close(sender.ID, domain.CloseAccountRequest{SweepAccountID: receiver.ID})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
 }
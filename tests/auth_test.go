@@ -0,0 +1,282 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/web"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/auth"
+)
+
+func newTestClaims(at time.Time) domain.Claims {
+	return domain.Claims{
+		Issuer:    auth.ISSUER,
+		Subject:   uuid.NewString(),
+		IssuedAt:  at.Unix(),
+		ExpiresAt: at.Add(domain.ACCESS_TOKEN_TTL).Unix(),
+		ID:        uuid.NewString(),
+	}
+}
+
+func Test_Auth_JWT_SignAndParseWorks(t *testing.T) {
+	for _, algorithm := range []auth.Algorithm{auth.HS256, auth.EdDSA} {
+		key, err := auth.GenerateKey("key-1", algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := auth.NewKeySet(key)
+
+		claims := newTestClaims(time.Now())
+
+		token, err := auth.Sign(claims, keys.Active())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := auth.Parse(token, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, claims, parsed)
+		assertEqual(t, nil, parsed.Validate(auth.ISSUER, time.Now()))
+	}
+}
+
+func Test_Auth_JWT_RejectsTamperedTokens(t *testing.T) {
+	key, _ := auth.GenerateKey("key-1", auth.EdDSA)
+	keys := auth.NewKeySet(key)
+
+	token, err := auth.Sign(newTestClaims(time.Now()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	// Swap the subject for another customer
+	forged, _ := json.Marshal(newTestClaims(time.Now()))
+	_, err = auth.Parse(parts[0]+"."+base64.RawURLEncoding.EncodeToString(forged)+"."+parts[2], keys)
+	assertEqual(t, "invalid token signature", err.Error())
+
+	// Downgrade the algorithm to none
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT","kid":"key-1"}`))
+	_, err = auth.Parse(none+"."+parts[1]+".", keys)
+	assertEqual(t, "unexpected signing algorithm", err.Error())
+
+	// Sign with a key the server doesnt know
+	other, _ := auth.GenerateKey("key-2", auth.EdDSA)
+	token, _ = auth.Sign(newTestClaims(time.Now()), other)
+	_, err = auth.Parse(token, keys)
+	assertEqual(t, "unknown signing key", err.Error())
+}
+
+func Test_Auth_Claims_ValidateRejectsExpiredTokens(t *testing.T) {
+	claims := newTestClaims(time.Now().Add(-time.Hour))
+
+	assertEqual(t, "token expired", claims.Validate(auth.ISSUER, time.Now()).Error())
+	assertEqual(t, "token was issued by someone else", claims.Validate("someone", time.Now()).Error())
+}
+
+func Test_Auth_KeySet_RotationWorks(t *testing.T) {
+	old, _ := auth.GenerateKey("old", auth.EdDSA)
+	keys := auth.NewKeySet(old)
+
+	token, _ := auth.Sign(newTestClaims(time.Now()), keys.Active())
+
+	secret, _ := auth.GenerateKey("secret", auth.HS256)
+	keys.Rotate(secret)
+	assertEqual(t, "secret", keys.Active().ID)
+
+	// Tokens signed before the rotation stay valid until the key is retired
+	_, err := auth.Parse(token, keys)
+	assertEqual(t, nil, err)
+
+	assertEqual(t, false, keys.Retire("secret"))
+	assertEqual(t, true, keys.Retire("old"))
+
+	_, err = auth.Parse(token, keys)
+	assertEqual(t, "unknown signing key", err.Error())
+}
+
+func Test_Auth_KeySet_JWKSOnlyHasPublicKeys(t *testing.T) {
+	public, _ := auth.NewEdDSAKey("public", make([]byte, 32))
+	secret, _ := auth.GenerateKey("secret", auth.HS256)
+
+	jwks := auth.NewKeySet(secret, public).JWKS()
+
+	assertEqual(t, 1, len(jwks.Keys))
+	assertEqual(t, "public", jwks.Keys[0].KeyID)
+	assertEqual(t, "OKP", jwks.Keys[0].KeyType)
+	assertEqual(t, "Ed25519", jwks.Keys[0].Curve)
+	assertEqual(t, "O2onvM62pC1io6jQKm8Nc2UyFXcd4kOmOsBIoYtZ2ik", jwks.Keys[0].X)
+}
+
+func Test_Auth_ParseKey_Works(t *testing.T) {
+	key, err := auth.ParseKey("2024-06:HS256:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "2024-06", key.ID)
+	assertEqual(t, auth.HS256, key.Algorithm)
+
+	_, err = auth.ParseKey("2024-06:HS256:" + base64.StdEncoding.EncodeToString([]byte("short")))
+	assertEqual(t, "HS256 secret must be at least 32 bytes long", err.Error())
+
+	_, err = auth.ParseKey("2024-06:RS256:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	assertEqual(t, "unsupported algorithm: RS256", err.Error())
+}
+
+func Test_Middleware_TokenAuth_PutsCustomerIntoContext(t *testing.T) {
+	server := web.NewServer(":8080", chi.NewMux())
+	server.AuthService = auth.NewAuthService(nil, nil, nil, nil, NewTestKeySet(), clock.NewSystemClock())
+	customerID := uuid.New()
+
+	router := chi.NewRouter()
+	router.With(server.TokenAuth).Get("/api/customer/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+		authenticated, ok := handlers.CustomerIDFromContext(r.Context())
+		assertEqual(t, true, ok)
+		assertEqual(t, customerID, authenticated)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/customer/%s", customerID.String()), nil)
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customerID))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusNoContent, recorder.Code)
+
+	// A valid token of one customer cannot be used on the routes of another
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/customer/%s", uuid.NewString()), nil)
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customerID))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func Test_Auth_LoginAndRefresh_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	customer, err := server.CustomerService.Create(domain.CreateCustomerRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Birthday:  NewTestCustomer().Birthday,
+		Email:     "john@example.com",
+		Phone:     "123-456-7890",
+		State:     "CA",
+		Address:   "123 Main St",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"CustomerID": "%s", "Token": "%s"}`, customer.ID.String(), customer.Token)
+
+	req, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+
+	login := struct {
+		Data domain.TokenPairDTO `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&login); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "Bearer", login.Data.TokenType)
+	assertEqual(t, int64(domain.ACCESS_TOKEN_TTL.Seconds()), login.Data.ExpiresIn)
+
+	claims, err := server.AuthService.Verify(login.Data.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, customer.ID.String(), claims.Subject)
+
+	// Refreshing gives a new pair and uses up the refresh token
+	refreshed, err := server.AuthService.Refresh(domain.RefreshRequest{RefreshToken: login.Data.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNotEqual(t, login.Data.RefreshToken, refreshed.RefreshToken)
+
+	// Using the old refresh token again revokes the whole family, including the new token
+	_, err = server.AuthService.Refresh(domain.RefreshRequest{RefreshToken: login.Data.RefreshToken})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	_, err = server.AuthService.Refresh(domain.RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+}
+
+func Test_Auth_Refresh_StopsAfterTokenRevoked(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	customer, err := server.CustomerService.Create(domain.CreateCustomerRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Birthday:  NewTestCustomer().Birthday,
+		Email:     "john@example.com",
+		Phone:     "123-456-7890",
+		State:     "CA",
+		Address:   "123 Main St",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: customer.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := db.GetCustomerTokens(customer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = server.CustomerService.RevokeToken(customer.ID, tokens[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session started with the token ends with it
+	_, err = server.AuthService.Refresh(domain.RefreshRequest{RefreshToken: login.RefreshToken})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+}
+
+func Test_Auth_Login_RejectsBadCredentials(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	body := fmt.Sprintf(`{"CustomerID": "%s", "Token": "%s"}`, customer.ID.String(), customer.Token)
+
+	req, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	// The customer was created without any stored token
+	assertEqual(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Consent_Validate_Works(t *testing.T) {
+	now := time.Now()
+
+	consent := domain.Consent{
+		ID:         uuid.New(),
+		CustomerID: uuid.New(),
+		ClientName: "Budget App",
+		AccountIDs: []uuid.UUID{uuid.New()},
+		Scopes:     []domain.Scope{domain.SCOPE_READ_BALANCE},
+		Salt:       []byte("salt"),
+		Hash:       []byte("hash"),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(domain.MAX_CONSENT_TTL),
+	}
+
+	assertEqual(t, true, consent.Validate() == nil)
+	assertEqual(t, true, consent.HasScope(domain.SCOPE_READ_BALANCE))
+	assertEqual(t, false, consent.HasScope(domain.SCOPE_INITIATE_PAYMENT))
+	assertEqual(t, true, consent.CoversAccount(consent.AccountIDs[0]))
+	assertEqual(t, false, consent.CoversAccount(uuid.New()))
+
+	consent.Scopes = []domain.Scope{"accounts:delete"}
+	consent.ExpiresAt = now.Add(domain.MAX_CONSENT_TTL + time.Hour)
+
+	assertEqual(t, []string{"Unknown scope accounts:delete", "ExpiresAt must be at most 90 days away"}, consent.Validate().Errors)
+}
+
+func Test_Middleware_APIKeys_RejectedOnRoutesNotOpenedToThem(t *testing.T) {
+	server := NewTestAuthServer()
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate).Get("/api/account", func(w http.ResponseWriter, r *http.Request) {
+		panic("Middleware is not working!")
+	})
+
+	req, _ := http.NewRequest("GET", "/api/account", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.API_KEY_PREFIX+uuid.NewString()+".secret")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusForbidden, recorder.Code)
+}
+
+func Test_Consent_APIKey_IsScopedToAccountsAndOperations(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	covered := NewTestAccount(customer.ID)
+	other := NewTestAccount(customer.ID)
+	stranger := NewTestAccount(uuid.New())
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(covered)
+	db.CreateAccount(other)
+
+	// Customers can only consent to their own accounts
+	_, _, err := server.ConsentService.Create(customer.ID, domain.CreateConsentRequest{
+		ClientName: "Budget App",
+		AccountIDs: []uuid.UUID{covered.ID, stranger.ID},
+		Scopes:     []domain.Scope{domain.SCOPE_READ_BALANCE},
+	})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+
+	consent, apiKey, err := server.ConsentService.Create(customer.ID, domain.CreateConsentRequest{
+		ClientName: "Budget App",
+		AccountIDs: []uuid.UUID{covered.ID},
+		Scopes:     []domain.Scope{domain.SCOPE_READ_BALANCE},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(method, url string) int {
+		req, _ := http.NewRequest(method, url, nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assertEqual(t, http.StatusOK, request("GET", fmt.Sprintf("/api/account/%s", covered.ID)))
+	assertEqual(t, http.StatusForbidden, request("GET", fmt.Sprintf("/api/account/%s", other.ID)))
+	assertEqual(t, http.StatusForbidden, request("GET", fmt.Sprintf("/api/transaction?account_id=%s", covered.ID)))
+	assertEqual(t, http.StatusForbidden, request("DELETE", fmt.Sprintf("/api/customer/%s", customer.ID)))
+	assertEqual(t, http.StatusForbidden, request("GET", fmt.Sprintf("/api/customer/%s/consent", customer.ID)))
+
+	_, err = server.ConsentService.Revoke(customer.ID, consent.ID)
+	assertEqual(t, nil, err)
+
+	assertEqual(t, http.StatusUnauthorized, request("GET", fmt.Sprintf("/api/account/%s", covered.ID)))
+}
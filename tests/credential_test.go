@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/credential"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/transactions"
+)
+
+// The SHA1 secret of the RFC 6238 test vectors.
+var rfcTOTPSecret = []byte("12345678901234567890")
+
+func Test_Credential_TOTPCode_MatchesRFC6238(t *testing.T) {
+	assertEqual(t, "287082", credential.TOTPCode(rfcTOTPSecret, credential.TOTPStep(time.Unix(59, 0))))
+	assertEqual(t, "081804", credential.TOTPCode(rfcTOTPSecret, credential.TOTPStep(time.Unix(1111111109, 0))))
+	assertEqual(t, "005924", credential.TOTPCode(rfcTOTPSecret, credential.TOTPStep(time.Unix(1234567890, 0))))
+}
+
+func Test_Credential_VerifyTOTP_RejectsReplays(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+
+	step, ok := credential.VerifyTOTP(rfcTOTPSecret, "081 804", at, 0)
+	assertEqual(t, true, ok)
+	assertEqual(t, credential.TOTPStep(at), step)
+
+	// The previous step is still accepted for clock drift, but not once a later code was used
+	_, ok = credential.VerifyTOTP(rfcTOTPSecret, "081804", at.Add(credential.TOTP_PERIOD*time.Second), 0)
+	assertEqual(t, true, ok)
+	_, ok = credential.VerifyTOTP(rfcTOTPSecret, "081804", at, step)
+	assertEqual(t, false, ok)
+
+	_, ok = credential.VerifyTOTP(rfcTOTPSecret, "000000", at, 0)
+	assertEqual(t, false, ok)
+}
+
+func Test_Credential_Password_HashAndVerifyWorks(t *testing.T) {
+	hash, err := credential.HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, true, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$"))
+
+	matches, err := credential.VerifyPassword("correct horse battery staple", hash)
+	assertEqual(t, nil, err)
+	assertEqual(t, true, matches)
+
+	matches, err = credential.VerifyPassword("correct horse battery stapler", hash)
+	assertEqual(t, nil, err)
+	assertEqual(t, false, matches)
+
+	other, _ := credential.HashPassword("correct horse battery staple")
+	assertNotEqual(t, hash, other)
+}
+
+func Test_Credential_ValidatePassword_Works(t *testing.T) {
+	assertEqual(t, "Password must be at least 12 characters long", domain.ValidatePassword("short").Error())
+	assertEqual(t, "Password must be at most 128 characters long", domain.ValidatePassword(strings.Repeat("a", 129)).Error())
+	assertEqual(t, true, domain.ValidatePassword("long enough password") == nil)
+}
+
+// enrollTestTwoFactor enables two-factor authentication for the customer and returns the secret and the recovery codes.
+func enrollTestTwoFactor(t *testing.T, service *credential.CredentialService, owner domain.Customer) ([]byte, []string) {
+	t.Helper()
+
+	enrollment, err := service.EnrollTOTP(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := credential.DecodeTOTPSecret(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Confirm with the code of the previous step, so the current one stays unused
+	code := credential.TOTPCode(secret, credential.TOTPStep(time.Now())-1)
+	recoveryCodes, err := service.ConfirmTOTP(owner.ID, domain.TwoFactorCodeRequest{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return secret, recoveryCodes
+}
+
+func Test_Credential_PasswordLogin_RequiresSecondFactor(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	service := server.CredentialService.(*credential.CredentialService)
+
+	if err := service.SetPassword(customer.ID, domain.SetPasswordRequest{NewPassword: "correct horse battery staple"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the password needs the current one
+	err := service.SetPassword(customer.ID, domain.SetPasswordRequest{NewPassword: "another long password"})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple"})
+	assertEqual(t, nil, err)
+
+	secret, recoveryCodes := enrollTestTwoFactor(t, service, customer)
+	assertEqual(t, domain.RECOVERY_CODE_COUNT, len(recoveryCodes))
+
+	// Once enabled the password alone is not enough
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple"})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	code := credential.TOTPCode(secret, credential.TOTPStep(time.Now()))
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple", Code: code})
+	assertEqual(t, nil, err)
+
+	// The same code cannot be used twice
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple", Code: code})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	// Recovery codes work once, with or without the dashes
+	recovery := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", ""))
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple", Code: recovery})
+	assertEqual(t, nil, err)
+	_, err = server.AuthService.PasswordLogin(domain.PasswordLoginRequest{CustomerID: customer.ID, Password: "correct horse battery staple", Code: recoveryCodes[0]})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+}
+
+func Test_Credential_StepUp_RequiredAboveThreshold(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	service := transactions.NewTransactionService(db, db, db, server.LedgerService, server.FXService, server.CredentialService, server.CurrencyService, 500, clock.NewSystemClock())
+
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+	sender := NewTestAccount(customer1.ID)
+	receiver := NewTestAccount(customer2.ID)
+	sender.Balance = domain.MoneyFromMajor(5000, "USD")
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	request := domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            json.Number("1000"),
+	}
+
+	// Small transfers dont need a second factor
+	_, err := service.Create(domain.CreateTransactionRequest{SenderAccountID: sender.ID, ReceiverAccountID: receiver.ID, Amount: json.Number("500")})
+	assertEqual(t, nil, err)
+
+	// Without two-factor authentication large transfers are forbidden
+	_, err = service.Create(request)
+	assertEqual(t, true, errors.Is(err, domain.ErrForbidden))
+
+	secret, _ := enrollTestTwoFactor(t, server.CredentialService.(*credential.CredentialService), customer1)
+
+	_, err = service.Create(request)
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	request.Code = credential.TOTPCode(secret, credential.TOTPStep(time.Now()))
+	_, err = service.Create(request)
+	assertEqual(t, nil, err)
+}
+
+func Test_Credential_TokenLogin_RequiresSecondFactor(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	customer, err := server.CustomerService.Create(domain.CreateCustomerRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Birthday:  NewTestCustomer().Birthday,
+		Email:     "john@example.com",
+		Phone:     "123-456-7890",
+		State:     "CA",
+		Address:   "123 Main St",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, _ := enrollTestTwoFactor(t, server.CredentialService.(*credential.CredentialService), customer)
+
+	// Once enabled the API token alone is not enough either
+	_, err = server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: customer.Token})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	code := credential.TOTPCode(secret, credential.TOTPStep(time.Now()))
+	_, err = server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: customer.Token, Code: code})
+	assertEqual(t, nil, err)
+}
+
+func Test_Credential_SecondFactor_LocksAfterTooManyInvalidCodes(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	service := server.CredentialService.(*credential.CredentialService)
+
+	enrollment, err := service.EnrollTOTP(customer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, _ := credential.DecodeTOTPSecret(enrollment.Secret)
+	if _, err := service.ConfirmTOTP(customer.ID, domain.TwoFactorCodeRequest{Code: credential.TOTPCode(secret, credential.TOTPStep(clock.Now())-1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < domain.MAX_SECOND_FACTOR_ATTEMPTS; i++ {
+		err := service.CheckSecondFactor(customer.ID, "not-a-code")
+		assertEqual(t, "Error unauthorized: Invalid two-factor code", err.Error())
+	}
+
+	// Even the right code is rejected now
+	err = service.CheckSecondFactor(customer.ID, credential.TOTPCode(secret, credential.TOTPStep(clock.Now())))
+	assertEqual(t, "Error unauthorized: Too many invalid two-factor codes, try again later", err.Error())
+
+	clock.Advance(domain.SECOND_FACTOR_LOCKOUT)
+	err = service.CheckSecondFactor(customer.ID, credential.TOTPCode(secret, credential.TOTPStep(clock.Now())))
+	assertEqual(t, nil, err)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/currency"
+)
+
+func Test_Currency_Registry_HasMinorUnits(t *testing.T) {
+	assertEqual(t, 2, domain.Currency("USD").Exponent())
+	assertEqual(t, 0, domain.Currency("JPY").Exponent())
+	assertEqual(t, 3, domain.Currency("KWD").Exponent())
+
+	info, ok := domain.Currencies.Lookup("CZK")
+	assertEqual(t, true, ok)
+	assertEqual(t, "203", info.NumericCode)
+
+	assertEqual(t, "1000", domain.MoneyFromMajor(1000, "JPY").String())
+	assertEqual(t, "1.500", domain.NewMoney(1500, "KWD").String())
+}
+
+func Test_Currency_PairParse_RejectsUnknownCodes(t *testing.T) {
+	_, err := domain.CurrencyPairParse("USD-XXY")
+	assertNotEqual(t, nil, err)
+
+	pair, err := domain.CurrencyPairParse("JPY-CZK")
+	assertEqual(t, nil, err)
+	assertEqual(t, "JPY-CZK", pair.String())
+}
+
+func Test_Currency_Update_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	// Another instance of the server sharing the database
+	other := currency.NewCurrencyService(db, domain.Currencies)
+	assertEqual(t, true, errors.Is(other.CheckEnabled("GBP"), domain.ErrValidation))
+
+	body, _ := json.Marshal(domain.UpdateCurrencyRequest{Enabled: true})
+
+	// Without the admin token
+	req, _ := http.NewRequest("PUT", "/api/admin/currency/GBP", bytes.NewBuffer(body))
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusUnauthorized, recorder.Code)
+
+	req, _ = http.NewRequest("PUT", "/api/admin/currency/GBP", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+	assertEqual(t, nil, server.CurrencyService.CheckEnabled("GBP"))
+	assertDatabaseHas(t, "currencies", "code", "GBP", db)
+
+	// Every instance of the server reads the change from the database
+	assertEqual(t, nil, other.CheckEnabled("GBP"))
+
+	req, _ = http.NewRequest("GET", "/api/currency?enabled=true", nil)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	rBody := struct {
+		Data []domain.CurrencyDTO `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&rBody); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+	assertEqual(t, 3, len(rBody.Data))
+}
+
+func Test_Currency_Disabled_KeepsExistingAccountsWorking(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, err := NewTestFundedAccount(server, customer.ID, "USD", "100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.CurrencyService.SetEnabled("USD", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// No new accounts or transfers in the disabled currency
+	_, err = server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+	assertEqual(t, true, errors.Is(err, domain.ErrValidation))
+
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{SenderAccountID: sender.ID, ReceiverAccountID: receiver.ID, Amount: "10"})
+	assertEqual(t, true, errors.Is(err, domain.ErrValidation))
+
+	// But the money held in it can still leave through a closing sweep
+	if _, err := server.AccountService.Close(sender.ID, domain.CloseAccountRequest{SweepAccountID: receiver.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := server.AccountService.Get(receiver.ID)
+	assertEqual(t, "100.00", updated.Balance.String())
+}
@@ -7,10 +7,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	customerService "github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
 )
 
 func Test_Customer_GetAll_Works(t *testing.T) {
@@ -248,7 +251,8 @@ func Test_Customer_Create_Works(t *testing.T) {
 
 
 	assertDatabaseHas(t, "customers", "id", id, db)
-	assertDatabaseHas(t, "customers", "token", rBody.Data.Token, db)
+	assertDatabaseHas(t, "customer_tokens", "customer_id", id, db)
+	assertEqual(t, customerService.TOKEN_LENGTH, len(rBody.Data.Token))
 }
 
 func Test_Customer_Create_ValidationWorks(t *testing.T) {
@@ -326,10 +330,11 @@ func Test_Customer_Update_Works(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer1.ID))
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Put("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Update))
+	router.With(server.TokenAuth).Put("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Update))
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusOK, recorder.Code)
@@ -371,10 +376,11 @@ func Test_Customer_Update_ValidationWorks(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer.ID))
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Put("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Update))
+	router.With(server.TokenAuth).Put("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Update))
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusBadRequest, recorder.Code)
@@ -407,13 +413,27 @@ func Test_Customer_Delete_Works(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer.ID))
 	recorder := httptest.NewRecorder()
 
 	router := chi.NewMux()
-	router.Delete("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Delete))
+	router.With(server.TokenAuth).Delete("/api/customer/{customer_id}", http.HandlerFunc(handlers.NewCustomerHandler(server.CustomerService).Delete))
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusOK, recorder.Code)
 	assertDatabaseMissing(t, "customers", "id", customer.ID, db);
 
 }
+
+func Test_Customer_Validate_AgeFollowsTheClock(t *testing.T) {
+	now := clock.NewFakeClock(TEST_CLOCK_START)
+
+	// Turns 18 the day after the clock starts
+	customer := NewTestCustomer()
+	customer.Birthday = time.Date(2008, time.January, 16, 0, 0, 0, 0, time.UTC)
+
+	assertEqual(t, []string{"age must be at least 18"}, customer.Validate(now.Now()).Errors)
+
+	now.AdvanceDays(1)
+	assertEqual(t, (*domain.ValidationErrors)(nil), customer.Validate(now.Now()))
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/exchange"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	exchangeService "github.com/realtobi999/GO_BankDemoApi/src/core/services/exchange"
+)
+
+func Test_Exchange_Convert_Works(t *testing.T) {
+	service := exchangeService.NewExchangeService(exchange.NewFixedRateProvider(map[domain.CurrencyPair]string{
+		{From: "USD", To: "EUR"}: "0.9369",
+	}), "USD")
+
+	converted, rate, err := service.Convert(domain.MoneyFromMajor(100, "USD"), "EUR", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "93.69", converted.String())
+	assertEqual(t, "0.9369", domain.FormatRate(rate.Rate))
+}
+
+func Test_Exchange_Convert_UsesInverseRate(t *testing.T) {
+	service := exchangeService.NewExchangeService(exchange.NewFixedRateProvider(map[domain.CurrencyPair]string{
+		{From: "EUR", To: "USD"}: "1.25",
+	}), "USD")
+
+	converted, rate, err := service.Convert(domain.MoneyFromMajor(100, "USD"), "EUR", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "80.00", converted.String())
+	assertEqual(t, "0.8", domain.FormatRate(rate.Rate))
+}
+
+func Test_Exchange_Convert_GivesErrorWhenRateIsMissing(t *testing.T) {
+	service := exchangeService.NewExchangeService(exchange.NewFixedRateProvider(map[domain.CurrencyPair]string{}), "USD")
+
+	_, _, err := service.Convert(domain.MoneyFromMajor(100, "USD"), "EUR", time.Now())
+
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+}
+
+func Test_Exchange_Convert_UsesCrossRateThroughBaseCurrency(t *testing.T) {
+	service := exchangeService.NewExchangeService(exchange.NewFixedRateProvider(map[domain.CurrencyPair]string{
+		{From: "USD", To: "EUR"}: "0.8",
+		{From: "USD", To: "JPY"}: "150",
+	}), "USD")
+
+	converted, rate, err := service.Convert(domain.MoneyFromMajor(100, "EUR"), "JPY", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "18750", converted.String())
+	assertEqual(t, "187.5", domain.FormatRate(rate.Rate))
+	assertEqual(t, "cross:USD", rate.Source)
+}
+
+func Test_Exchange_LoadCSV_Works(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	content := "from,to,rate,effective_at\nusd,eur,0.9369,2024-01-01\nEUR,USD,1.0674,2024-01-01T12:00:00Z\n"
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := exchange.LoadCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, 2, len(rates))
+	assertEqual(t, domain.NewCurrencyPair("USD", "EUR"), rates[0].Pair)
+	assertEqual(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC), rates[1].EffectiveAt)
+}
+
+func Test_Exchange_Rate_UsesRateEffectiveAtTransactionTime(t *testing.T) {
+	db := NewTestDatabase()
+	NewTestServer(db)
+
+	pair := domain.NewCurrencyPair("USD", "EUR")
+	january := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	rate, _ := domain.ParseRate("0.9")
+	db.CreateExchangeRate(domain.NewExchangeRate(pair, rate, january, "test"))
+	rate, _ = domain.ParseRate("0.95")
+	db.CreateExchangeRate(domain.NewExchangeRate(pair, rate, february, "test"))
+
+	service := exchangeService.NewExchangeService(db, "USD")
+
+	mid, err := service.Rate(pair, january.AddDate(0, 0, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "0.9", domain.FormatRate(mid.Rate))
+
+	later, err := service.Rate(pair, february.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "0.95", domain.FormatRate(later.Rate))
+}
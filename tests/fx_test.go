@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/exchange"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	exchangeService "github.com/realtobi999/GO_BankDemoApi/src/core/services/exchange"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/fx"
+)
+
+func Test_FX_Price_AppliesSpreadAndFee(t *testing.T) {
+	rates := exchangeService.NewExchangeService(exchange.NewFixedRateProvider(map[domain.CurrencyPair]string{
+		{From: "USD", To: "EUR"}: "0.9",
+	}), "USD")
+	service := fx.NewFXService(nil, rates, nil, domain.FXPricing{Spread: domain.RatFromFloat(0.01), FeeRate: domain.RatFromFloat(0.005)}, clock.NewSystemClock())
+
+	quote, err := service.Price(domain.MoneyFromMajor(100, "USD"), "EUR", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "0.9", domain.FormatRate(quote.MidRate))
+	assertEqual(t, "0.891", domain.FormatRate(quote.Rate))
+	assertEqual(t, "0.50", quote.Fee.String())
+	assertEqual(t, "88.65", quote.Converted.String())
+
+	// Nothing is charged when the currency doesnt change
+	same, err := service.Price(domain.MoneyFromMajor(100, "USD"), "USD", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "0.00", same.Fee.String())
+	assertEqual(t, "100.00", same.Converted.String())
+}
+
+func Test_Ledger_TransferJournal_BooksTheFee(t *testing.T) {
+	transaction := NewTestTransaction(uuid.New(), uuid.New())
+	transaction.Amount = domain.MoneyFromMajor(100, "USD")
+	transaction.Fee = domain.NewMoney(50, "USD")
+
+	journal := domain.NewTransferJournal(transaction, domain.NewMoney(8865, "EUR"))
+
+	assertEqual(t, 5, len(journal.Entries))
+	assertEqual(t, domain.SystemFeeIncome, journal.Entries[1].SystemAccount)
+	assertEqual(t, (*domain.ValidationErrors)(nil), journal.Validate())
+}
+
+func Test_FX_Quote_LocksTheRateOfTheTransfer(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+
+	sender, err := NewTestFundedAccount(server, customer1.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := server.AccountService.Create(customer2.ID, domain.CreateAccountRequest{Type: 1, Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/api/fx/quote", strings.NewReader(`{"From": "USD", "To": "EUR", "Amount": 100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer1.ID))
+	recorder := httptest.NewRecorder()
+
+	router := chi.NewMux()
+	router.With(server.TokenAuth).Post("/api/fx/quote", handlers.NewFXHandler(server.FXService).Quote)
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	body := struct {
+		Data domain.FXQuoteDTO `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "0.9369", body.Data.Rate.String())
+	assertEqual(t, "93.69", body.Data.Converted.String())
+
+	// The market moves after the quote was made
+	rate, _ := domain.ParseRate("0.5")
+	db.CreateExchangeRate(domain.NewExchangeRate(domain.NewCurrencyPair("USD", "EUR"), rate, time.Now().Add(-time.Second), "test"))
+
+	// The quote is bound to the customer who asked for it
+	other, err := NewTestFundedAccount(server, customer2.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   other.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100",
+		QuoteID:           body.Data.ID,
+	})
+	assertEqual(t, true, errors.Is(err, domain.ErrNotFound))
+
+	transaction, err := server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100",
+		QuoteID:           body.Data.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "0.9369", domain.FormatRate(transaction.ExchangeRate))
+	assertEqual(t, body.Data.ID, transaction.QuoteID)
+	assertDatabaseHas(t, "accounts", "balance", "93.69", db)
+
+	// A quote can only be used once
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100",
+		QuoteID:           body.Data.ID,
+	})
+
+	assertEqual(t, true, errors.Is(err, domain.ErrConflict))
+}
+
+func Test_FX_Quote_GivesErrorWhenExpired(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+
+	sender, err := NewTestFundedAccount(server, customer1.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := server.AccountService.Create(customer2.ID, domain.CreateAccountRequest{Type: 1, Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote, err := server.FXService.Price(domain.MoneyFromMajor(100, "USD"), "EUR", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote.CustomerID = customer1.ID
+	db.CreateFXQuote(quote)
+
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100",
+		QuoteID:           quote.ID,
+	})
+
+	assertEqual(t, true, errors.Is(err, domain.ErrUnprocessable))
+	assertDatabaseHas(t, "accounts", "balance", "1000.00", db)
+}
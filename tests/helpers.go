@@ -1,31 +1,85 @@
 package tests
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/repository"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/repository/migrations"
 	"github.com/realtobi999/GO_BankDemoApi/src/adapters/web"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/account"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/auth"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/consent"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/credential"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/currency"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/exchange"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/fx"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/idempotency"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/interest"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/jobs"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/ledger"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/mandates"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/standingorders"
 	"github.com/realtobi999/GO_BankDemoApi/src/core/services/transactions"
 )
 
+const TEST_ADMIN_TOKEN = "test-admin-token"
+
+// TEST_RETRY_POLICY is how standing orders of the test server retry transfers the account couldnt cover.
+var TEST_RETRY_POLICY = domain.RetryPolicy{Retries: 2, Interval: 24 * time.Hour}
+
+// TEST_REFUND_DAYS is for how many days payers can have collections under mandates of the test server refunded.
+const TEST_REFUND_DAYS = 56
+
+// TEST_CLOCK_START is where the fake clock of NewTestClockServer starts, in the middle of a month.
+var TEST_CLOCK_START = time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+
 func NewTestServer(db *repository.Postgres) *web.Server {
+	return newTestServer(db, clock.NewSystemClock())
+}
+
+// NewTestClockServer returns a test server running on a fake clock, so tests can move
+// days or months ahead in an instant.
+func NewTestClockServer(db *repository.Postgres) (*web.Server, *clock.FakeClock) {
+	fake := clock.NewFakeClock(TEST_CLOCK_START)
+	return newTestServer(db, fake), fake
+}
+
+func newTestServer(db *repository.Postgres, testClock ports.IClock) *web.Server {
 	server := web.NewServer(":8080", chi.NewMux())
-	server.CustomerService = customer.NewCustomerService(db)
-	server.AccountService = account.NewAccountService(db, db)
-	server.TransactionService = transactions.NewTransactionService(db, db, db)
+	server.CustomerService = customer.NewCustomerService(db, db, db, testClock)
+	server.CredentialService = credential.NewCredentialService(db, db, db, testClock)
+	server.ConsentService = consent.NewConsentService(db, db, testClock)
+	server.AuthService = auth.NewAuthService(server.CustomerService, server.CredentialService, db, db, NewTestKeySet(), testClock)
+	server.LedgerService = ledger.NewLedgerService(db, db)
+	server.ExchangeService = exchange.NewExchangeService(db, "USD")
+	server.CurrencyService = currency.NewCurrencyService(db, domain.Currencies)
+	server.FXService = fx.NewFXService(db, server.ExchangeService, server.CurrencyService, domain.FXPricing{}, testClock)
+	server.TransactionService = transactions.NewTransactionService(db, db, db, server.LedgerService, server.FXService, server.CredentialService, server.CurrencyService, 0, testClock)
+	server.InterestService = interest.NewInterestService(db, db, server.TransactionService)
+	server.AccountService = account.NewAccountService(db, db, db, server.LedgerService, server.TransactionService, server.InterestService, server.CurrencyService, testClock)
+	server.StandingOrderService = standingorders.NewStandingOrderService(db, db, db, server.TransactionService, server.CredentialService, TEST_RETRY_POLICY, testClock)
+	server.MandateService = mandates.NewMandateService(db, db, db, server.TransactionService, server.CredentialService, TEST_REFUND_DAYS, testClock)
+	server.JobService = jobs.NewJobService(db, testClock)
+	server.IdempotencyService = idempotency.NewIdempotencyService(db, testClock)
+	server.AdminToken = TEST_ADMIN_TOKEN
 
 	if err := migrations.DropMigrations(db.DB); err != nil {
 		panic(err)
@@ -38,6 +92,30 @@ func NewTestServer(db *repository.Postgres) *web.Server {
 	return server
 }
 
+func NewTestKeySet() *auth.KeySet {
+	key, err := auth.GenerateKey("test", auth.EdDSA)
+	if err != nil {
+		panic(err)
+	}
+
+	return auth.NewKeySet(key)
+}
+
+// NewTestAccessToken signs an access token the test server accepts for the customer.
+func NewTestAccessToken(server *web.Server, customerID uuid.UUID) string {
+	return NewTestRoleAccessToken(server, customerID, domain.ROLE_CUSTOMER)
+}
+
+// NewTestRoleAccessToken signs an access token the test server accepts for the customer with the role.
+func NewTestRoleAccessToken(server *web.Server, customerID uuid.UUID, role domain.Role) string {
+	token, _, err := server.AuthService.AccessToken(customerID, role, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
+
 func NewTestDatabase() *repository.Postgres {
 	err := godotenv.Load("./../.env")
 	if err != nil {
@@ -74,6 +152,7 @@ func NewTestCustomer() domain.Customer {
 		State:     "CA",
 		Address:   "123 Main St",
 		CreatedAt: time.Now(),
+		Role:      domain.ROLE_CUSTOMER,
 		Token:	   customer.GenerateToken(),
 	}
 }
@@ -82,23 +161,56 @@ func NewTestAccount(customerID uuid.UUID) domain.Account {
 	return domain.Account{
 		ID:                  uuid.New(),
 		CustomerID:          customerID,
-		Balance:             0.0,
+		Balance:             domain.NewMoney(0, "USD"),
+		OverdraftLimit:      domain.NewMoney(0, "USD"),
 		Type:                1,
 		Currency:            "USD",
-		Status:              true,
+		Status:              domain.AccountActive,
 		OpeningDate:         time.Now(),
 		LastTransactionDate: time.Now(),
 		InterestRate:        0.0,
+		DayCount:            domain.DayCountActual365,
+		// Accrued through yesterday, just like accounts opened through the service
+		InterestAccruedThrough: domain.StartOfDay(time.Now()).AddDate(0, 0, -1),
+	}
+}
+
+// NewTestFundedAccount opens an account through the services and has the staff deposit the amount
+// on it in cash through the admin endpoint.
+func NewTestFundedAccount(server *web.Server, customerID uuid.UUID, currency domain.Currency, amount json.Number) (domain.Account, error) {
+	account, err := server.AccountService.Create(customerID, domain.CreateAccountRequest{Type: 1, Currency: currency})
+	if err != nil {
+		return domain.Account{}, err
+	}
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate, server.RequirePermission(domain.PERMISSION_MANAGE_DEPOSITS)).Post("/api/admin/account/{account_id}/deposit", handlers.NewTransactionHandler(server.TransactionService).Deposit)
+
+	body := fmt.Sprintf(`{"Amount": %s, "Channel": "%s"}`, amount, domain.ChannelCash)
+	req, err := http.NewRequest("POST", fmt.Sprintf("/api/admin/account/%s/deposit", account.ID), strings.NewReader(body))
+	if err != nil {
+		return domain.Account{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusCreated {
+		return domain.Account{}, fmt.Errorf("failed to deposit %s: %s", amount, recorder.Body.String())
 	}
+
+	return server.AccountService.Get(account.ID)
 }
 
 func NewTestTransaction(senderID uuid.UUID, receiver uuid.UUID) domain.Transaction {
 	return domain.Transaction{
 		ID: uuid.New(),
+		Type: domain.TransactionTransfer,
 		SenderAccountID: senderID,
 		ReceiverAccountID: receiver,
-		Amount: 0,
+		Amount: domain.NewMoney(0, "USD"),
 		CurrencyPair: domain.NewCurrencyPair("USD", "EUR"),
+		Status: domain.TransactionPosted,
 		CreatedAt: time.Now(),
 	}
 }
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Interest_DayCount_Works(t *testing.T) {
+	day := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	assertEqual(t, big.NewRat(1, 365), domain.DayCountActual365.YearFraction(day))
+	assertEqual(t, big.NewRat(1, 360), domain.DayCountActual360.YearFraction(day))
+
+	// Under 30/360 every month counts as 30 days, whatever its length
+	for _, month := range []time.Month{time.January, time.February, time.April} {
+		total := new(big.Rat)
+		for day := time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC); day.Month() == month; day = day.AddDate(0, 0, 1) {
+			total.Add(total, domain.DayCount30360.YearFraction(day))
+		}
+
+		assertEqual(t, big.NewRat(30, 360), total)
+	}
+
+	assertEqual(t, big.NewRat(0, 1), domain.DayCount30360.YearFraction(time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)))
+	assertEqual(t, big.NewRat(3, 360), domain.DayCount30360.YearFraction(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)))
+}
+
+func Test_Interest_Tiers_ApplyToTheirBands(t *testing.T) {
+	account := NewTestAccount(uuid.New())
+	account.Type = 3
+	account.InterestRate = 0.01
+	account.OverdraftInterestRate = 0.1
+	account.InterestTiers = []domain.InterestTier{
+		{Above: domain.MoneyFromMajor(1000, "USD"), Rate: 0.02},
+		{Above: domain.MoneyFromMajor(5000, "USD"), Rate: 0.03},
+	}
+
+	assertEqual(t, (*domain.ValidationErrors)(nil), account.Validate())
+
+	// 1000 at 1%, 4000 at 2% and 1000 at 3%
+	assertEqual(t, big.NewRat(120, 1), account.YearlyInterest(domain.MoneyFromMajor(6000, "USD")))
+	assertEqual(t, big.NewRat(5, 1), account.YearlyInterest(domain.MoneyFromMajor(500, "USD")))
+	assertEqual(t, big.NewRat(-10, 1), account.YearlyInterest(domain.MoneyFromMajor(-100, "USD")))
+
+	account.InterestTiers[1].Above = domain.MoneyFromMajor(1000, "USD")
+	assertEqual(t, []string{"Interest tiers must be in ascending order"}, account.Validate().Errors)
+
+	account.Type = 1
+	account.InterestRate = 0
+	account.InterestTiers = account.InterestTiers[:1]
+	assertEqual(t, []string{"Non-savings account cannot have interest tiers"}, account.Validate().Errors)
+}
+
+func Test_Interest_Accrue_CatchesUpAndPostsMonthly(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	account, err := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 3, Currency: "USD", InterestRate: 0.0365})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.TransactionService.Deposit(account.ID, domain.CreateDepositRequest{Amount: "1000", Channel: domain.ChannelCash}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the server was down from the 15th of january until the 1st of march
+	clock.Set(time.Date(2026, time.March, 1, 6, 0, 0, 0, time.UTC))
+
+	// 1000 at 3.65% earns exactly 0.10 a day in january, in february it earns on the posted interest too
+	balance := domain.MoneyFromMajor(1000, "USD").Add(domain.NewMoney(17*10, "USD"))
+	secondPosting, _ := domain.MoneyFromRat(new(big.Rat).Mul(balance.Rat(), big.NewRat(28, 10000)), "USD")
+
+	// Running it again doesnt accrue anything twice
+	for range 2 {
+		if err := server.InterestService.Accrue(clock.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, err := server.AccountService.Get(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, balance.Add(secondPosting).String(), updated.Balance.String())
+	assertEqual(t, time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), updated.InterestAccruedThrough)
+
+	verified, err := server.LedgerService.Verify(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, true, verified.IsBalanced())
+}
+
+func Test_Interest_Accrue_DailyRunsMatchCatchingUp(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	var accounts []domain.Account
+	for range 2 {
+		account, err := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 3, Currency: "USD", InterestRate: 0.042, DayCount: "ACT/360"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := server.TransactionService.Deposit(account.ID, domain.CreateDepositRequest{Amount: "1234.56", Channel: domain.ChannelCash}); err != nil {
+			t.Fatal(err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	// Three months of nightly runs for the first account, in an instant
+	for range 90 {
+		clock.AdvanceDays(1)
+
+		if err := server.InterestService.AccrueAccount(accounts[0].ID, clock.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second one is only accrued at the end
+	if err := server.InterestService.AccrueAccount(accounts[1].ID, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	daily, err := server.AccountService.Get(accounts[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caughtUp, err := server.AccountService.Get(accounts[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, true, daily.Balance.Cmp(domain.MoneyFromMajor(1234, "USD")) > 0)
+	assertEqual(t, daily.Balance.String(), caughtUp.Balance.String())
+	assertEqual(t, daily.AccruedInterest.RatString(), caughtUp.AccruedInterest.RatString())
+	assertEqual(t, daily.InterestAccruedThrough, caughtUp.InterestAccruedThrough)
+}
+
+func Test_Interest_Close_PostsTheAccruedInterest(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	account, err := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 3, Currency: "USD", InterestRate: 0.0365})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.TransactionService.Deposit(account.ID, domain.CreateDepositRequest{Amount: "1000", Channel: domain.ChannelCash}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The nightly run accrues the 15th through the 17th of january without posting it yet
+	clock.Set(time.Date(2026, time.January, 18, 6, 0, 0, 0, time.UTC))
+	if err := server.InterestService.Accrue(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing on the 20th pays the 0.10 of every day through the day of closing
+	clock.Set(time.Date(2026, time.January, 20, 9, 0, 0, 0, time.UTC))
+	closed, err := server.AccountService.Close(account.ID, domain.CloseAccountRequest{SweepAccountID: target.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, true, closed.Balance.IsZero())
+
+	swept, err := server.AccountService.Get(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "1000.60", swept.Balance.String())
+
+	updated, err := server.AccountService.Get(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, 0, updated.Accrued().Sign())
+	assertEqual(t, time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC), updated.InterestAccruedThrough)
+
+	verified, err := server.LedgerService.Verify(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, true, verified.IsBalanced())
+}
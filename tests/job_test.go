@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Job_Schedule_Works(t *testing.T) {
+	at := time.Date(2026, 4, 3, 10, 7, 30, 0, time.UTC) // A friday
+
+	next := func(expression string) time.Time {
+		schedule, err := domain.ParseSchedule(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return schedule.Next(at)
+	}
+
+	assertEqual(t, time.Date(2026, 4, 3, 10, 15, 0, 0, time.UTC), next("*/15 * * * *"))
+	assertEqual(t, time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC), next("@daily"))
+	assertEqual(t, time.Date(2026, 4, 6, 9, 0, 0, 0, time.UTC), next("0 9 * * 1-5"))
+	assertEqual(t, time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC), next("0 0 31 * *"))
+	assertEqual(t, time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC), next("0 0 * * 7"))
+
+	// Restricting both days matches either of them
+	assertEqual(t, time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), next("0 0 13 * 5"))
+
+	for _, invalid := range []string{"61 * * * *", "* * *", "*/0 * * * *", "5-1 * * * *"} {
+		_, err := domain.ParseSchedule(invalid)
+		assertNotEqual(t, nil, err)
+	}
+}
+
+func Test_Job_Trigger_RecordsRunsAndNeverOverlaps(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	release := make(chan struct{})
+
+	server.JobService.Register("blocking", "@daily", func(at time.Time) error {
+		<-release
+		return nil
+	})
+	server.JobService.Register("panicking", "@daily", func(at time.Time) error {
+		panic("something went terribly wrong")
+	})
+
+	// Waits until the latest run of the job finished
+	finished := func(name string) domain.JobRun {
+		for range 100 {
+			runs, err := server.JobService.Runs(name, 1, 0)
+			if err == nil && runs[0].Status != domain.JobRunning {
+				return runs[0]
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		t.Fatal("Job " + name + " didnt finish")
+		return domain.JobRun{}
+	}
+
+	triggeredBy := uuid.New()
+
+	run, err := server.JobService.Trigger("blocking", triggeredBy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.JobRunning, run.Status)
+
+	_, err = server.JobService.Trigger("blocking", triggeredBy)
+	assertEqual(t, true, errors.Is(err, domain.ErrConflict))
+
+	close(release)
+	run = finished("blocking")
+	assertEqual(t, domain.JobSucceeded, run.Status)
+	assertEqual(t, triggeredBy, run.TriggeredBy)
+
+	if _, err := server.JobService.Trigger("panicking", uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+
+	run = finished("panicking")
+	assertEqual(t, domain.JobFailed, run.Status)
+	assertEqual(t, "Job panicked: something went terribly wrong", run.Error)
+
+	_, err = server.JobService.Trigger("unknown", uuid.Nil)
+	assertEqual(t, true, errors.Is(err, domain.ErrNotFound))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Ledger_TransferJournal_Balances(t *testing.T) {
+	transaction := NewTestTransaction(uuid.New(), uuid.New())
+	transaction.Amount = domain.MoneyFromMajor(100, "USD")
+
+	journal := domain.NewTransferJournal(transaction, domain.NewMoney(9369, "EUR"))
+
+	// Cross currency transfers go through the FX position so both currencies balance
+	assertEqual(t, 4, len(journal.Entries))
+	assertEqual(t, (*domain.ValidationErrors)(nil), journal.Validate())
+}
+
+func Test_Ledger_Journal_ValidationWorks(t *testing.T) {
+	journal := domain.NewJournal(domain.JournalAdjustment, uuid.Nil, "Broken", time.Now())
+	journal.Debit(uuid.Nil, domain.SystemAdjustment, domain.MoneyFromMajor(10, "USD"))
+	journal.Credit(uuid.New(), "", domain.MoneyFromMajor(9, "USD"))
+
+	err := journal.Validate()
+	if err == nil {
+		t.Fatal("expected the journal to be unbalanced")
+	}
+
+	assertEqual(t, "Journal doesnt balance in USD", err.Errors[0])
+}
+
+func Test_Ledger_AdjustmentJournal_TakesTheAdjustmentID(t *testing.T) {
+	adjustment := domain.Adjustment{
+		ID:        uuid.New(),
+		AccountID: uuid.New(),
+		Amount:    domain.MoneyFromMajor(-5, "USD"),
+		Reason:    domain.AdjustmentWriteOff,
+		Note:      "Unpaid fee",
+		CreatedAt: time.Now(),
+	}
+
+	journal := domain.NewAdjustmentJournal(adjustment)
+
+	assertEqual(t, adjustment.ID, journal.ID)
+	assertEqual(t, "Adjustment WRITE_OFF: Unpaid fee", journal.Description)
+	assertEqual(t, domain.Debit, journal.Entries[1].Side)
+	assertEqual(t, (*domain.ValidationErrors)(nil), journal.Validate())
+}
+
+func Test_Ledger_Transfer_DerivedBalanceMatches(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+
+	sender, err := NewTestFundedAccount(server, customer1.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := server.AccountService.Create(customer2.ID, domain.CreateAccountRequest{Type: 1, Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100.10",
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, accountID := range []uuid.UUID{sender.ID, receiver.ID} {
+		balance, err := server.LedgerService.Verify(accountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, true, balance.IsBalanced())
+	}
+
+	url := fmt.Sprintf("/api/account/%s/ledger", sender.ID.String())
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+
+	router := chi.NewMux()
+	router.Get("/api/account/{account_id}/ledger", handlers.NewLedgerHandler(server.LedgerService).Index)
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+
+	body := struct {
+		Message string                  `json:"message"`
+		Code    int                     `json:"code"`
+		Data    []domain.LedgerEntryDTO `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, 2, len(body.Data))
+	assertEqual(t, string(domain.JournalDeposit), body.Data[0].JournalKind)
+	assertEqual(t, string(domain.JournalTransfer), body.Data[1].JournalKind)
+	assertEqual(t, string(domain.Debit), body.Data[1].Side)
+	assertEqual(t, "100.10", body.Data[1].Amount.String())
+}
@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/repository"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/web"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Mandate_Allows_Limits(t *testing.T) {
+	mandate := domain.Mandate{
+		MaxAmount: domain.MoneyFromMajor(50, "USD"),
+		Frequency: domain.MandateMonthly,
+		Status:    domain.MandateActive,
+	}
+
+	assertEqual(t, nil, mandate.Allows(domain.MoneyFromMajor(50, "USD"), 0))
+	assertEqual(t, "Collection cannot be more than the mandate allows: 50.00 USD", mandate.Allows(domain.NewMoney(5001, "USD"), 0).Error())
+	assertEqual(t, "Collection must be in the currency of the mandate USD", mandate.Allows(domain.MoneyFromMajor(10, "EUR"), 0).Error())
+	assertEqual(t, "Mandate was already collected this month", mandate.Allows(domain.MoneyFromMajor(10, "USD"), 1).Error())
+
+	// Periods are calendar weeks starting on mondays, months and years
+	at := time.Date(2026, time.March, 19, 15, 30, 0, 0, time.UTC) // A thursday
+	assertEqual(t, date(2026, 3, 1), mandate.PeriodStart(at))
+
+	mandate.Frequency = domain.MandateWeekly
+	assertEqual(t, date(2026, 3, 16), mandate.PeriodStart(at))
+	assertEqual(t, "Mandate was already collected this week", mandate.Allows(domain.MoneyFromMajor(10, "USD"), 1).Error())
+
+	mandate.Frequency = domain.MandateYearly
+	assertEqual(t, date(2026, 1, 1), mandate.PeriodStart(at))
+
+	mandate.Frequency = domain.MandateOnce
+	assertEqual(t, time.Time{}, mandate.PeriodStart(at))
+	assertEqual(t, "Mandate was already collected", mandate.Allows(domain.MoneyFromMajor(10, "USD"), 1).Error())
+}
+
+func Test_Mandate_Validate_Works(t *testing.T) {
+	accountID := uuid.New()
+	mandate := domain.Mandate{
+		ID:                uuid.New(),
+		PayerAccountID:    accountID,
+		CreditorAccountID: accountID,
+		Reference:         strings.Repeat("x", domain.MAX_MANDATE_REFERENCE_LENGTH+1),
+		MaxAmount:         domain.NewMoney(0, "USD"),
+		Frequency:         "DAILY",
+		Status:            domain.MandateActive,
+	}
+
+	assertEqual(t, []string{
+		"Mandate cannot be given to its own account",
+		"Reference must not be longer than 140 characters",
+		"MaxAmount must be bigger than 0",
+		"Unknown mandate frequency DAILY",
+		"CreatedAt must be set",
+	}, mandate.Validate().Errors)
+}
+
+// newTestMandate opens a payer account with 1000 USD and a creditor account of another customer,
+// and mandates the creditor to collect up to 50 USD from the payer every month.
+func newTestMandate(t *testing.T, server *web.Server, db *repository.Postgres) domain.Mandate {
+	t.Helper()
+
+	payer := NewTestCustomer()
+	db.CreateCustomer(payer)
+	creditor := NewTestCustomer()
+	db.CreateCustomer(creditor)
+
+	payerAccount, err := NewTestFundedAccount(server, payer.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creditorAccount, err := server.AccountService.Create(creditor.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mandate, err := server.MandateService.Create(payerAccount.ID, domain.CreateMandateRequest{
+		CreditorAccountID: creditorAccount.ID,
+		Reference:         "Gym membership 1042",
+		MaxAmount:         "50",
+		Frequency:         domain.MandateMonthly,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mandate
+}
+
+func Test_Mandate_Create_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	payer, _ := NewTestFundedAccount(server, customer.ID, "USD", "100")
+	creditor, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	body := fmt.Sprintf(`{"CreditorAccountID": "%s", "Reference": "Streaming subscription", "MaxAmount": 15.99, "Frequency": "MONTHLY"}`, creditor.ID)
+	url := fmt.Sprintf("/api/customer/%s/account/%s/mandates", customer.ID, payer.ID)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+
+	router := chi.NewRouter()
+	router.Post("/api/customer/{customer_id}/account/{account_id}/mandates", handlers.NewMandateHandler(server.MandateService).Create)
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	location := recorder.Header().Get("Location")
+	id, err := uuid.Parse(location[strings.LastIndex(location, "/")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the payer and the creditor find the mandate
+	for _, accountID := range []uuid.UUID{payer.ID, creditor.ID} {
+		mandate, err := server.MandateService.Get(accountID, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, "15.99", mandate.MaxAmount.String())
+		assertEqual(t, domain.MandateActive, mandate.Status)
+	}
+}
+
+func Test_Mandate_Collect_StaysWithinTheMandate(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	mandate := newTestMandate(t, server, db)
+
+	// The payer cannot pull money to the creditor
+	_, err := server.MandateService.Collect(mandate.PayerAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "10"})
+	assertEqual(t, true, errors.Is(err, domain.ErrForbidden))
+
+	_, err = server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "50.01"})
+	assertEqual(t, "Error bad request: Collection cannot be more than the mandate allows: 50.00 USD", err.Error())
+
+	collection, err := server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "49.90"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, mandate.ID, collection.MandateID)
+	assertEqual(t, domain.TransactionPosted, collection.Status)
+
+	_, err = server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "10"})
+	assertEqual(t, "Error bad request: Mandate was already collected this month", err.Error())
+
+	// Next month it can be collected again
+	clock.Set(time.Date(2026, time.February, 1, 8, 0, 0, 0, time.UTC))
+	if _, err := server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "49.90"}); err != nil {
+		t.Fatal(err)
+	}
+
+	creditor, _ := server.AccountService.Get(mandate.CreditorAccountID)
+	assertEqual(t, "99.80", creditor.Balance.String())
+
+	// Rejected collections are recorded as failed for the payer to see
+	collections, err := server.MandateService.Collections(mandate.PayerAccountID, mandate.ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := 0
+	for _, collection := range collections {
+		if collection.Status == domain.TransactionFailed {
+			failed++
+		}
+	}
+
+	assertEqual(t, 4, len(collections))
+	assertEqual(t, 2, failed)
+}
+
+func Test_Mandate_Cancel_StopsCollections(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	mandate := newTestMandate(t, server, db)
+
+	// Only the payer can cancel the mandate
+	_, err := server.MandateService.Cancel(mandate.CreditorAccountID, mandate.ID)
+	assertEqual(t, true, errors.Is(err, domain.ErrForbidden))
+
+	mandate, err = server.MandateService.Cancel(mandate.PayerAccountID, mandate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.MandateCancelled, mandate.Status)
+
+	_, err = server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "10"})
+	assertEqual(t, "Error conflict: Mandate is cancelled", err.Error())
+
+	_, err = server.MandateService.Cancel(mandate.PayerAccountID, mandate.ID)
+	assertEqual(t, "Error conflict: Mandate is already cancelled", err.Error())
+}
+
+func Test_Mandate_Refund_WithinTheRefundPeriod(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	mandate := newTestMandate(t, server, db)
+
+	first, err := server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.AdvanceDays(31)
+	second, err := server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the payer can have a collection refunded
+	_, err = server.MandateService.Refund(mandate.CreditorAccountID, mandate.ID, second.ID)
+	assertEqual(t, true, errors.Is(err, domain.ErrForbidden))
+
+	refund, err := server.MandateService.Refund(mandate.PayerAccountID, mandate.ID, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, second.ID, refund.ReversalOf)
+
+	// The first collection was made more than TEST_REFUND_DAYS ago by now
+	clock.AdvanceDays(TEST_REFUND_DAYS - 30)
+	_, err = server.MandateService.Refund(mandate.PayerAccountID, mandate.ID, first.ID)
+	assertEqual(t, "Error bad request: Collections can only be refunded within 56 days", err.Error())
+
+	payer, _ := server.AccountService.Get(mandate.PayerAccountID)
+	assertEqual(t, "970.00", payer.Balance.String())
+}
+
+func Test_Mandate_Refund_WhenTheCreditorSpentTheMoney(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	mandate := newTestMandate(t, server, db)
+
+	collection, err := server.MandateService.Collect(mandate.CreditorAccountID, mandate.ID, domain.CreateCollectionRequest{Amount: "40"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The creditor withdraws everything and then freezes the account
+	if _, err := server.TransactionService.Withdraw(mandate.CreditorAccountID, domain.CreateWithdrawalRequest{Amount: "40", Channel: domain.ChannelCash}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.AccountService.Freeze(mandate.CreditorAccountID); err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := server.MandateService.Refund(mandate.PayerAccountID, mandate.ID, collection.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, collection.ID, refund.ReversalOf)
+	assertEqual(t, domain.TransactionPosted, refund.Status)
+
+	payer, _ := server.AccountService.Get(mandate.PayerAccountID)
+	assertEqual(t, "1000.00", payer.Balance.String())
+
+	creditor, _ := server.AccountService.Get(mandate.CreditorAccountID)
+	assertEqual(t, "-40.00", creditor.Balance.String())
+
+	verified, err := server.LedgerService.Verify(mandate.CreditorAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, true, verified.IsBalanced())
+
+	// Nothing failed along the way and the collection cannot be refunded twice
+	collections, err := server.MandateService.Collections(mandate.PayerAccountID, mandate.ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, 1, len(collections))
+	assertEqual(t, domain.TransactionReversed, collections[0].Status)
+
+	_, err = server.MandateService.Refund(mandate.PayerAccountID, mandate.ID, collection.ID)
+	assertEqual(t, "Error conflict: Only posted collections can be refunded, this one is REVERSED", err.Error())
+}
+
+func Test_Mandate_CloseAccount_CancelsTheMandate(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	mandate := newTestMandate(t, server, db)
+
+	// The creditor account holds nothing, so it closes without a sweep
+	if _, err := server.AccountService.Close(mandate.CreditorAccountID, domain.CloseAccountRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	mandate, err := server.MandateService.Get(mandate.PayerAccountID, mandate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.MandateCancelled, mandate.Status)
+	assertEqual(t, false, mandate.CancelledAt.IsZero())
+}
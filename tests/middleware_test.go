@@ -2,12 +2,17 @@ package tests
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
 	customerService "github.com/realtobi999/GO_BankDemoApi/src/core/services/customer"
 )
 
@@ -80,10 +85,11 @@ func Test_Middleware_AccountOwnerAuth_Works(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customer.ID))
 	recorder := httptest.NewRecorder()	
 
 	router := chi.NewRouter()
-	router.With(server.AccountOwnerAuth).Delete("/api/{customer_id}/account/{account_id}", func(w http.ResponseWriter, r *http.Request) {panic("Middleware is not working!")})
+	router.With(server.TokenAuth, server.AccountOwnerAuth).Delete("/api/{customer_id}/account/{account_id}", func(w http.ResponseWriter, r *http.Request) {panic("Middleware is not working!")})
 	router.ServeHTTP(recorder, req)
 
 	assertEqual(t, http.StatusUnauthorized, recorder.Code)
@@ -98,3 +104,150 @@ func Test_Middleware_AccountOwnerAuth_Works(t *testing.T) {
 
 	assertEqual(t, "Not authorized!", body.ErrorMessage)
 }
+
+func Test_Middleware_Idempotency_ReplaysResponse(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	sender := NewTestAccount(customer1.ID)
+	receiver := NewTestAccount(customer2.ID)
+	sender.Balance = domain.MoneyFromMajor(1000, "USD")
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	router := chi.NewRouter()
+	router.With(server.TokenAuth, server.Idempotency).Post("/api/{customer_id}/account/{account_id}/transaction", handlers.NewTransactionHandler(server.TransactionService).Create)
+
+	url := fmt.Sprintf("/api/%s/account/%s/transaction", customer1.ID.String(), sender.ID.String())
+	body := fmt.Sprintf(`{"ReceiverAccountID": "%s", "Amount": 100, "Currency": "USD"}`, receiver.ID.String())
+
+	sendAs := func(customerID uuid.UUID, url string, key string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+NewTestAccessToken(server, customerID))
+		req.Header.Set("Idempotency-Key", key)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	send := func(body string) *httptest.ResponseRecorder {
+		return sendAs(customer1.ID, url, "retry-me", body)
+	}
+
+	first := send(body)
+	second := send(body)
+
+	assertEqual(t, http.StatusCreated, first.Code)
+	assertEqual(t, http.StatusCreated, second.Code)
+	assertEqual(t, first.Header().Get("Location"), second.Header().Get("Location"))
+	assertEqual(t, "true", second.Header().Get("Idempotent-Replayed"))
+
+	// The money only moved once
+	account, err := server.AccountService.Get(sender.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "900.00", account.Balance.String())
+
+	// Reusing the key for a different request is rejected
+	third := send(fmt.Sprintf(`{"ReceiverAccountID": "%s", "Amount": 200, "Currency": "USD"}`, receiver.ID.String()))
+	assertEqual(t, http.StatusUnprocessableEntity, third.Code)
+
+	// Keys are scoped to the customer, another customer using the same key makes their own transfer
+	otherURL := fmt.Sprintf("/api/%s/account/%s/transaction", customer2.ID.String(), receiver.ID.String())
+	other := sendAs(customer2.ID, otherURL, "retry-me", fmt.Sprintf(`{"ReceiverAccountID": "%s", "Amount": 50, "Currency": "USD"}`, sender.ID.String()))
+	assertEqual(t, http.StatusCreated, other.Code)
+	assertEqual(t, "", other.Header().Get("Idempotent-Replayed"))
+	assertNotEqual(t, first.Header().Get("Location"), other.Header().Get("Location"))
+
+	// Failures are not stored, the client can retry them with the same key
+	tooMuch := fmt.Sprintf(`{"ReceiverAccountID": "%s", "Amount": 5000, "Currency": "USD"}`, receiver.ID.String())
+	assertEqual(t, http.StatusBadRequest, sendAs(customer1.ID, url, "fails", tooMuch).Code)
+	assertEqual(t, "", sendAs(customer1.ID, url, "fails", tooMuch).Header().Get("Idempotent-Replayed"))
+}
+
+func Test_Token_HashToken_Works(t *testing.T) {
+	token := customerService.GenerateToken()
+	salt, err := customerService.GenerateTokenSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := customerService.HashToken(token, salt)
+	otherSalt, _ := customerService.GenerateTokenSalt()
+
+	assertEqual(t, true, customerService.VerifyToken(token, salt, hash))
+	assertEqual(t, false, customerService.VerifyToken(customerService.GenerateToken(), salt, hash))
+	assertNotEqual(t, hash, customerService.HashToken(token, otherSalt))
+}
+
+func Test_Middleware_TokenAuth_RotationWorks(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	customer, err := server.CustomerService.Create(domain.CreateCustomerRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Birthday:  NewTestCustomer().Birthday,
+		Email:     "john@example.com",
+		Phone:     "123-456-7890",
+		State:     "CA",
+		Address:   "123 Main St",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken := NewTestAccessToken(server, customer.ID)
+	url := fmt.Sprintf("/api/customer/%s/token", customer.ID.String())
+
+	// Create a second token
+	req, _ := http.NewRequest("POST", url, strings.NewReader(`{"Name": "ci"}`))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	created := struct {
+		Data struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := server.CustomerService.Tokens(customer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, 2, len(tokens))
+
+	// Revoke the first token
+	req, _ = http.NewRequest("DELETE", url+"/"+tokens[0].ID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+
+	// The revoked token cannot log in anymore, the new one can
+	_, err = server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: customer.Token})
+	assertEqual(t, true, errors.Is(err, domain.ErrUnauthorized))
+
+	_, err = server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: created.Data.Token})
+	assertEqual(t, nil, err)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func Test_Money_Parse_Works(t *testing.T) {
+	money, err := domain.ParseMoney("1000.25", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, int64(100025), money.Amount)
+	assertEqual(t, "1000.25", money.String())
+
+	money, err = domain.ParseMoney("-0.5", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, int64(-50), money.Amount)
+	assertEqual(t, "-0.50", money.String())
+
+	_, err = domain.ParseMoney("ten dollars", "USD")
+	assertNotEqual(t, nil, err)
+}
+
+func Test_Money_Parse_RoundsHalfToEven(t *testing.T) {
+	cases := map[string]int64{
+		"0.125":  12,
+		"0.135":  14,
+		"0.1251": 13,
+		"-0.125": -12,
+		"-0.135": -14,
+	}
+
+	for value, expected := range cases {
+		money, err := domain.ParseMoney(value, "USD")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, expected, money.Amount)
+	}
+}
+
+func Test_Money_Convert_Works(t *testing.T) {
+	money := domain.MoneyFromMajor(100, "USD")
+
+	converted := money.Convert("EUR", big.NewRat(9369, 10000))
+
+	assertEqual(t, domain.Currency("EUR"), converted.Currency)
+	assertEqual(t, "93.69", converted.String())
+}
+
+func Test_Money_Mul_DoesntDrift(t *testing.T) {
+	money := domain.NewMoney(10, "USD")
+
+	// 0.1 + 0.2 style float errors must not appear after repeated operations
+	for i := 0; i < 1000; i++ {
+		money = money.Add(domain.NewMoney(10, "USD"))
+	}
+	assertEqual(t, "100.10", money.String())
+
+	interest := money.Mul(big.NewRat(1, 3))
+	assertEqual(t, "33.37", interest.String())
+}
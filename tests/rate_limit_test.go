@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/ratelimit"
+)
+
+func Test_RateLimit_Parse_Works(t *testing.T) {
+	limit, err := domain.ParseRateLimit("60/1m")
+	assertEqual(t, nil, err)
+	assertEqual(t, domain.RateLimit{Burst: 60, Period: time.Minute}, limit)
+
+	limit, err = domain.ParseRateLimit("0")
+	assertEqual(t, nil, err)
+	assertEqual(t, true, limit.IsZero())
+
+	for _, value := range []string{"60", "-1/1m", "60/0s", "sixty/1m"} {
+		_, err := domain.ParseRateLimit(value)
+		assertNotEqual(t, nil, err)
+	}
+}
+
+func Test_RateLimit_Take_RefillsOverTime(t *testing.T) {
+	limit := domain.RateLimit{Burst: 2, Period: 10 * time.Second}
+	now := time.Now()
+
+	bucket, result := limit.Take(domain.RateLimitBucket{}, now)
+	assertEqual(t, true, result.Allowed)
+	assertEqual(t, 1, result.Remaining)
+	assertEqual(t, 5*time.Second, result.Reset)
+
+	bucket, result = limit.Take(bucket, now)
+	assertEqual(t, true, result.Allowed)
+	assertEqual(t, 0, result.Remaining)
+
+	bucket, result = limit.Take(bucket, now.Add(time.Second))
+	assertEqual(t, false, result.Allowed)
+	assertEqual(t, 4*time.Second, result.RetryAfter)
+
+	// A token is back after a fifth of the period
+	_, result = limit.Take(bucket, now.Add(5*time.Second))
+	assertEqual(t, true, result.Allowed)
+	assertEqual(t, 0, result.Remaining)
+}
+
+func Test_RateLimit_MemoryStore_ForgetsFullBuckets(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := domain.RateLimit{Burst: 1, Period: time.Second}
+	now := time.Now()
+
+	store.Take("first", limit, now)
+	store.Take("second", limit, now.Add(domain.RATE_LIMIT_SWEEP_INTERVAL))
+
+	assertEqual(t, 1, store.Len())
+}
+
+func Test_RateLimit_Middleware_LimitsReadsAndWritesSeparately(t *testing.T) {
+	server := NewTestAuthServer()
+	server.RateLimitService = ratelimit.NewRateLimitService(ratelimit.NewMemoryStore(), domain.RateLimitPolicy{
+		Read:  domain.RateLimit{Burst: 2, Period: time.Minute},
+		Write: domain.RateLimit{Burst: 1, Period: time.Minute},
+	}, clock.NewSystemClock())
+
+	router := chi.NewRouter()
+	router.Use(server.RateLimit)
+	router.HandleFunc("/api/account", func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(method, remoteAddr, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/account", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := request("GET", "10.0.0.1:1234", "")
+	assertEqual(t, http.StatusOK, recorder.Code)
+	assertEqual(t, "2", recorder.Header().Get("RateLimit-Limit"))
+	assertEqual(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+
+	assertEqual(t, http.StatusOK, request("POST", "10.0.0.1:1234", "").Code)
+	assertEqual(t, http.StatusOK, request("GET", "10.0.0.1:1234", "").Code)
+
+	recorder = request("GET", "10.0.0.1:1234", "")
+	assertEqual(t, http.StatusTooManyRequests, recorder.Code)
+	assertEqual(t, "30", recorder.Header().Get("Retry-After"))
+
+	// The customer keeps their own limit across client IPs
+	token := NewTestAccessToken(server, uuid.New())
+	assertEqual(t, http.StatusOK, request("POST", "10.0.0.2:1234", token).Code)
+	assertEqual(t, http.StatusTooManyRequests, request("POST", "10.0.0.3:1234", token).Code)
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/realtobi999/GO_BankDemoApi/src/core/ports"
+)
+
+func Test_Repository_WithinTx_CommitsOnSuccess(t *testing.T) {
+	customer := NewTestCustomer()
+	account := NewTestAccount(customer.ID)
+
+	db := NewTestDatabase()
+	NewTestServer(db)
+
+	err := db.WithinTx(func(repositories ports.IRepositories) error {
+		if _, err := repositories.CreateCustomer(customer); err != nil {
+			return err
+		}
+		_, err := repositories.CreateAccount(account)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertDatabaseHas(t, "customers", "id", customer.ID.String(), db)
+	assertDatabaseHas(t, "accounts", "id", account.ID.String(), db)
+}
+
+func Test_Repository_WithinTx_RollsBackOnError(t *testing.T) {
+	customer := NewTestCustomer()
+	account := NewTestAccount(customer.ID)
+
+	db := NewTestDatabase()
+	NewTestServer(db)
+
+	err := db.WithinTx(func(repositories ports.IRepositories) error {
+		if _, err := repositories.CreateCustomer(customer); err != nil {
+			return err
+		}
+		if _, err := repositories.CreateAccount(account); err != nil {
+			return err
+		}
+		return errors.New("crash between the writes")
+	})
+	assertEqual(t, "crash between the writes", err.Error())
+
+	assertDatabaseMissing(t, "customers", "id", customer.ID.String(), db)
+	assertDatabaseMissing(t, "accounts", "id", account.ID.String(), db)
+}
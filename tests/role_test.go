@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/clock"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/web"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/services/auth"
+)
+
+// NewTestAuthServer returns a server authenticating requests without a database.
+func NewTestAuthServer() *web.Server {
+	server := web.NewServer(":8080", chi.NewMux())
+	server.AuthService = auth.NewAuthService(nil, nil, nil, nil, NewTestKeySet(), clock.NewSystemClock())
+	server.AdminToken = TEST_ADMIN_TOKEN
+
+	return server
+}
+
+func Test_Role_Permissions_Work(t *testing.T) {
+	assertEqual(t, false, domain.ROLE_CUSTOMER.Can(domain.PERMISSION_READ_CUSTOMERS))
+	assertEqual(t, true, domain.ROLE_TELLER.Can(domain.PERMISSION_REVERSE_TRANSACTIONS))
+	assertEqual(t, true, domain.ROLE_AUDITOR.Can(domain.PERMISSION_READ_TRANSACTIONS))
+	assertEqual(t, false, domain.ROLE_AUDITOR.Can(domain.PERMISSION_REVERSE_TRANSACTIONS))
+	assertEqual(t, true, domain.ROLE_ADMIN.Can(domain.PERMISSION_MANAGE_ROLES))
+	assertEqual(t, false, domain.Role("root").Can(domain.PERMISSION_READ_CUSTOMERS))
+
+	role, err := domain.ParseRole("auditor")
+	assertEqual(t, nil, err)
+	assertEqual(t, domain.ROLE_AUDITOR, role)
+
+	_, err = domain.ParseRole("root")
+	assertNotEqual(t, nil, err)
+
+	// Tokens issued before roles existed belong to plain customers
+	assertEqual(t, domain.ROLE_CUSTOMER, domain.Claims{}.CustomerRole())
+}
+
+func Test_Middleware_RequirePermission_Works(t *testing.T) {
+	server := NewTestAuthServer()
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate, server.RequirePermission(domain.PERMISSION_READ_CUSTOMERS)).Get("/api/customer", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		token    string
+		expected int
+	}{
+		{"", http.StatusUnauthorized},
+		{NewTestAccessToken(server, uuid.New()), http.StatusForbidden},
+		{NewTestRoleAccessToken(server, uuid.New(), domain.ROLE_AUDITOR), http.StatusNoContent},
+		{NewTestRoleAccessToken(server, uuid.New(), domain.ROLE_TELLER), http.StatusNoContent},
+		{TEST_ADMIN_TOKEN, http.StatusNoContent},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/api/customer", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assertEqual(t, c.expected, recorder.Code)
+	}
+}
+
+func Test_Middleware_CustomerOrPermission_Works(t *testing.T) {
+	server := NewTestAuthServer()
+	customerID := uuid.New()
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate, server.CustomerOrPermission(domain.PERMISSION_READ_CUSTOMERS)).Get("/api/customer/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request := func(path, token string) int {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Customers see themselves but nobody else, staff see everyone
+	assertEqual(t, http.StatusNoContent, request("/api/customer/"+customerID.String(), NewTestAccessToken(server, customerID)))
+	assertEqual(t, http.StatusForbidden, request("/api/customer/"+uuid.NewString(), NewTestAccessToken(server, customerID)))
+	assertEqual(t, http.StatusNoContent, request("/api/customer/"+uuid.NewString(), NewTestRoleAccessToken(server, customerID, domain.ROLE_AUDITOR)))
+}
+
+func Test_Middleware_OwnAccountsOrPermission_ScopesCustomers(t *testing.T) {
+	server := NewTestAuthServer()
+	customerID := uuid.New()
+
+	var filter string
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate, server.OwnAccountsOrPermission(domain.PERMISSION_READ_ACCOUNTS)).Get("/api/account", func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("customer_id")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request := func(path, token string) int {
+		filter = ""
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Customers are limited to their own accounts
+	assertEqual(t, http.StatusNoContent, request("/api/account?limit=5", NewTestAccessToken(server, customerID)))
+	assertEqual(t, customerID.String(), filter)
+
+	assertEqual(t, http.StatusForbidden, request("/api/account?customer_id="+uuid.NewString(), NewTestAccessToken(server, customerID)))
+
+	// Staff can list the accounts of every customer
+	assertEqual(t, http.StatusNoContent, request("/api/account", NewTestRoleAccessToken(server, customerID, domain.ROLE_TELLER)))
+	assertEqual(t, "", filter)
+}
+
+func Test_Middleware_TransactionPartyOrPermission_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+	stranger := NewTestCustomer()
+	account1 := NewTestAccount(customer1.ID)
+	account2 := NewTestAccount(customer2.ID)
+	transaction := NewTestTransaction(account1.ID, account2.ID)
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+	db.CreateCustomer(stranger)
+	db.CreateAccount(account1)
+	db.CreateAccount(account2)
+	db.CreateTransaction(transaction)
+
+	request := func(token string) int {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/transaction/%s", transaction.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Both the sender and the receiver can see the transaction
+	assertEqual(t, http.StatusOK, request(NewTestAccessToken(server, customer1.ID)))
+	assertEqual(t, http.StatusOK, request(NewTestAccessToken(server, customer2.ID)))
+	assertEqual(t, http.StatusForbidden, request(NewTestAccessToken(server, stranger.ID)))
+	assertEqual(t, http.StatusOK, request(NewTestRoleAccessToken(server, stranger.ID, domain.ROLE_AUDITOR)))
+}
+
+func Test_Customer_SetRole_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	customer, err := server.CustomerService.Create(domain.CreateCustomerRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Birthday:  NewTestCustomer().Birthday,
+		Email:     "john@example.com",
+		Phone:     "123-456-7890",
+		State:     "CA",
+		Address:   "123 Main St",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setRole := func(token string, role domain.Role) int {
+		body, _ := json.Marshal(domain.UpdateRoleRequest{Role: role})
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/admin/customer/%s/role", customer.ID), bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Customers cannot promote themselves
+	assertEqual(t, http.StatusForbidden, setRole(NewTestAccessToken(server, customer.ID), domain.ROLE_ADMIN))
+	assertEqual(t, http.StatusBadRequest, setRole(TEST_ADMIN_TOKEN, domain.Role("root")))
+	assertEqual(t, http.StatusOK, setRole(TEST_ADMIN_TOKEN, domain.ROLE_TELLER))
+
+	// The role is part of the access tokens issued from now on
+	pair, err := server.AuthService.Login(domain.LoginRequest{CustomerID: customer.ID, Token: customer.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := server.AuthService.Verify(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.ROLE_TELLER, claims.CustomerRole())
+
+	req, _ := http.NewRequest("GET", "/api/customer", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+
+	// An admin token identifies no customer, so it cannot act as one
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/customer/%s", customer.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func Test_Middleware_RoleFromContext_IsSet(t *testing.T) {
+	server := NewTestAuthServer()
+
+	router := chi.NewRouter()
+	router.With(server.Authenticate).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		role, ok := handlers.RoleFromContext(r.Context())
+		assertEqual(t, true, ok)
+		assertEqual(t, domain.ROLE_ADMIN, role)
+
+		_, ok = handlers.CustomerIDFromContext(r.Context())
+		assertEqual(t, false, ok)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusNoContent, recorder.Code)
+}
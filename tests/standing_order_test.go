@@ -0,0 +1,365 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/realtobi999/GO_BankDemoApi/src/adapters/handlers"
+	"github.com/realtobi999/GO_BankDemoApi/src/core/domain"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nextDays returns the first n days the rule falls on, starting on start.
+func nextDays(t *testing.T, rule string, start time.Time, n int) []time.Time {
+	t.Helper()
+
+	recurrence, err := domain.ParseRecurrence(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var days []time.Time
+	for day := start.AddDate(0, 0, -1); len(days) < n; {
+		if day = recurrence.Next(start, day); day.IsZero() {
+			break
+		}
+		days = append(days, day)
+	}
+
+	return days
+}
+
+func Test_StandingOrder_Recurrence_Presets(t *testing.T) {
+	start := date(2026, time.January, 30) // A friday
+
+	assertEqual(t, []time.Time{date(2026, 1, 30), date(2026, 1, 31), date(2026, 2, 1)}, nextDays(t, "FREQ=DAILY", start, 3))
+	assertEqual(t, []time.Time{date(2026, 1, 30), date(2026, 2, 6), date(2026, 2, 13)}, nextDays(t, "FREQ=WEEKLY", start, 3))
+
+	// The 30th falls on the last day of february instead of skipping it
+	assertEqual(t, []time.Time{date(2026, 1, 30), date(2026, 2, 28), date(2026, 3, 30)}, nextDays(t, "FREQ=MONTHLY", start, 3))
+}
+
+func Test_StandingOrder_Recurrence_CustomRules(t *testing.T) {
+	start := date(2026, time.January, 1)
+
+	assertEqual(t, []time.Time{date(2026, 1, 30), date(2026, 2, 27), date(2026, 3, 27)}, nextDays(t, "RRULE:FREQ=MONTHLY;BYDAY=-1FR", start, 3))
+	assertEqual(t, []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31)}, nextDays(t, "FREQ=MONTHLY;BYMONTHDAY=-1", start, 3))
+	assertEqual(t, []time.Time{date(2026, 1, 1), date(2026, 1, 15), date(2026, 1, 29)}, nextDays(t, "FREQ=WEEKLY;INTERVAL=2", start, 3))
+	assertEqual(t, []time.Time{date(2026, 1, 5), date(2026, 1, 7), date(2026, 1, 12)}, nextDays(t, "freq=weekly;byday=MO,WE", start, 3))
+	assertEqual(t, []time.Time{date(2026, 4, 1), date(2026, 10, 1)}, nextDays(t, "FREQ=MONTHLY;BYMONTH=4,10;COUNT=2", start, 3))
+	assertEqual(t, []time.Time{date(2026, 1, 1), date(2026, 4, 1)}, nextDays(t, "FREQ=MONTHLY;INTERVAL=3;UNTIL=20260630", start, 3))
+
+	for _, rule := range []string{"", "BYDAY=MO", "FREQ=HOURLY", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=WEEKLY;BYMONTHDAY=1", "FREQ=DAILY;COUNT=2;UNTIL=20261231", "FREQ=MONTHLY;BYMONTHDAY=32", "FREQ=DAILY;INTERVAL=0"} {
+		if _, err := domain.ParseRecurrence(rule); err == nil {
+			t.Errorf("expected %q to be rejected", rule)
+		}
+	}
+}
+
+func Test_StandingOrder_Retry_FollowsThePolicy(t *testing.T) {
+	policy, err := domain.ParseRetryPolicy("2/24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.RetryPolicy{Retries: 2, Interval: 24 * time.Hour}, policy)
+
+	recurrence, _ := domain.ParseRecurrence("FREQ=MONTHLY")
+	order := domain.StandingOrder{
+		Recurrence:  recurrence,
+		StartDate:   date(2026, 1, 1),
+		NextDueDate: date(2026, 1, 1),
+		Status:      domain.StandingOrderActive,
+	}
+
+	at := date(2026, 1, 1).Add(time.Hour)
+	assertEqual(t, true, order.Retry(policy, at))
+	assertEqual(t, true, order.Retry(policy, order.NextAttemptAt))
+	assertEqual(t, false, order.Retry(policy, order.NextAttemptAt))
+	assertEqual(t, 2, order.Attempts)
+	assertEqual(t, at.Add(48*time.Hour), order.NextAttemptAt)
+
+	order.Advance()
+	assertEqual(t, 0, order.Attempts)
+	assertEqual(t, date(2026, 2, 1), order.NextDueDate)
+	assertEqual(t, date(2026, 2, 1), order.NextAttemptAt)
+
+	// A daily order never retries into the next day
+	order.Recurrence, _ = domain.ParseRecurrence("FREQ=DAILY")
+	assertEqual(t, false, order.Retry(policy, order.NextAttemptAt))
+
+	if _, err := domain.ParseRetryPolicy("3"); err == nil {
+		t.Error("expected a policy without an interval to be rejected")
+	}
+}
+
+func Test_StandingOrder_Create_Works(t *testing.T) {
+	db := NewTestDatabase()
+	server, _ := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "1000")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	body := fmt.Sprintf(`{"ReceiverAccountID": "%s", "Amount": 250, "Frequency": "CUSTOM", "Rule": "FREQ=MONTHLY;BYMONTHDAY=1", "StartDate": "2026-02-01T00:00:00Z"}`, receiver.ID)
+	url := fmt.Sprintf("/api/customer/%s/account/%s/standing-orders", customer.ID, sender.ID)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+
+	router := chi.NewRouter()
+	router.Post("/api/customer/{customer_id}/account/{account_id}/standing-orders", handlers.NewStandingOrderHandler(server.StandingOrderService).Create)
+	router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	location := recorder.Header().Get("Location")
+	id, err := uuid.Parse(location[strings.LastIndex(location, "/")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := server.StandingOrderService.Get(sender.ID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "250.00", order.Amount.String())
+	assertEqual(t, date(2026, 2, 1), order.NextDueDate)
+	assertEqual(t, domain.StandingOrderActive, order.Status)
+
+	// Orders are only found through their own account
+	if _, err := server.StandingOrderService.Get(receiver.ID, id); err == nil {
+		t.Error("expected the order not to be found through another account")
+	}
+}
+
+func Test_StandingOrder_Create_RejectsStartInThePast(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "1000")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	_, err := server.StandingOrderService.Create(sender.ID, domain.CreateStandingOrderRequest{
+		ReceiverAccountID: receiver.ID,
+		Amount:            "10",
+		Frequency:         domain.StandingOrderDaily,
+		StartDate:         clock.Now().AddDate(0, 0, -1),
+	})
+
+	assertEqual(t, "Error bad request: StartDate cannot be in the past", err.Error())
+}
+
+func Test_StandingOrder_Execute_PaysRentOnTheFirst(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "1000")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	order, err := server.StandingOrderService.Create(sender.ID, domain.CreateStandingOrderRequest{
+		ReceiverAccountID: receiver.ID,
+		Amount:            "300",
+		Frequency:         domain.StandingOrderMonthly,
+		StartDate:         date(2026, 2, 1),
+		EndDate:           date(2026, 4, 30),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is due before the 1st
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The scheduler runs every day of the next four months
+	for range 120 {
+		clock.AdvanceDays(1)
+
+		if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, _ := server.AccountService.Get(receiver.ID)
+	assertEqual(t, "900.00", updated.Balance.String())
+
+	order, _ = server.StandingOrderService.Get(sender.ID, order.ID)
+	assertEqual(t, domain.StandingOrderCompleted, order.Status)
+
+	executions, err := server.StandingOrderService.Executions(sender.ID, order.ID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, 3, len(executions))
+	for _, execution := range executions {
+		assertEqual(t, domain.ExecutionSucceeded, execution.Status)
+		assertEqual(t, 1, execution.DueDate.Day())
+		assertDatabaseHas(t, "transactions", "id", execution.TransactionID.String(), db)
+	}
+}
+
+func Test_StandingOrder_Execute_RetriesWhenFundsAreShort(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "100")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	order, err := server.StandingOrderService.Create(sender.ID, domain.CreateStandingOrderRequest{
+		ReceiverAccountID: receiver.ID,
+		Amount:            "300",
+		Frequency:         domain.StandingOrderMonthly,
+		StartDate:         clock.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	order, _ = server.StandingOrderService.Get(sender.ID, order.ID)
+	assertEqual(t, 1, order.Attempts)
+	assertEqual(t, true, clock.Now().Add(TEST_RETRY_POLICY.Interval).Equal(order.NextAttemptAt))
+
+	// Running again before the retry is due doesnt attempt it
+	clock.Advance(time.Hour)
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Salary comes in before the retry
+	if _, err := server.TransactionService.Deposit(sender.ID, domain.CreateDepositRequest{Amount: "500", Channel: domain.ChannelCash}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.AdvanceDays(1)
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := server.AccountService.Get(receiver.ID)
+	assertEqual(t, "300.00", updated.Balance.String())
+
+	order, _ = server.StandingOrderService.Get(sender.ID, order.ID)
+	assertEqual(t, 0, order.Attempts)
+	assertEqual(t, date(2026, 2, 15), order.NextDueDate)
+
+	executions, _ := server.StandingOrderService.Executions(sender.ID, order.ID, 10, 0)
+	assertEqual(t, 2, len(executions))
+	assertEqual(t, domain.ExecutionSucceeded, executions[0].Status)
+	assertEqual(t, domain.ExecutionRetrying, executions[1].Status)
+	assertEqual(t, domain.BadRequestError(domain.ErrInsufficientFunds).Error(), executions[1].Error)
+}
+
+func Test_StandingOrder_Cancel_StopsTransfers(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "100")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	order, err := server.StandingOrderService.Create(sender.ID, domain.CreateStandingOrderRequest{
+		ReceiverAccountID: receiver.ID,
+		Amount:            "10",
+		Frequency:         domain.StandingOrderDaily,
+		StartDate:         clock.Now().AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err = server.StandingOrderService.Cancel(sender.ID, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.StandingOrderCancelled, order.Status)
+
+	clock.AdvanceDays(3)
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := server.AccountService.Get(receiver.ID)
+	assertEqual(t, "0.00", updated.Balance.String())
+
+	_, err = server.StandingOrderService.Cancel(sender.ID, order.ID)
+	assertEqual(t, "Error conflict: Standing order is already cancelled", err.Error())
+}
+
+func Test_StandingOrder_CloseAccount_CancelsTheOrders(t *testing.T) {
+	db := NewTestDatabase()
+	server, clock := NewTestClockServer(db)
+	defer db.ClearAllTables()
+
+	customer := NewTestCustomer()
+	db.CreateCustomer(customer)
+
+	sender, _ := NewTestFundedAccount(server, customer.ID, "USD", "100")
+	receiver, _ := server.AccountService.Create(customer.ID, domain.CreateAccountRequest{Type: 1, Currency: "USD"})
+
+	order, err := server.StandingOrderService.Create(sender.ID, domain.CreateStandingOrderRequest{
+		ReceiverAccountID: receiver.ID,
+		Amount:            "10",
+		Frequency:         domain.StandingOrderDaily,
+		StartDate:         clock.Now().AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.AccountService.Close(sender.ID, domain.CloseAccountRequest{SweepAccountID: receiver.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err = server.StandingOrderService.Get(sender.ID, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.StandingOrderCancelled, order.Status)
+	assertEqual(t, true, order.NextDueDate.IsZero())
+
+	// No attempt is made from the closed account anymore
+	clock.AdvanceDays(3)
+	if err := server.StandingOrderService.Execute(clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	executions, _ := server.StandingOrderService.Executions(sender.ID, order.ID, 10, 0)
+	assertEqual(t, 0, len(executions))
+}
@@ -2,11 +2,15 @@ package tests
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -296,8 +300,9 @@ func Test_Transaction_Create_Works(t *testing.T) {
 	senderAcc := NewTestAccount(customer1.ID)
 	receiverAcc := NewTestAccount(customer2.ID)
 
-	senderAcc.Balance = 1000
+	senderAcc.Balance = domain.MoneyFromMajor(1000, "USD")
 	receiverAcc.Currency = "EUR"
+	receiverAcc.Balance = domain.NewMoney(0, "EUR")
 
 	db := NewTestDatabase()
 	server := NewTestServer(db)
@@ -348,8 +353,9 @@ func Test_Transaction_Create_Works(t *testing.T) {
 	assertEqual(t, domain.Currency("USD"), transaction.CurrencyPair.From)
 	assertEqual(t, receiverAcc.Currency, transaction.CurrencyPair.To)
 
-	assertDatabaseHas(t, "accounts", "balance", domain.ConversionRateMap[transaction.CurrencyPair]*transaction.Amount, db)
-	assertDatabaseHas(t, "accounts", "balance", senderAcc.Balance-transaction.Amount, db)
+	assertEqual(t, "0.9369", transaction.ExchangeRate.FloatString(4))
+	assertDatabaseHas(t, "accounts", "balance", transaction.Amount.Convert("EUR", transaction.ExchangeRate).String(), db)
+	assertDatabaseHas(t, "accounts", "balance", senderAcc.Balance.Sub(transaction.Amount).String(), db)
 }
 
 func Test_Transaction_Create_GivesErrorWhenSenderDoesntHaveEnoughBalance(t *testing.T) {
@@ -367,7 +373,7 @@ func Test_Transaction_Create_GivesErrorWhenSenderDoesntHaveEnoughBalance(t *test
 	db.CreateAccount(sender)
 	db.CreateAccount(receiver)
 
-	sender.Balance = 0
+	sender.Balance = domain.NewMoney(0, "USD")
 
 	body := fmt.Sprintf(`
 	{
@@ -454,10 +460,10 @@ func Test_Transactions_Create_GivesErrorWhenAmountIsBeyondTheMaximum(t *testing.
 	sender := NewTestAccount(customer1.ID)
 	receiver := NewTestAccount(customer2.ID)
 
-	sender.Balance = 9999999999999;
+	sender.Balance = domain.MoneyFromMajor(9999999999999, "USD")
 
 	transaction := NewTestTransaction(sender.ID, receiver.ID)
-	transaction.Amount = domain.MAX_TRANSFER_AMOUNT * 2
+	transaction.Amount = domain.MoneyFromMajor(domain.MAX_TRANSFER_AMOUNT * 2, "USD")
 
 	db := NewTestDatabase()
 	server := NewTestServer(db)
@@ -473,7 +479,7 @@ func Test_Transactions_Create_GivesErrorWhenAmountIsBeyondTheMaximum(t *testing.
  	 	"Amount": %v,
 	  	"Currency": "USD"
 	}
-	`, receiver.ID.String(), transaction.Amount)
+	`, receiver.ID.String(), transaction.Amount.String())
 
 	url := fmt.Sprintf("/api/%s/account/%s/transaction", customer1.ID.String(), sender.ID.String())
 
@@ -499,4 +505,358 @@ func Test_Transactions_Create_GivesErrorWhenAmountIsBeyondTheMaximum(t *testing.
 	}
 
 	assertEqual(t, "Sending amount must not be bigger than: 10000", rBody.Errors[0])
-}
\ No newline at end of file
+}
+func Test_Transaction_Create_IsConcurrencySafe(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	sender := NewTestAccount(customer1.ID)
+	receiver := NewTestAccount(customer2.ID)
+
+	sender.Balance = domain.MoneyFromMajor(100, "USD")
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	// 50 transfers of 10 USD from an account holding 100 USD, only 10 of them can succeed
+	var wg sync.WaitGroup
+	var succeeded atomic.Int64
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := server.TransactionService.Create(domain.CreateTransactionRequest{
+				SenderAccountID:   sender.ID,
+				ReceiverAccountID: receiver.ID,
+				Amount:            "10",
+				Currency:          "USD",
+			})
+			if err == nil {
+				succeeded.Add(1)
+			} else if !errors.Is(err, domain.ErrBadRequest) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assertEqual(t, int64(10), succeeded.Load())
+
+	senderAfter, err := server.AccountService.Get(sender.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiverAfter, err := server.AccountService.Get(receiver.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, "0.00", senderAfter.Balance.String())
+	assertEqual(t, "100.00", receiverAfter.Balance.String())
+}
+
+func Test_Transaction_Status_TransitionsAreEnforced(t *testing.T) {
+	transaction := NewTestTransaction(uuid.New(), uuid.New())
+	transaction.Status = domain.TransactionPending
+
+	assertEqual(t, nil, transaction.TransitionTo(domain.TransactionPosted))
+	assertEqual(t, nil, transaction.TransitionTo(domain.TransactionReversed))
+	assertNotEqual(t, nil, transaction.TransitionTo(domain.TransactionPosted))
+
+	failed := NewTestTransaction(uuid.New(), uuid.New())
+	failed.Status = domain.TransactionPending
+
+	assertEqual(t, nil, failed.TransitionTo(domain.TransactionFailed))
+	assertNotEqual(t, nil, failed.TransitionTo(domain.TransactionReversed))
+
+	_, err := domain.ParseTransactionStatus("settled")
+	assertNotEqual(t, nil, err)
+}
+
+func Test_Transaction_Create_RecordsRejectedTransactionAsFailed(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	sender := NewTestAccount(customer1.ID)
+	receiver := NewTestAccount(customer2.ID)
+
+	sender.Balance = domain.MoneyFromMajor(100, "USD")
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	posted, err := server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "60",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, domain.TransactionPosted, posted.Status)
+
+	_, err = server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "60",
+	})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+
+	req, err := http.NewRequest("GET", "/api/transaction?status=failed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(handlers.NewTransactionHandler(server.TransactionService).Index)
+	handler.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusOK, recorder.Code)
+
+	body := struct {
+		Data []domain.TransactionDTO `json:"data"`
+	}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, 1, len(body.Data))
+	assertEqual(t, string(domain.TransactionFailed), body.Data[0].Status)
+	assertEqual(t, true, strings.Contains(body.Data[0].FailureReason, "doesnt have enough balance"))
+	assertDatabaseHas(t, "accounts", "balance", "40.00", db)
+}
+
+func Test_Transaction_NewReversal_UsesTheOriginalRate(t *testing.T) {
+	original := NewTestTransaction(uuid.New(), uuid.New())
+	original.Amount = domain.MoneyFromMajor(100, "USD")
+	original.ExchangeRate, _ = domain.ParseRate("0.9369")
+
+	assertEqual(t, "93.69", original.Credited().String())
+
+	reversal := domain.NewReversal(original, domain.NewMoney(1874, "EUR"), time.Now())
+
+	assertEqual(t, original.ReceiverAccountID, reversal.SenderAccountID)
+	assertEqual(t, domain.NewCurrencyPair("EUR", "USD"), reversal.CurrencyPair)
+	assertEqual(t, "20.00", reversal.Credited().String())
+	assertEqual(t, original.ID, reversal.ReversalOf)
+}
+
+func Test_Transaction_Reverse_Works(t *testing.T) {
+	customer1 := NewTestCustomer()
+	customer2 := NewTestCustomer()
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer1)
+	db.CreateCustomer(customer2)
+
+	sender, err := NewTestFundedAccount(server, customer1.ID, "USD", "1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := server.AccountService.Create(customer2.ID, domain.CreateAccountRequest{Type: 1, Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := server.TransactionService.Create(domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "100",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := fmt.Sprintf("/api/transaction/%s/reversal", original.ID.String())
+
+	// A rejected reversal is recorded as failed but doesnt count as one of the reversals
+	server.AccountService.Freeze(receiver.ID)
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusConflict, recorder.Code)
+	server.AccountService.Unfreeze(receiver.ID)
+
+	// Partial refund of the 93.69 EUR the receiver got
+	req, _ = http.NewRequest("POST", url, strings.NewReader(`{"Amount": 46.85}`))
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	// The rest of it
+	req, _ = http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusCreated, recorder.Code)
+
+	reversed, err := server.TransactionService.Get(original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, domain.TransactionReversed, reversed.Status)
+	assertEqual(t, 2, len(reversed.Reversals))
+	assertEqual(t, "93.69", reversed.Refunded.String())
+
+	reversal, err := server.TransactionService.Get(reversed.Reversals[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, original.ID, reversal.ReversalOf)
+
+	receiverAfter, _ := server.AccountService.Get(receiver.ID)
+	assertEqual(t, "0.00", receiverAfter.Balance.String())
+
+	// Nothing is left to reverse
+	req, _ = http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+TEST_ADMIN_TOKEN)
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+
+	assertEqual(t, http.StatusConflict, recorder.Code)
+
+	for _, accountID := range []uuid.UUID{sender.ID, receiver.ID} {
+		balance, err := server.LedgerService.Verify(accountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, true, balance.IsBalanced())
+	}
+}
+
+func Test_Transaction_Create_GivesErrorWhenAccountIsNotActive(t *testing.T) {
+	customer := NewTestCustomer()
+	sender := NewTestAccount(customer.ID)
+	sender.Balance = domain.NewMoney(100_00, "USD")
+	receiver := NewTestAccount(customer.ID)
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(sender)
+	db.CreateAccount(receiver)
+
+	body := domain.CreateTransactionRequest{
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Amount:            "10",
+	}
+
+	_, err := server.AccountService.Freeze(receiver.ID)
+	assertEqual(t, nil, err)
+
+	_, err = server.TransactionService.Create(body)
+	assertEqual(t, true, errors.Is(err, domain.ErrConflict))
+
+	_, err = server.AccountService.Unfreeze(receiver.ID)
+	assertEqual(t, nil, err)
+
+	_, err = server.TransactionService.Create(body)
+	assertEqual(t, nil, err)
+}
+
+func Test_Transaction_Deposit_GoesThroughTheChannelAccount(t *testing.T) {
+	account := NewTestAccount(uuid.New())
+
+	deposit := domain.NewDeposit(account, domain.MoneyFromMajor(50, "USD"), domain.ChannelCard, time.Now())
+	assertEqual(t, (*domain.ValidationErrors)(nil), deposit.Validate())
+
+	journal := domain.NewTransferJournal(deposit, deposit.Amount)
+
+	assertEqual(t, domain.JournalDeposit, journal.Kind)
+	assertEqual(t, domain.SystemCardClearing, journal.Entries[0].SystemAccount)
+	assertEqual(t, domain.Debit, journal.Entries[0].Side)
+	assertEqual(t, account.ID, journal.Entries[1].AccountID)
+	assertEqual(t, (*domain.ValidationErrors)(nil), journal.Validate())
+
+	deposit.SenderAccountID = uuid.New()
+	deposit.Channel = "PIGEON"
+
+	assertEqual(t, []string{"Deposits and withdrawals cant have a counterparty account", "Unknown channel PIGEON"}, deposit.Validate().Errors)
+}
+
+func Test_Transaction_DepositAndWithdrawal_Works(t *testing.T) {
+	customer := NewTestCustomer()
+	account := NewTestAccount(customer.ID)
+
+	db := NewTestDatabase()
+	server := NewTestServer(db)
+	server.LoadRoutes()
+	defer db.ClearAllTables()
+
+	db.CreateCustomer(customer)
+	db.CreateAccount(account)
+
+	send := func(url, token, body string) int {
+		req, _ := http.NewRequest("POST", url, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+
+		return recorder.Code
+	}
+
+	token := NewTestAccessToken(server, customer.ID)
+	deposit := fmt.Sprintf("/api/admin/account/%s/deposit", account.ID)
+	withdrawal := fmt.Sprintf("/api/customer/%s/account/%s/withdrawal", customer.ID, account.ID)
+
+	// Only the staff books money coming into the bank
+	assertEqual(t, http.StatusForbidden, send(deposit, token, `{"Amount": 100, "Channel": "cash"}`))
+
+	assertEqual(t, http.StatusCreated, send(deposit, TEST_ADMIN_TOKEN, `{"Amount": 100, "Channel": "cash"}`))
+	assertEqual(t, http.StatusCreated, send(withdrawal, token, `{"Amount": 30, "Channel": "CARD"}`))
+	assertEqual(t, http.StatusBadRequest, send(withdrawal, token, `{"Amount": 1000, "Channel": "EXTERNAL"}`))
+	assertEqual(t, http.StatusBadRequest, send(deposit, TEST_ADMIN_TOKEN, `{"Amount": 10, "Channel": "PIGEON"}`))
+
+	updated, err := server.AccountService.Get(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "70.00", updated.Balance.String())
+
+	balance, err := server.LedgerService.Verify(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, true, balance.IsBalanced())
+
+	transactions, err := server.TransactionService.Index(account.ID, "", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, 3, len(transactions))
+	assertEqual(t, domain.TransactionDeposit, transactions[0].Type)
+	assertEqual(t, uuid.Nil, transactions[0].SenderAccountID)
+	assertEqual(t, domain.TransactionWithdrawal, transactions[1].Type)
+	assertEqual(t, domain.TransactionFailed, transactions[2].Status)
+
+	// Only transfers can be sent back
+	_, err = server.TransactionService.Reverse(transactions[0].ID, domain.CreateReversalRequest{})
+	assertEqual(t, true, errors.Is(err, domain.ErrBadRequest))
+}